@@ -2,7 +2,10 @@ package main
 
 import (
 	_ "api/internal/load_config"
+	loggergrpc "api/internal/loggerGRPC"
+	"api/internal/messages"
 	"api/internal/router"
+	"api/internal/tracing"
 	"context"
 	"log"
 	"net/http"
@@ -16,6 +19,25 @@ import (
 
 func main() {
 	apiPort := viper.GetString("api.port")
+
+	// Экспорт трасс в коллектор OpenTelemetry, если он указан
+	var exporter *tracing.Exporter
+	if endpoint := viper.GetString("tracing.endpoint"); endpoint != "" {
+		exporter = tracing.NewExporter(tracing.Config{
+			Endpoint:    endpoint,
+			ServiceName: viper.GetString("tracing.serviceName"),
+			BatchSize:   viper.GetInt("tracing.batchSize"),
+			Interval:    viper.GetDuration("tracing.interval"),
+			OnError: func(err error) {
+				loggergrpc.LC.LogError(messages.ServiceTracing, messages.LogErrExportSpans, map[string]string{
+					messages.LogDetails: err.Error(),
+				})
+			},
+		})
+		tracing.SetExporter(exporter)
+		log.Printf("Exporting traces to %s", endpoint)
+	}
+
 	router := router.CreateNewRouter()
 
 	srv := &http.Server{
@@ -42,5 +64,11 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	if exporter != nil {
+		if err := exporter.Shutdown(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}
+
 	log.Println("Server gracefully stopped")
 }
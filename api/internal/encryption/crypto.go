@@ -40,7 +40,7 @@ func GetCryptoParams(w http.ResponseWriter, r *http.Request) {
 		messages.CryptoParamGenerator: generator.String(),
 	}
 
-	loggergrpc.LC.LogInfo(messages.ServiceEncryption, messages.LogStatusParamsSent, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceEncryption, messages.LogStatusParamsSent, map[string]string{
 		messages.LogPrime:     strPrime,
 		messages.LogGenerator: generator.String(),
 	})
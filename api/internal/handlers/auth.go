@@ -44,7 +44,7 @@ func (p *AuthHandler) EncryptionKey(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrParamsRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrParamsRequest, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadRequest, nil)
@@ -53,7 +53,7 @@ func (p *AuthHandler) EncryptionKey(w http.ResponseWriter, r *http.Request) {
 
 	secret, err := encryption.DeriveSharedKeyHex(req.ClientPublic)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrKeyDerivation, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrKeyDerivation, map[string]string{
 			messages.LogDetails: err.Error(),
 			"client_pub":        req.ClientPublic,
 		})
@@ -64,7 +64,7 @@ func (p *AuthHandler) EncryptionKey(w http.ResponseWriter, r *http.Request) {
 	p.secret = secret
 
 	serverPublic := encryption.GetServerPublicKey()
-	loggergrpc.LC.LogInfo(messages.ServiceAuth, messages.LogStatusParamsSent, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceAuth, messages.LogStatusParamsSent, map[string]string{
 		"server_pub": serverPublic,
 	})
 
@@ -77,7 +77,7 @@ func (p *AuthHandler) EncryptionKey(w http.ResponseWriter, r *http.Request) {
 func (p *AuthHandler) LogIN(w http.ResponseWriter, r *http.Request) {
 	var requestData map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrParamsRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrParamsRequest, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadRequest, nil)
@@ -91,7 +91,7 @@ func (p *AuthHandler) LogIN(w http.ResponseWriter, r *http.Request) {
 
 	username, err := encryption.DecryptData(encryptedUsername, key)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrDecryption, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrDecryption, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrDecryption, nil)
@@ -100,7 +100,7 @@ func (p *AuthHandler) LogIN(w http.ResponseWriter, r *http.Request) {
 
 	password, err := encryption.DecryptData(encryptedPassword, key)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrDecryption, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrDecryption, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrDecryption, nil)
@@ -109,16 +109,16 @@ func (p *AuthHandler) LogIN(w http.ResponseWriter, r *http.Request) {
 
 	newPassword, err := encryption.EncryptData(password, string(serverSecretKey))
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrEncryption, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrEncryption, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrEncryption, nil)
 		return
 	}
 
-	userID, userRole, err := p.User.CheckPass(username, newPassword)
+	userID, userRole, err := p.User.CheckPass(r.Context(), username, newPassword)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrAuthFailed, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrAuthFailed, map[string]string{
 			messages.LogDetails:  err.Error(),
 			messages.LogUsername: username,
 		})
@@ -129,7 +129,7 @@ func (p *AuthHandler) LogIN(w http.ResponseWriter, r *http.Request) {
 	sessionID := uuid.New()
 	token, err := p.Token.GenerateJWT(sessionID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
 			messages.LogSessionID: sessionID.String(),
 			messages.LogDetails:   err.Error(),
 		})
@@ -137,9 +137,9 @@ func (p *AuthHandler) LogIN(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = p.Session.SetSession(sessionID, userID, userRole, sessionLifetime)
+	err = p.Session.SetSession(r.Context(), sessionID, userID, userRole, sessionLifetime)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
 			messages.LogSessionID: sessionID.String(),
 			messages.LogDetails:   err.Error(),
 		})
@@ -150,7 +150,7 @@ func (p *AuthHandler) LogIN(w http.ResponseWriter, r *http.Request) {
 	setCookie(w, messages.CookieAuthToken, token, true)
 	setCookie(w, messages.CookieUserRole, userRole, false)
 
-	loggergrpc.LC.LogInfo(messages.ServiceAuth, messages.LogStatusUserAuth, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceAuth, messages.LogStatusUserAuth, map[string]string{
 		messages.LogUserID:   userID.String(),
 		messages.LogUserRole: userRole,
 	})
@@ -161,7 +161,7 @@ func (p *AuthHandler) LogIN(w http.ResponseWriter, r *http.Request) {
 func (p *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var requestData map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrParamsRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrParamsRequest, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadRequest, nil)
@@ -176,7 +176,7 @@ func (p *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	username, err := encryption.DecryptData(encryptedUsername, key)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrDecryption, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrDecryption, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrDecryption, nil)
@@ -185,7 +185,7 @@ func (p *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	password, err := encryption.DecryptData(encryptedPassword, key)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrDecryption, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrDecryption, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrDecryption, nil)
@@ -194,16 +194,16 @@ func (p *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	newPassword, err := encryption.EncryptData(password, string(serverSecretKey))
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrEncryption, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrEncryption, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrEncryption, nil)
 		return
 	}
 
-	userID, err := p.User.CreateAccount(username, newPassword, role)
+	userID, err := p.User.CreateAccount(r.Context(), username, newPassword, role)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrDBQuery, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrDBQuery, map[string]string{
 			messages.LogDetails:  err.Error(),
 			messages.LogUsername: username,
 		})
@@ -212,7 +212,7 @@ func (p *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if userID == uuid.Nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrUserExists, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrUserExists, map[string]string{
 			messages.LogUsername: username,
 		})
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrUserExists, nil)
@@ -223,7 +223,7 @@ func (p *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	token, err := p.Token.GenerateJWT(sessionID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrTokenGeneration, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrTokenGeneration, map[string]string{
 			messages.LogSessionID: sessionID.String(),
 			messages.LogDetails:   err.Error(),
 		})
@@ -231,9 +231,9 @@ func (p *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = p.Session.SetSession(sessionID, userID, role, sessionLifetime)
+	err = p.Session.SetSession(r.Context(), sessionID, userID, role, sessionLifetime)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
 			messages.LogSessionID: sessionID.String(),
 			messages.LogDetails:   err.Error(),
 		})
@@ -245,14 +245,14 @@ func (p *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	setCookie(w, messages.CookieUserRole, role, false)
 
 	response.WriteAPIResponse(w, http.StatusOK, true, messages.StatusAuth, nil)
-	loggergrpc.LC.LogInfo(messages.ServiceAuth, messages.LogStatusUserAuth, map[string]string{messages.LogUserID: userID.String()})
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceAuth, messages.LogStatusUserAuth, map[string]string{messages.LogUserID: userID.String()})
 }
 
 // LogOUT завершает сессию пользователя
 func (p *AuthHandler) LogOUT(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie(messages.CookieAuthToken)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusOK, false, messages.ClientErrSessionExpired, nil)
@@ -261,7 +261,7 @@ func (p *AuthHandler) LogOUT(w http.ResponseWriter, r *http.Request) {
 
 	token, err := p.Token.ParseJWT(cookie.Value)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrSessionInvalid, map[string]string{
 			messages.LogSessionID: token.SessionID.String(),
 			messages.LogDetails:   err.Error(),
 		})
@@ -269,9 +269,9 @@ func (p *AuthHandler) LogOUT(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := p.Session.DeleteSession(token.SessionID)
+	userID, err := p.Session.DeleteSession(r.Context(), token.SessionID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceAuth, messages.LogErrSessionDelete, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceAuth, messages.LogErrSessionDelete, map[string]string{
 			messages.LogSessionID: token.SessionID.String(),
 			messages.LogDetails:   err.Error(),
 		})
@@ -283,7 +283,7 @@ func (p *AuthHandler) LogOUT(w http.ResponseWriter, r *http.Request) {
 	clearCookie(w, messages.CookieUserRole)
 
 	response.WriteAPIResponse(w, http.StatusOK, true, messages.StatusLogOut, nil)
-	loggergrpc.LC.LogInfo(messages.ServiceAuth, messages.LogStatusUserLogOut, map[string]string{messages.LogUserID: userID.String()})
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceAuth, messages.LogStatusUserLogOut, map[string]string{messages.LogUserID: userID.String()})
 }
 
 // setCookie устанавливает cookie с заданными параметрами
@@ -78,7 +78,7 @@ type createRoomRequest struct {
 func (h *ChatHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	var req createRoomRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		loggergrpc.LC.LogError(messages.ServiceChat, messages.LogErrDecodeRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceChat, messages.LogErrDecodeRequest, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadRequest, nil)
@@ -92,18 +92,18 @@ func (h *ChatHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err = h.User.FindUser(userID); err != nil {
+	if _, err = h.User.FindUser(r.Context(), userID); err != nil {
 		response.WriteAPIResponse(w, http.StatusNotFound, false, messages.ClientErrUserNotFound, nil)
 		return
 	}
-	if _, err = h.User.FindUser(otherID); err != nil {
+	if _, err = h.User.FindUser(r.Context(), otherID); err != nil {
 		response.WriteAPIResponse(w, http.StatusNotFound, false, messages.ClientErrUserNotFound, nil)
 		return
 	}
 
-	roomID, existed, err := h.Chat.CreateRoom(userID, otherID)
+	roomID, existed, err := h.Chat.CreateRoom(r.Context(), userID, otherID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceChat, messages.LogErrChatRoomCreate, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceChat, messages.LogErrChatRoomCreate, map[string]string{
 			messages.LogDetails: err.Error(),
 			messages.LogUserID:  userID.String(),
 			messages.LogOtherID: otherID.String(),
@@ -120,7 +120,7 @@ func (h *ChatHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, code, true, msg, map[string]string{messages.LogRoomID: roomID})
-	loggergrpc.LC.LogInfo(messages.ServiceChat, messages.LogStatusChatRoomCreated, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceChat, messages.LogStatusChatRoomCreated, map[string]string{
 		messages.LogRoomID:  roomID,
 		messages.LogUserID:  userID.String(),
 		messages.LogOtherID: otherID.String(),
@@ -131,7 +131,7 @@ func (h *ChatHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	roomID := r.URL.Query().Get(messages.ReqRoom)
 	if roomID == "" {
-		loggergrpc.LC.LogError(messages.ServiceChat, messages.LogErrNoRoomID, nil)
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceChat, messages.LogErrNoRoomID, nil)
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrNoRoomID, nil)
 		return
 	}
@@ -142,7 +142,7 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	history, err := h.Chat.History(roomID)
+	history, err := h.Chat.History(r.Context(), roomID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusForbidden, false, messages.ClientErrNoRoomAccess, nil)
 		return
@@ -150,7 +150,7 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceChat, messages.LogErrUpgradeConn, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceChat, messages.LogErrUpgradeConn, map[string]string{
 			messages.LogDetails: err.Error(),
 			messages.LogRoomID:  roomID,
 		})
@@ -162,7 +162,7 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	roomsMu.Lock()
 	room := rooms[roomID]
 	if room == nil {
-		loggergrpc.LC.LogInfo(messages.ServiceChat, messages.LogStatusRoomCreating, map[string]string{
+		loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceChat, messages.LogStatusRoomCreating, map[string]string{
 			messages.LogRoomID: roomID,
 		})
 		var u1, u2 uuid.UUID
@@ -184,7 +184,7 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	room.clientsLock.Lock()
 	for i, c := range room.clients {
 		if c.userID == currentUserID {
-			loggergrpc.LC.LogInfo(messages.ServiceChat, messages.LogStatusUserReconnected, map[string]string{
+			loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceChat, messages.LogStatusUserReconnected, map[string]string{
 				messages.LogRoomID: roomID,
 				messages.LogUserID: currentUserID.String(),
 			})
@@ -196,7 +196,7 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	room.clients = append(room.clients, client)
 	room.clientsLock.Unlock()
 
-	loggergrpc.LC.LogInfo(messages.ServiceChat, messages.LogStatusUserConnected, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceChat, messages.LogStatusUserConnected, map[string]string{
 		messages.LogRoomID: roomID,
 		messages.LogUserID: currentUserID.String(),
 	})
@@ -204,12 +204,12 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	// Обработка истории сообщений
 	for i, m := range history {
 		if m.Status == chatpb.MessageStatus_SENT && m.SenderID != currentUserID {
-			loggergrpc.LC.LogInfo(messages.ServiceChat, messages.LogStatusMessageDelivered, map[string]string{
+			loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceChat, messages.LogStatusMessageDelivered, map[string]string{
 				messages.LogRoomID:    roomID,
 				messages.LogUserID:    currentUserID.String(),
 				messages.LogMessageID: m.ID.String(),
 			})
-			_ = h.Chat.UpdateStatus(m.ID, chatpb.MessageStatus_DELIVERED)
+			_ = h.Chat.UpdateStatus(r.Context(), m.ID, chatpb.MessageStatus_DELIVERED)
 			history[i].Status = chatpb.MessageStatus_DELIVERED
 
 			room.clientsLock.Lock()
@@ -245,7 +245,7 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 		var incoming wsMessage
 		if err := conn.ReadJSON(&incoming); err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
-				loggergrpc.LC.LogError(messages.ServiceChat, messages.LogErrWSRead, map[string]string{
+				loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceChat, messages.LogErrWSRead, map[string]string{
 					messages.LogDetails: err.Error(),
 					messages.LogRoomID:  roomID,
 					messages.LogUserID:  currentUserID.String(),
@@ -268,8 +268,8 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 			Status:   chatpb.MessageStatus_SENT,
 		}
 
-		if err := h.Chat.SaveMessage(newMsg); err != nil {
-			loggergrpc.LC.LogError(messages.ServiceChat, messages.LogErrSaveMessage, map[string]string{
+		if err := h.Chat.SaveMessage(r.Context(), newMsg); err != nil {
+			loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceChat, messages.LogErrSaveMessage, map[string]string{
 				messages.LogDetails: err.Error(),
 				messages.LogRoomID:  roomID,
 				messages.LogUserID:  currentUserID.String(),
@@ -288,7 +288,7 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 			Status:   messages.ChatStatusSent,
 		})
 
-		loggergrpc.LC.LogInfo(messages.ServiceChat, messages.LogStatusMessageSent, map[string]string{
+		loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceChat, messages.LogStatusMessageSent, map[string]string{
 			messages.LogRoomID:    roomID,
 			messages.LogUserID:    currentUserID.String(),
 			messages.LogMessageID: newMsg.ID.String(),
@@ -310,14 +310,14 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 				IsSender: false,
 				Status:   messages.ChatStatusSent,
 			}); err == nil {
-				loggergrpc.LC.LogInfo(messages.ServiceChat, messages.LogStatusMessageDelivered, map[string]string{
+				loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceChat, messages.LogStatusMessageDelivered, map[string]string{
 					messages.LogRoomID:     roomID,
 					messages.LogUserID:     currentUserID.String(),
 					messages.LogReceiverID: c.userID.String(),
 					messages.LogMessageID:  newMsg.ID.String(),
 				})
 			} else {
-				loggergrpc.LC.LogError(messages.ServiceChat, messages.LogErrWSSend, map[string]string{
+				loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceChat, messages.LogErrWSSend, map[string]string{
 					messages.LogDetails:    err.Error(),
 					messages.LogRoomID:     roomID,
 					messages.LogUserID:     currentUserID.String(),
@@ -333,7 +333,7 @@ func (h *ChatHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	for i, c := range room.clients {
 		if c == client {
 			room.clients = append(room.clients[:i], room.clients[i+1:]...)
-			loggergrpc.LC.LogInfo(messages.ServiceChat, messages.LogStatusUserDisconnected, map[string]string{
+			loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceChat, messages.LogStatusUserDisconnected, map[string]string{
 				messages.LogRoomID: roomID,
 				messages.LogUserID: currentUserID.String(),
 			})
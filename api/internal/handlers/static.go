@@ -8,11 +8,26 @@ import (
 	"net/http"
 )
 
+// Заголовки Cache-Control для статики: по ним ответы кэширует балансировщик и браузер.
+// Страницы не зависят от пользователя, данные на них подгружаются запросами к /api/
+const (
+	pageCacheControl  = "public, max-age=60"
+	assetCacheControl = "public, max-age=300"
+)
+
+// CacheAssets добавляет Cache-Control к ответам с файлами из assets
+func CacheAssets(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", assetCacheControl)
+		next.ServeHTTP(w, r)
+	})
+}
+
 // serveHTML обрабатывает запрос на отдачу HTML страницы
 func serveHTML(w http.ResponseWriter, r *http.Request, filename string) {
 	tmpl, err := template.ParseFiles("assets/html/" + filename)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceStatic, messages.LogErrLoadTemplate, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceStatic, messages.LogErrLoadTemplate, map[string]string{
 			messages.LogDetails:  err.Error(),
 			messages.LogReqPath:  r.URL.Path,
 			messages.LogFilename: filename,
@@ -21,9 +36,10 @@ func serveHTML(w http.ResponseWriter, r *http.Request, filename string) {
 		return
 	}
 
+	w.Header().Set("Cache-Control", pageCacheControl)
 	err = tmpl.Execute(w, nil)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceStatic, messages.LogErrRenderTemplate, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceStatic, messages.LogErrRenderTemplate, map[string]string{
 			messages.LogDetails:  err.Error(),
 			messages.LogReqPath:  r.URL.Path,
 			messages.LogFilename: filename,
@@ -32,7 +48,7 @@ func serveHTML(w http.ResponseWriter, r *http.Request, filename string) {
 		return
 	}
 
-	loggergrpc.LC.LogInfo(messages.ServiceStatic, messages.LogStatusPageServed, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceStatic, messages.LogStatusPageServed, map[string]string{
 		messages.LogReqPath:  r.URL.Path,
 		messages.LogFilename: filename,
 	})
@@ -27,7 +27,7 @@ func (p *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	fileName := r.Header.Get(messages.ReqFileName)
 
 	if userID == "" || taskName == "" || fileName == "" {
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrParamsRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrParamsRequest, map[string]string{
 			messages.LogDetails: messages.LogErrNoParams,
 		})
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadRequest, nil)
@@ -37,13 +37,13 @@ func (p *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	studentID, err := uuid.Parse(userID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadStudentID, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrParseStudentID, map[string]string{messages.LogUserID: userID})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrParseStudentID, map[string]string{messages.LogUserID: userID})
 		return
 	}
 
-	student, err := p.User.FindUser(studentID)
+	student, err := p.User.FindUser(r.Context(), studentID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrUserNotFound, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrUserNotFound, map[string]string{
 			messages.LogUserID:  studentID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -54,25 +54,25 @@ func (p *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	taskData, err := io.ReadAll(r.Body)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrBadRequest, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrDecodeRequest, map[string]string{messages.LogDetails: err.Error()})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrDecodeRequest, map[string]string{messages.LogDetails: err.Error()})
 		return
 	}
 
 	teacherID := middleware.GetContext(r.Context())
 
-	teacher, err := p.User.FindUser(teacherID)
+	teacher, err := p.User.FindUser(r.Context(), teacherID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrFindTeacher, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrFindTeacher, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrFindTeacher, map[string]string{
 			messages.LogUserID:  teacherID.String(),
 			messages.LogDetails: err.Error(),
 		})
 		return
 	}
 
-	taskID, err := p.Tasks.CreateTask(teacherID, studentID, taskName, teacher.Fio, student.Fio)
+	taskID, err := p.Tasks.CreateTask(r.Context(), teacherID, studentID, taskName, teacher.Fio, student.Fio)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrTaskCreate, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrTaskCreate, map[string]string{
 			messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 			messages.LogUserID + messages.RoleStudent: studentID.String(),
 			messages.LogDetails:                       err.Error(),
@@ -81,10 +81,10 @@ func (p *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = p.Tasks.LinkFileTask(taskID, fileName, taskData)
+	err = p.Tasks.LinkFileTask(r.Context(), taskID, fileName, taskData)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrLinkFile, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrLinkFile, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrLinkFile, map[string]string{
 			messages.LogUserID:  teacherID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -94,7 +94,7 @@ func (p *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	response.WriteAPIResponse(w, http.StatusCreated, true, messages.StatusTaskCreated, map[string]string{
 		messages.LogTaskID: taskID.String(),
 	})
-	loggergrpc.LC.LogInfo(messages.ServiceTasks, messages.LogStatusTaskCreated, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceTasks, messages.LogStatusTaskCreated, map[string]string{
 		messages.LogTaskID:                        taskID.String(),
 		messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 		messages.LogUserID + messages.RoleStudent: studentID.String(),
@@ -112,14 +112,14 @@ func (p *TaskHandler) DownloadTask(w http.ResponseWriter, r *http.Request) {
 	taskID, err := uuid.Parse(taskIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadTaskID, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrParseTaskID, map[string]string{messages.LogTaskID: taskIDStr})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrParseTaskID, map[string]string{messages.LogTaskID: taskIDStr})
 		return
 	}
 
-	fileName, fileData, err := p.Tasks.GetTask(taskID)
+	fileName, fileData, err := p.Tasks.GetTask(r.Context(), taskID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusNotFound, false, messages.ClientErrGetTask, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrGetTask, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrGetTask, map[string]string{
 			messages.LogTaskID:  taskID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -132,11 +132,11 @@ func (p *TaskHandler) DownloadTask(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(fileData)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrWriteFile, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrWriteFile, map[string]string{messages.LogDetails: err.Error()})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrWriteFile, map[string]string{messages.LogDetails: err.Error()})
 		return
 	}
 
-	loggergrpc.LC.LogInfo(messages.ServiceTasks, messages.LogStatusFileDownload, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceTasks, messages.LogStatusFileDownload, map[string]string{
 		messages.LogTaskID:   taskID.String(),
 		messages.LogFilename: fileName,
 	})
@@ -153,14 +153,14 @@ func (p *TaskHandler) DownloadSolution(w http.ResponseWriter, r *http.Request) {
 	taskID, err := uuid.Parse(taskIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadTaskID, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrParseTaskID, map[string]string{messages.LogTaskID: taskIDStr})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrParseTaskID, map[string]string{messages.LogTaskID: taskIDStr})
 		return
 	}
 
-	fileName, fileData, err := p.Tasks.GetSolution(taskID)
+	fileName, fileData, err := p.Tasks.GetSolution(r.Context(), taskID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusNotFound, false, messages.ClientErrGetSolution, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrGetSolution, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrGetSolution, map[string]string{
 			messages.LogTaskID:  taskID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -173,11 +173,11 @@ func (p *TaskHandler) DownloadSolution(w http.ResponseWriter, r *http.Request) {
 	_, err = w.Write(fileData)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrWriteFile, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrWriteFile, map[string]string{messages.LogDetails: err.Error()})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrWriteFile, map[string]string{messages.LogDetails: err.Error()})
 		return
 	}
 
-	loggergrpc.LC.LogInfo(messages.ServiceTasks, messages.LogStatusFileDownload, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceTasks, messages.LogStatusFileDownload, map[string]string{
 		messages.LogTaskID:   taskID.String(),
 		messages.LogFilename: fileName,
 	})
@@ -196,31 +196,31 @@ func (p *TaskHandler) AddSolution(w http.ResponseWriter, r *http.Request) {
 	taskID, err := uuid.Parse(taskIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadTaskID, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrParseTaskID, map[string]string{messages.LogTaskID: taskIDStr})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrParseTaskID, map[string]string{messages.LogTaskID: taskIDStr})
 		return
 	}
 
 	taskData, err := io.ReadAll(r.Body)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrBadRequest, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrDecodeRequest, map[string]string{messages.LogDetails: err.Error()})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrDecodeRequest, map[string]string{messages.LogDetails: err.Error()})
 		return
 	}
 
-	err = p.Tasks.LinkFileSolution(taskID, fileName, taskData)
+	err = p.Tasks.LinkFileSolution(r.Context(), taskID, fileName, taskData)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrSaveSolution, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrSaveSolution, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrSaveSolution, map[string]string{
 			messages.LogTaskID:  taskID.String(),
 			messages.LogDetails: err.Error(),
 		})
 		return
 	}
 
-	err = p.Tasks.Solve(taskID)
+	err = p.Tasks.Solve(r.Context(), taskID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrSaveSolution, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrSaveSolution, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrSaveSolution, map[string]string{
 			messages.LogTaskID:  taskID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -230,7 +230,7 @@ func (p *TaskHandler) AddSolution(w http.ResponseWriter, r *http.Request) {
 	response.WriteAPIResponse(w, http.StatusCreated, true, messages.StatusTaskUpdated, map[string]string{
 		messages.LogTaskID: taskID.String(),
 	})
-	loggergrpc.LC.LogInfo(messages.ServiceTasks, messages.LogStatusSolutionAdded, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceTasks, messages.LogStatusSolutionAdded, map[string]string{
 		messages.LogTaskID:   taskID.String(),
 		messages.LogFilename: fileName,
 	})
@@ -248,21 +248,21 @@ func (p *TaskHandler) AddGrade(w http.ResponseWriter, r *http.Request) {
 	taskID, err := uuid.Parse(taskIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadTaskID, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrParseTaskID, map[string]string{messages.LogTaskID: taskIDStr})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrParseTaskID, map[string]string{messages.LogTaskID: taskIDStr})
 		return
 	}
 
 	numGrade, err := strconv.Atoi(grade)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadGrade, err.Error())
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrParseGrade, map[string]string{messages.LogGrade: grade})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrParseGrade, map[string]string{messages.LogGrade: grade})
 		return
 	}
 
-	studentID, err := p.Tasks.Grade(taskID, uint8(numGrade))
+	studentID, err := p.Tasks.Grade(r.Context(), taskID, uint8(numGrade))
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrGradeTask, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrGradeTask, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrGradeTask, map[string]string{
 			messages.LogTaskID:  taskID.String(),
 			messages.LogGrade:   grade,
 			messages.LogDetails: err.Error(),
@@ -270,20 +270,20 @@ func (p *TaskHandler) AddGrade(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gradeTotal, err := p.Tasks.AvgGrade(studentID)
+	gradeTotal, err := p.Tasks.AvgGrade(r.Context(), studentID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrCalcGrade, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrCalcGrade, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrCalcGrade, map[string]string{
 			messages.LogUserID:  studentID.String(),
 			messages.LogDetails: err.Error(),
 		})
 		return
 	}
 
-	err = p.User.EditGrade(studentID, gradeTotal)
+	err = p.User.EditGrade(r.Context(), studentID, gradeTotal)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusNotFound, false, messages.ClientErrUpdateGrade, nil)
-		loggergrpc.LC.LogError(messages.ServiceTasks, messages.LogErrUpdateGrade, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceTasks, messages.LogErrUpdateGrade, map[string]string{
 			messages.LogUserID:  studentID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -293,7 +293,7 @@ func (p *TaskHandler) AddGrade(w http.ResponseWriter, r *http.Request) {
 	response.WriteAPIResponse(w, http.StatusOK, true, messages.StatusGradeAdded, map[string]string{
 		messages.LogTaskID: taskID.String(),
 	})
-	loggergrpc.LC.LogInfo(messages.ServiceTasks, messages.LogStatusGradeAdded, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceTasks, messages.LogStatusGradeAdded, map[string]string{
 		messages.LogTaskID: taskID.String(),
 		messages.LogUserID: studentID.String(),
 		messages.LogGrade:  grade,
@@ -304,10 +304,10 @@ func (p *TaskHandler) AddGrade(w http.ResponseWriter, r *http.Request) {
 // OutAllTasks выводит все задания пользователя
 func (p *TaskHandler) OutAllTasks(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetContext(r.Context())
-	tasks := p.Tasks.AllTasks(userID)
+	tasks := p.Tasks.AllTasks(r.Context(), userID)
 
 	response.WriteAPIResponse(w, http.StatusOK, true, messages.StatusSuccess, tasks)
-	loggergrpc.LC.LogInfo(messages.ServiceTasks, messages.LogStatusTaskList, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceTasks, messages.LogStatusTaskList, map[string]string{
 		messages.LogUserID:  userID.String(),
 		messages.LogDetails: fmt.Sprintf("found %d tasks", len(tasks)),
 	})
@@ -27,7 +27,7 @@ func (p *UserHandler) OutAllTeachers(w http.ResponseWriter, r *http.Request) {
 
 	userID := middleware.GetContext(r.Context())
 
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusTeacherListRequested, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusTeacherListRequested, map[string]string{
 		messages.LogUserID:  userID.String(),
 		messages.LogDetails: fmt.Sprintf("params: order=%s, field=%s, specialty=%s", orderBy, orderField, specialty),
 	})
@@ -38,13 +38,13 @@ func (p *UserHandler) OutAllTeachers(w http.ResponseWriter, r *http.Request) {
 	)
 
 	if orderBy == "desc" {
-		users, err = p.User.OutDescendingBySpecialty(orderField, specialty, userID)
+		users, err = p.User.OutDescendingBySpecialty(r.Context(), orderField, specialty, userID)
 	} else {
-		users, err = p.User.OutAscendingBySpecialty(orderField, specialty, userID)
+		users, err = p.User.OutAscendingBySpecialty(r.Context(), orderField, specialty, userID)
 	}
 
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrTeacherList, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrTeacherList, map[string]string{
 			messages.LogUserID:  userID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -54,7 +54,7 @@ func (p *UserHandler) OutAllTeachers(w http.ResponseWriter, r *http.Request) {
 
 	response.WriteAPIResponse(w, http.StatusOK, true, messages.StatusSuccess, users)
 
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusTeacherList, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusTeacherList, map[string]string{
 		messages.LogUserID:  userID.String(),
 		messages.LogDetails: fmt.Sprintf("found %d teachers", len(users)),
 	})
@@ -66,7 +66,7 @@ func (p *UserHandler) AddRating(w http.ResponseWriter, r *http.Request) {
 	rating := r.URL.Query().Get(messages.ReqRating)
 
 	if teacherIDStr == "" || rating == "" {
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrMissingParams, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrMissingParams, map[string]string{
 			messages.LogDetails: "missing teacherId or rating",
 		})
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrNoParams, nil)
@@ -76,17 +76,17 @@ func (p *UserHandler) AddRating(w http.ResponseWriter, r *http.Request) {
 	teacherID, err := uuid.Parse(teacherIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadTeacherID, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrParseTeacherID, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrParseTeacherID, map[string]string{
 			messages.LogUserID: teacherIDStr,
 		})
 		return
 	}
 
 	studentID := middleware.GetContext(r.Context())
-	flag, err := p.User.HasThatTeacher(studentID, teacherID)
+	flag, err := p.User.HasThatTeacher(r.Context(), studentID, teacherID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrCheckTeacher, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrCheckTeacher, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrCheckTeacher, map[string]string{
 			messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 			messages.LogUserID + messages.RoleStudent: studentID.String(),
 			messages.LogDetails:                       err.Error(),
@@ -102,17 +102,17 @@ func (p *UserHandler) AddRating(w http.ResponseWriter, r *http.Request) {
 	numRating, err := strconv.Atoi(rating)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadRating, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrParseRating, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrParseRating, map[string]string{
 			messages.LogRating:  rating,
 			messages.LogDetails: err.Error(),
 		})
 		return
 	}
 
-	err = p.User.AddRating(teacherID, float32(numRating))
+	err = p.User.AddRating(r.Context(), teacherID, float32(numRating))
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrAddRating, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrAddRating, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrAddRating, map[string]string{
 			messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 			messages.LogUserID + messages.RoleStudent: studentID.String(),
 			messages.LogDetails:                       err.Error(),
@@ -121,7 +121,7 @@ func (p *UserHandler) AddRating(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, http.StatusOK, true, messages.StatusRated, nil)
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusRatingAdded, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusRatingAdded, map[string]string{
 		messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 		messages.LogUserID + messages.RoleStudent: studentID.String(),
 		messages.LogRating:                        rating,
@@ -132,9 +132,9 @@ func (p *UserHandler) AddRating(w http.ResponseWriter, r *http.Request) {
 func (p *UserHandler) OutRequests(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetContext(r.Context())
 
-	requests, err := p.User.ShowRequests(userID)
+	requests, err := p.User.ShowRequests(r.Context(), userID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrRequestList, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrRequestList, map[string]string{
 			messages.LogUserID:  userID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -143,7 +143,7 @@ func (p *UserHandler) OutRequests(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, http.StatusOK, true, "", requests)
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusRequestList, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusRequestList, map[string]string{
 		messages.LogUserID:  userID.String(),
 		messages.LogDetails: fmt.Sprintf("found %d requests", len(requests)),
 	})
@@ -153,9 +153,9 @@ func (p *UserHandler) OutRequests(w http.ResponseWriter, r *http.Request) {
 func (p *UserHandler) OutAllStudents(w http.ResponseWriter, r *http.Request) {
 	teacherID := middleware.GetContext(r.Context())
 
-	students, err := p.User.StudentsByTeacher(teacherID)
+	students, err := p.User.StudentsByTeacher(r.Context(), teacherID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrStudentList, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrStudentList, map[string]string{
 			messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 			messages.LogDetails:                       err.Error(),
 		})
@@ -164,7 +164,7 @@ func (p *UserHandler) OutAllStudents(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, http.StatusOK, true, messages.StatusSuccess, students)
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusStudentList, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusStudentList, map[string]string{
 		messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 		messages.LogDetails:                       fmt.Sprintf("found %d students", len(students)),
 	})
@@ -181,14 +181,14 @@ func (p *UserHandler) AddRequest(w http.ResponseWriter, r *http.Request) {
 	teacherID, err := uuid.Parse(teacherIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadTeacherID, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrParseTeacherID, map[string]string{messages.LogUserID: teacherIDStr})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrParseTeacherID, map[string]string{messages.LogUserID: teacherIDStr})
 		return
 	}
 
 	studentID := middleware.GetContext(r.Context())
-	err = p.User.AddRequest(studentID, teacherID)
+	err = p.User.AddRequest(r.Context(), studentID, teacherID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrAddRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrAddRequest, map[string]string{
 			messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 			messages.LogUserID + messages.RoleStudent: studentID.String(),
 			messages.LogDetails:                       err.Error(),
@@ -198,7 +198,7 @@ func (p *UserHandler) AddRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, http.StatusCreated, true, messages.StatusReqSent, nil)
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusUserReqSent, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusUserReqSent, map[string]string{
 		messages.LogUserID + messages.RoleStudent: studentID.String(),
 		messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 	})
@@ -215,14 +215,14 @@ func (p *UserHandler) ConfirmRequest(w http.ResponseWriter, r *http.Request) {
 	studentID, err := uuid.Parse(studentIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadStudentID, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrParseStudentID, map[string]string{messages.LogUserID: studentIDStr})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrParseStudentID, map[string]string{messages.LogUserID: studentIDStr})
 		return
 	}
 
 	teacherID := middleware.GetContext(r.Context())
-	err = p.User.Accept(teacherID, studentID)
+	err = p.User.Accept(r.Context(), teacherID, studentID)
 	if err != nil {
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrAcceptRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrAcceptRequest, map[string]string{
 			messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 			messages.LogUserID + messages.RoleStudent: studentID.String(),
 			messages.LogDetails:                       err.Error(),
@@ -232,7 +232,7 @@ func (p *UserHandler) ConfirmRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, http.StatusCreated, true, messages.StatusReqAccepted, nil)
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusUserReqAccepted, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusUserReqAccepted, map[string]string{
 		messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 		messages.LogUserID + messages.RoleStudent: studentID.String(),
 	})
@@ -249,15 +249,15 @@ func (p *UserHandler) DenyRequest(w http.ResponseWriter, r *http.Request) {
 	studentID, err := uuid.Parse(studentIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadStudentID, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrParseStudentID, map[string]string{messages.LogUserID: studentIDStr})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrParseStudentID, map[string]string{messages.LogUserID: studentIDStr})
 		return
 	}
 
 	teacherID := middleware.GetContext(r.Context())
-	err = p.User.Deny(teacherID, studentID)
+	err = p.User.Deny(r.Context(), teacherID, studentID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrDenyRequest, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrDenyRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrDenyRequest, map[string]string{
 			messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 			messages.LogUserID + messages.RoleStudent: studentID.String(),
 			messages.LogDetails:                       err.Error(),
@@ -266,7 +266,7 @@ func (p *UserHandler) DenyRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, http.StatusCreated, true, messages.StatusReqDenied, nil)
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusUserReqDenied, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusUserReqDenied, map[string]string{
 		messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 		messages.LogUserID + messages.RoleStudent: studentID.String(),
 	})
@@ -283,15 +283,15 @@ func (p *UserHandler) CancelRequest(w http.ResponseWriter, r *http.Request) {
 	teacherID, err := uuid.Parse(teacherIDStr)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadTeacherID, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrParseTeacherID, map[string]string{messages.LogUserID: teacherIDStr})
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrParseTeacherID, map[string]string{messages.LogUserID: teacherIDStr})
 		return
 	}
 
 	studentID := middleware.GetContext(r.Context())
-	err = p.User.Deny(teacherID, studentID)
+	err = p.User.Deny(r.Context(), teacherID, studentID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrCancelRequest, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrCancelRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrCancelRequest, map[string]string{
 			messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 			messages.LogUserID + messages.RoleStudent: studentID.String(),
 			messages.LogDetails:                       err.Error(),
@@ -300,7 +300,7 @@ func (p *UserHandler) CancelRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, http.StatusCreated, true, messages.StatusReqCanceled, nil)
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusUserReqCanceled, map[string]string{
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusUserReqCanceled, map[string]string{
 		messages.LogUserID + messages.RoleTeacher: teacherID.String(),
 		messages.LogUserID + messages.RoleStudent: studentID.String(),
 	})
@@ -312,17 +312,17 @@ func (p *UserHandler) FillProfile(w http.ResponseWriter, r *http.Request) {
 
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrBadRequest, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrDecodeRequest, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrDecodeRequest, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		return
 	}
 
 	userID := middleware.GetContext(r.Context())
-	err := p.User.FillProfile(userID, user)
+	err := p.User.FillProfile(r.Context(), userID, user)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrFillProfile, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrFillProfile, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrFillProfile, map[string]string{
 			messages.LogUserID:  userID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -330,16 +330,16 @@ func (p *UserHandler) FillProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.WriteAPIResponse(w, http.StatusOK, true, messages.StatusUpdated, nil)
-	loggergrpc.LC.LogInfo(messages.ServiceUsers, messages.LogStatusUserUpdated, map[string]string{messages.LogUserID: userID.String()})
+	loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceUsers, messages.LogStatusUserUpdated, map[string]string{messages.LogUserID: userID.String()})
 }
 
 func (p *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetContext(r.Context())
 
-	user, err := p.User.FindUser(userID)
+	user, err := p.User.FindUser(r.Context(), userID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusNotFound, false, messages.ClientErrFindUser, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrFindUser, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrFindUser, map[string]string{
 			messages.LogUserID:  userID.String(),
 			messages.LogDetails: err.Error(),
 		})
@@ -353,10 +353,10 @@ func (p *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 func (p *UserHandler) OutMyTeachers(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetContext(r.Context())
 
-	teachers, err := p.User.TeachersByStudent(userID)
+	teachers, err := p.User.TeachersByStudent(r.Context(), userID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrGetTeachers, nil)
-		loggergrpc.LC.LogError(messages.ServiceUsers, messages.LogErrGetTeachers, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceUsers, messages.LogErrGetTeachers, map[string]string{
 			messages.LogUserID:  userID.String(),
 			messages.LogDetails: err.Error(),
 		})
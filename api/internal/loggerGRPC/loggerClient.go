@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"api/internal/tracing"
 	"api/logservice"
 
 	"google.golang.org/grpc"
@@ -22,8 +23,32 @@ func NewLogClient(conn *grpc.ClientConn) *LogClient {
 	}
 }
 
+// ключи метаданных записи с трассой запроса
+const (
+	metaTraceparent = "traceparent"
+	metaTraceID     = "trace_id"
+	metaSpanID      = "span_id"
+)
+
 func (lc *LogClient) Log(level, service, message string, metadata map[string]string) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	lc.LogContext(context.Background(), level, service, message, metadata)
+}
+
+// LogContext - запись в рамках запроса: в метаданные добавляется его трасса. Отмена
+// запроса клиентом запись не прерывает
+func (lc *LogClient) LogContext(ctx context.Context, level, service, message string, metadata map[string]string) {
+	if sc, ok := tracing.FromContext(ctx); ok {
+		withTrace := make(map[string]string, len(metadata)+3)
+		for k, v := range metadata {
+			withTrace[k] = v
+		}
+		withTrace[metaTraceparent] = sc.Traceparent()
+		withTrace[metaTraceID] = sc.TraceIDString()
+		withTrace[metaSpanID] = sc.SpanIDString()
+		metadata = withTrace
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
 	defer cancel()
 
 	_, err := lc.client.WriteLog(ctx, &logservice.LogRequest{
@@ -46,3 +71,11 @@ func (lc *LogClient) LogError(service, message string, metadata map[string]strin
 func (lc *LogClient) LogInfo(service, message string, metadata map[string]string) {
 	lc.Log("INFO", service, message, metadata)
 }
+
+func (lc *LogClient) LogErrorContext(ctx context.Context, service, message string, metadata map[string]string) {
+	lc.LogContext(ctx, "ERROR", service, message, metadata)
+}
+
+func (lc *LogClient) LogInfoContext(ctx context.Context, service, message string, metadata map[string]string) {
+	lc.LogContext(ctx, "INFO", service, message, metadata)
+}
@@ -10,6 +10,7 @@ const (
 	ServiceUsers       = "users"
 	ServiceChat        = "chat"
 	ServiceStatic      = "static"
+	ServiceTracing     = "tracing"
 )
 
 // Константы для шифрования
@@ -213,6 +214,7 @@ const (
 	LogErrFindTeacher      = "failed to find teacher"
 	LogErrLinkFile         = "failed to link file"
 	LogErrGetTask          = "failed to get task"
+	LogErrExportSpans      = "failed to export spans"
 	LogErrGetSolution      = "failed to get solution"
 	LogErrSaveSolution     = "failed to save solution"
 	LogErrGradeTask        = "failed to grade task"
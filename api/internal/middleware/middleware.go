@@ -29,23 +29,23 @@ func (p *MiddlewareHandler) CheckSes(w http.ResponseWriter, r *http.Request, nex
 	cookie, err := r.Cookie(messages.CookieAuthToken)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ClientErrNoCookie, nil)
-		loggergrpc.LC.LogInfo(messages.ServiceMiddleware, messages.LogErrNoAuthToken, nil)
+		loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceMiddleware, messages.LogErrNoAuthToken, nil)
 		return
 	}
 
 	token, err := p.Token.ParseJWT(cookie.Value)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ClientErrBadToken, nil)
-		loggergrpc.LC.LogError(messages.ServiceMiddleware, messages.LogErrParseToken, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceMiddleware, messages.LogErrParseToken, map[string]string{
 			messages.LogDetails: err.Error(),
 		})
 		return
 	}
 
-	userID, role, err := p.Session.GetSession(token.SessionID)
+	userID, role, err := p.Session.GetSession(r.Context(), token.SessionID)
 	if err != nil {
 		response.WriteAPIResponse(w, http.StatusUnauthorized, false, messages.ClientErrNoSession, nil)
-		loggergrpc.LC.LogError(messages.ServiceMiddleware, messages.LogErrSessionNotFound, map[string]string{
+		loggergrpc.LC.LogErrorContext(r.Context(), messages.ServiceMiddleware, messages.LogErrSessionNotFound, map[string]string{
 			messages.LogSessionID: token.SessionID.String(),
 			messages.LogDetails:   err.Error(),
 		})
@@ -54,7 +54,7 @@ func (p *MiddlewareHandler) CheckSes(w http.ResponseWriter, r *http.Request, nex
 
 	if role != targetRole && targetRole != "any" {
 		response.WriteAPIResponse(w, http.StatusUnauthorized, false, messages.StatusNoPermission, nil)
-		loggergrpc.LC.LogInfo(messages.ServiceMiddleware, messages.LogStatusUserNoPermission, map[string]string{
+		loggergrpc.LC.LogInfoContext(r.Context(), messages.ServiceMiddleware, messages.LogStatusUserNoPermission, map[string]string{
 			messages.LogUserID:   userID.String(),
 			messages.LogUserRole: role,
 			messages.LogNeedRole: targetRole,
@@ -30,11 +30,11 @@ const (
 )
 
 // CreateRoom создает новую комнату чата для двух пользователей
-func (r *ChatRepoGRPC) CreateRoom(user1, user2 uuid.UUID) (string, bool, error) {
+func (r *ChatRepoGRPC) CreateRoom(ctx context.Context, user1, user2 uuid.UUID) (string, bool, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + chatToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.CreateRoom(ctx, &chatpb.CreateRoomRequest{
 		User1Id: user1.String(),
 		User2Id: user2.String(),
@@ -46,11 +46,11 @@ func (r *ChatRepoGRPC) CreateRoom(user1, user2 uuid.UUID) (string, bool, error)
 }
 
 // History возвращает историю сообщений для указанной комнаты
-func (r *ChatRepoGRPC) History(roomID string) ([]ChatMessage, error) {
+func (r *ChatRepoGRPC) History(ctx context.Context, roomID string) ([]ChatMessage, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + chatToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.History(ctx, &chatpb.RoomIDRequest{RoomId: roomID})
 	if err != nil {
 		return nil, err
@@ -71,11 +71,11 @@ func (r *ChatRepoGRPC) History(roomID string) ([]ChatMessage, error) {
 }
 
 // SaveMessage сохраняет новое сообщение в базе данных
-func (r *ChatRepoGRPC) SaveMessage(msg ChatMessage) error {
+func (r *ChatRepoGRPC) SaveMessage(ctx context.Context, msg ChatMessage) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + chatToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.SendMessage(ctx, &chatpb.SendMessageRequest{
 		Message: &chatpb.MessageInfo{
 			Id:       msg.ID.String(),
@@ -90,11 +90,11 @@ func (r *ChatRepoGRPC) SaveMessage(msg ChatMessage) error {
 }
 
 // UpdateStatus обновляет статус сообщения
-func (r *ChatRepoGRPC) UpdateStatus(msgID uuid.UUID, status chatpb.MessageStatus) error {
+func (r *ChatRepoGRPC) UpdateStatus(ctx context.Context, msgID uuid.UUID, status chatpb.MessageStatus) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + chatToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.UpdateStatus(ctx, &chatpb.UpdateStatusRequest{
 		Id:     msgID.String(),
 		Status: status,
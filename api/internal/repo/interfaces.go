@@ -2,6 +2,7 @@ package repo
 
 import (
 	"api/internal/proto/chatpb"
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,8 @@ type UsersList struct {
 	Rating    float32   `json:"rating"`              // Рейтинг преподавателя
 }
 
+// Запросы к базам берут из контекста запроса только трассу (context.WithoutCancel):
+// обрыв соединения клиентом не должен прерывать начатую запись на полпути
 const (
 	authorization = "authorization"
 	bearer        = "Bearer "
@@ -25,49 +28,49 @@ const (
 // UserRepo определяет методы для работы с пользователями в системе
 type UserRepo interface {
 	// FindUser находит пользователя по ID
-	FindUser(userID uuid.UUID) (user UsersList, err error)
+	FindUser(ctx context.Context, userID uuid.UUID) (user UsersList, err error)
 
 	// CheckPass проверяет учетные данные пользователя
-	CheckPass(username string, pass string) (userID uuid.UUID, role string, err error)
+	CheckPass(ctx context.Context, username string, pass string) (userID uuid.UUID, role string, err error)
 
 	// CreateAccount создает новую учетную запись
-	CreateAccount(username string, pass string, role string) (userID uuid.UUID, err error)
+	CreateAccount(ctx context.Context, username string, pass string, role string) (userID uuid.UUID, err error)
 
 	// OutAscendingBySpecialty возвращает отсортированный по возрастанию список преподавателей
-	OutAscendingBySpecialty(orderField string, specialty string, userID uuid.UUID) (users []UsersList, err error)
+	OutAscendingBySpecialty(ctx context.Context, orderField string, specialty string, userID uuid.UUID) (users []UsersList, err error)
 
 	// OutDescendingBySpecialty возвращает отсортированный по убыванию список преподавателей
-	OutDescendingBySpecialty(orderField string, specialty string, userID uuid.UUID) (users []UsersList, err error)
+	OutDescendingBySpecialty(ctx context.Context, orderField string, specialty string, userID uuid.UUID) (users []UsersList, err error)
 
 	// HasThatTeacher проверяет связь студента с преподавателем
-	HasThatTeacher(studentID uuid.UUID, teacherID uuid.UUID) (bool, error)
+	HasThatTeacher(ctx context.Context, studentID uuid.UUID, teacherID uuid.UUID) (bool, error)
 
 	// AddRating добавляет оценку преподавателю
-	AddRating(userID uuid.UUID, rating float32) error
+	AddRating(ctx context.Context, userID uuid.UUID, rating float32) error
 
 	// StudentsByTeacher возвращает список студентов преподавателя
-	StudentsByTeacher(teacherID uuid.UUID) (users []UsersList, err error)
+	StudentsByTeacher(ctx context.Context, teacherID uuid.UUID) (users []UsersList, err error)
 
 	// EditGrade обновляет среднюю оценку студента
-	EditGrade(studentID uuid.UUID, grade float32) error
+	EditGrade(ctx context.Context, studentID uuid.UUID, grade float32) error
 
 	// FillProfile обновляет профиль пользователя
-	FillProfile(userID uuid.UUID, userData UsersList) error
+	FillProfile(ctx context.Context, userID uuid.UUID, userData UsersList) error
 
 	// TeachersByStudent возвращает список преподавателей студента
-	TeachersByStudent(studentID uuid.UUID) (teachers []UsersList, err error)
+	TeachersByStudent(ctx context.Context, studentID uuid.UUID) (teachers []UsersList, err error)
 
 	// AddRequest создает запрос на обучение
-	AddRequest(studentID uuid.UUID, teacherID uuid.UUID) error
+	AddRequest(ctx context.Context, studentID uuid.UUID, teacherID uuid.UUID) error
 
 	// ShowRequests возвращает список запросов на обучение
-	ShowRequests(userID uuid.UUID) (users []UsersList, err error)
+	ShowRequests(ctx context.Context, userID uuid.UUID) (users []UsersList, err error)
 
 	// Accept подтверждает запрос на обучение
-	Accept(teacherID uuid.UUID, studentID uuid.UUID) error
+	Accept(ctx context.Context, teacherID uuid.UUID, studentID uuid.UUID) error
 
 	// Deny отклоняет запрос на обучение
-	Deny(teacherID uuid.UUID, studentID uuid.UUID) error
+	Deny(ctx context.Context, teacherID uuid.UUID, studentID uuid.UUID) error
 }
 
 // taskList содержит информацию о задании
@@ -83,43 +86,43 @@ type taskList struct {
 // TaskRepo определяет методы для работы с заданиями
 type TaskRepo interface {
 	// CreateTask создает новое задание
-	CreateTask(teacher uuid.UUID, student uuid.UUID, name string, studentFIO string, teacherFIO string) (uuid.UUID, error)
+	CreateTask(ctx context.Context, teacher uuid.UUID, student uuid.UUID, name string, studentFIO string, teacherFIO string) (uuid.UUID, error)
 
 	// GetTask получает файл задания
-	GetTask(taskID uuid.UUID) (fileName string, fileData []byte, err error)
+	GetTask(ctx context.Context, taskID uuid.UUID) (fileName string, fileData []byte, err error)
 
 	// GetSolution получает файл решения
-	GetSolution(taskID uuid.UUID) (fileName string, fileData []byte, err error)
+	GetSolution(ctx context.Context, taskID uuid.UUID) (fileName string, fileData []byte, err error)
 
 	// LinkFileTask прикрепляет файл к заданию
-	LinkFileTask(taskID uuid.UUID, fileName string, fileData []byte) error
+	LinkFileTask(ctx context.Context, taskID uuid.UUID, fileName string, fileData []byte) error
 
 	// LinkFileSolution прикрепляет файл решения
-	LinkFileSolution(taskID uuid.UUID, fileName string, fileData []byte) error
+	LinkFileSolution(ctx context.Context, taskID uuid.UUID, fileName string, fileData []byte) error
 
 	// Grade выставляет оценку за задание
-	Grade(taskID uuid.UUID, grade uint8) (studentID uuid.UUID, err error)
+	Grade(ctx context.Context, taskID uuid.UUID, grade uint8) (studentID uuid.UUID, err error)
 
 	// Solve отмечает задание как решенное
-	Solve(taskID uuid.UUID) error
+	Solve(ctx context.Context, taskID uuid.UUID) error
 
 	// AvgGrade считает среднюю оценку студента
-	AvgGrade(studentID uuid.UUID) (grade float32, err error)
+	AvgGrade(ctx context.Context, studentID uuid.UUID) (grade float32, err error)
 
 	// AllTasks возвращает все задания пользователя
-	AllTasks(userID uuid.UUID) (tasks []taskList)
+	AllTasks(ctx context.Context, userID uuid.UUID) (tasks []taskList)
 }
 
 // SessionRepo определяет методы для работы с сессиями
 type SessionRepo interface {
 	// GetSession получает информацию о сессии
-	GetSession(sessionID uuid.UUID) (userID uuid.UUID, role string, err error)
+	GetSession(ctx context.Context, sessionID uuid.UUID) (userID uuid.UUID, role string, err error)
 
 	// SetSession создает новую сессию
-	SetSession(sessionID uuid.UUID, userID uuid.UUID, role string, sessionLifetime time.Duration) error
+	SetSession(ctx context.Context, sessionID uuid.UUID, userID uuid.UUID, role string, sessionLifetime time.Duration) error
 
 	// DeleteSession удаляет сессию
-	DeleteSession(sessionID uuid.UUID) (userID uuid.UUID, err error)
+	DeleteSession(ctx context.Context, sessionID uuid.UUID) (userID uuid.UUID, err error)
 }
 
 // ChatMessage содержит информацию о сообщении в чате
@@ -135,14 +138,14 @@ type ChatMessage struct {
 // ChatRepo определяет методы для работы с чатом
 type ChatRepo interface {
 	// CreateRoom создает новую комнату чата
-	CreateRoom(user1, user2 uuid.UUID) (roomID string, existed bool, err error)
+	CreateRoom(ctx context.Context, user1, user2 uuid.UUID) (roomID string, existed bool, err error)
 
 	// History возвращает историю сообщений
-	History(roomID string) ([]ChatMessage, error)
+	History(ctx context.Context, roomID string) ([]ChatMessage, error)
 
 	// SaveMessage сохраняет новое сообщение
-	SaveMessage(msg ChatMessage) error
+	SaveMessage(ctx context.Context, msg ChatMessage) error
 
 	// UpdateStatus обновляет статус сообщения
-	UpdateStatus(msgID uuid.UUID, status chatpb.MessageStatus) error
+	UpdateStatus(ctx context.Context, msgID uuid.UUID, status chatpb.MessageStatus) error
 }
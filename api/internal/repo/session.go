@@ -30,11 +30,11 @@ const (
 )
 
 // GetSession получает информацию о сессии из базы данных
-func (r *SessionRepoGRPC) GetSession(sessionID uuid.UUID) (userID uuid.UUID, role string, err error) {
+func (r *SessionRepoGRPC) GetSession(ctx context.Context, sessionID uuid.UUID) (userID uuid.UUID, role string, err error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + sessionToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.GetSession(ctx, &sessionpb.SessionIDRequest{
 		SessionId: sessionID.String(),
 	})
@@ -51,11 +51,11 @@ func (r *SessionRepoGRPC) GetSession(sessionID uuid.UUID) (userID uuid.UUID, rol
 }
 
 // SetSession создает новую сессию в базе данных
-func (r *SessionRepoGRPC) SetSession(sessionID uuid.UUID, userID uuid.UUID, role string, sessionLifetime time.Duration) error {
+func (r *SessionRepoGRPC) SetSession(ctx context.Context, sessionID uuid.UUID, userID uuid.UUID, role string, sessionLifetime time.Duration) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + sessionToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	expiresAt := time.Now().Add(sessionLifetime).Unix()
 
 	_, err := r.db.SetSession(ctx, &sessionpb.SetSessionRequest{
@@ -68,11 +68,11 @@ func (r *SessionRepoGRPC) SetSession(sessionID uuid.UUID, userID uuid.UUID, role
 }
 
 // DeleteSession удаляет сессию из базы данных и возвращает ID пользователя
-func (r *SessionRepoGRPC) DeleteSession(sessionID uuid.UUID) (userID uuid.UUID, err error) {
+func (r *SessionRepoGRPC) DeleteSession(ctx context.Context, sessionID uuid.UUID) (userID uuid.UUID, err error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + sessionToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.DeleteSession(ctx, &sessionpb.SessionIDRequest{
 		SessionId: sessionID.String(),
 	})
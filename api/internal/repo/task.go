@@ -29,11 +29,11 @@ const (
 )
 
 // CreateTask создает новое задание в базе данных
-func (r *TaskRepoGRPC) CreateTask(teacher uuid.UUID, student uuid.UUID, name string, studentFIO string, teacherFIO string) (uuid.UUID, error) {
+func (r *TaskRepoGRPC) CreateTask(ctx context.Context, teacher uuid.UUID, student uuid.UUID, name string, studentFIO string, teacherFIO string) (uuid.UUID, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.CreateTask(ctx, &taskpb.CreateTaskRequest{
 		TeacherId:  teacher.String(),
 		StudentId:  student.String(),
@@ -48,11 +48,11 @@ func (r *TaskRepoGRPC) CreateTask(teacher uuid.UUID, student uuid.UUID, name str
 }
 
 // GetTask получает файл задания из хранилища
-func (r *TaskRepoGRPC) GetTask(taskID uuid.UUID) (fileName string, fileData []byte, err error) {
+func (r *TaskRepoGRPC) GetTask(ctx context.Context, taskID uuid.UUID) (fileName string, fileData []byte, err error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.GetTask(ctx, &taskpb.TaskIDRequest{
 		Id: taskID.String(),
 	})
@@ -66,11 +66,11 @@ func (r *TaskRepoGRPC) GetTask(taskID uuid.UUID) (fileName string, fileData []by
 }
 
 // GetSolution получает файл решения из хранилища
-func (r *TaskRepoGRPC) GetSolution(taskID uuid.UUID) (fileName string, fileData []byte, err error) {
+func (r *TaskRepoGRPC) GetSolution(ctx context.Context, taskID uuid.UUID) (fileName string, fileData []byte, err error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.GetSolution(ctx, &taskpb.TaskIDRequest{
 		Id: taskID.String(),
 	})
@@ -84,11 +84,11 @@ func (r *TaskRepoGRPC) GetSolution(taskID uuid.UUID) (fileName string, fileData
 }
 
 // LinkFileTask прикрепляет файл к заданию в хранилище
-func (r *TaskRepoGRPC) LinkFileTask(taskID uuid.UUID, fileName string, fileData []byte) error {
+func (r *TaskRepoGRPC) LinkFileTask(ctx context.Context, taskID uuid.UUID, fileName string, fileData []byte) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.LinkFileTask(ctx, &taskpb.LinkFileRequest{
 		TaskId:   taskID.String(),
 		FileName: fileName,
@@ -101,11 +101,11 @@ func (r *TaskRepoGRPC) LinkFileTask(taskID uuid.UUID, fileName string, fileData
 }
 
 // LinkFileSolution прикрепляет файл решения к заданию в хранилище
-func (r *TaskRepoGRPC) LinkFileSolution(taskID uuid.UUID, fileName string, fileData []byte) error {
+func (r *TaskRepoGRPC) LinkFileSolution(ctx context.Context, taskID uuid.UUID, fileName string, fileData []byte) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.LinkFileSolution(ctx, &taskpb.LinkFileRequest{
 		TaskId:   taskID.String(),
 		FileName: fileName,
@@ -118,11 +118,11 @@ func (r *TaskRepoGRPC) LinkFileSolution(taskID uuid.UUID, fileName string, fileD
 }
 
 // Grade выставляет оценку за задание и возвращает ID студента
-func (r *TaskRepoGRPC) Grade(taskID uuid.UUID, grade uint8) (studentID uuid.UUID, err error) {
+func (r *TaskRepoGRPC) Grade(ctx context.Context, taskID uuid.UUID, grade uint8) (studentID uuid.UUID, err error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.Grade(ctx, &taskpb.GradeRequest{
 		TaskId: taskID.String(),
 		Grade:  uint32(grade),
@@ -134,11 +134,11 @@ func (r *TaskRepoGRPC) Grade(taskID uuid.UUID, grade uint8) (studentID uuid.UUID
 }
 
 // Solve отмечает задание как решенное
-func (r *TaskRepoGRPC) Solve(taskID uuid.UUID) error {
+func (r *TaskRepoGRPC) Solve(ctx context.Context, taskID uuid.UUID) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.Solve(ctx, &taskpb.TaskIDRequest{
 		Id: taskID.String(),
 	})
@@ -149,11 +149,11 @@ func (r *TaskRepoGRPC) Solve(taskID uuid.UUID) error {
 }
 
 // AvgGrade вычисляет среднюю оценку студента по всем заданиям
-func (r *TaskRepoGRPC) AvgGrade(studentID uuid.UUID) (grade float32, err error) {
+func (r *TaskRepoGRPC) AvgGrade(ctx context.Context, studentID uuid.UUID) (grade float32, err error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.AvgGrade(ctx, &taskpb.StudentIDRequest{
 		StudentId: studentID.String(),
 	})
@@ -164,11 +164,11 @@ func (r *TaskRepoGRPC) AvgGrade(studentID uuid.UUID) (grade float32, err error)
 }
 
 // AllTasks возвращает список всех заданий пользователя
-func (r *TaskRepoGRPC) AllTasks(userID uuid.UUID) []taskList {
+func (r *TaskRepoGRPC) AllTasks(ctx context.Context, userID uuid.UUID) []taskList {
 	md := metadata.New(map[string]string{
 		authorization: bearer + taskToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.AllTasks(ctx, &taskpb.UserIDRequest{
 		UserId: userID.String(),
 	})
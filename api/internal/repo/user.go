@@ -30,11 +30,11 @@ const (
 )
 
 // CreateAccount создает новую учетную запись
-func (r *UserRepoGRPC) CreateAccount(username string, pass string, role string) (uuid.UUID, error) {
+func (r *UserRepoGRPC) CreateAccount(ctx context.Context, username string, pass string, role string) (uuid.UUID, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	existsResp, err := r.db.UserExists(ctx, &userpb.UsernameRequest{Username: username})
 	if err != nil {
 		return uuid.Nil, err
@@ -54,11 +54,11 @@ func (r *UserRepoGRPC) CreateAccount(username string, pass string, role string)
 }
 
 // CheckPass проверяет учетные данные пользователя
-func (r *UserRepoGRPC) CheckPass(username string, pass string) (uuid.UUID, string, error) {
+func (r *UserRepoGRPC) CheckPass(ctx context.Context, username string, pass string) (uuid.UUID, string, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.CheckCredentials(ctx, &userpb.CredentialsRequest{
 		Username: username,
 		Password: pass,
@@ -70,11 +70,11 @@ func (r *UserRepoGRPC) CheckPass(username string, pass string) (uuid.UUID, strin
 }
 
 // FindUser находит пользователя по ID
-func (r *UserRepoGRPC) FindUser(userID uuid.UUID) (UsersList, error) {
+func (r *UserRepoGRPC) FindUser(ctx context.Context, userID uuid.UUID) (UsersList, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.GetUserByID(ctx, &userpb.UserIDRequest{Id: userID.String()})
 	if err != nil {
 		return UsersList{}, err
@@ -90,11 +90,11 @@ func (r *UserRepoGRPC) FindUser(userID uuid.UUID) (UsersList, error) {
 }
 
 // FillProfile обновляет профиль пользователя
-func (r *UserRepoGRPC) FillProfile(userID uuid.UUID, userData UsersList) error {
+func (r *UserRepoGRPC) FillProfile(ctx context.Context, userID uuid.UUID, userData UsersList) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.UpdateUserProfile(ctx, &userpb.UpdateProfileRequest{
 		Id:        userID.String(),
 		Fio:       userData.Fio,
@@ -109,11 +109,11 @@ func (r *UserRepoGRPC) FillProfile(userID uuid.UUID, userData UsersList) error {
 }
 
 // AddRequest создает запрос на обучение
-func (r *UserRepoGRPC) AddRequest(studentID, teacherID uuid.UUID) error {
+func (r *UserRepoGRPC) AddRequest(ctx context.Context, studentID, teacherID uuid.UUID) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.AddRequestLink(ctx, &userpb.RelationRequest{
 		FromId: studentID.String(),
 		ToId:   teacherID.String(),
@@ -125,11 +125,11 @@ func (r *UserRepoGRPC) AddRequest(studentID, teacherID uuid.UUID) error {
 }
 
 // Accept подтверждает запрос на обучение
-func (r *UserRepoGRPC) Accept(teacherID, studentID uuid.UUID) error {
+func (r *UserRepoGRPC) Accept(ctx context.Context, teacherID, studentID uuid.UUID) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.AcceptRequest(ctx, &userpb.RelationRequest{
 		FromId: teacherID.String(),
 		ToId:   studentID.String(),
@@ -141,11 +141,11 @@ func (r *UserRepoGRPC) Accept(teacherID, studentID uuid.UUID) error {
 }
 
 // Deny отклоняет запрос на обучение
-func (r *UserRepoGRPC) Deny(teacherID, studentID uuid.UUID) error {
+func (r *UserRepoGRPC) Deny(ctx context.Context, teacherID, studentID uuid.UUID) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.DenyRequest(ctx, &userpb.RelationRequest{
 		FromId: teacherID.String(),
 		ToId:   studentID.String(),
@@ -157,11 +157,11 @@ func (r *UserRepoGRPC) Deny(teacherID, studentID uuid.UUID) error {
 }
 
 // ShowRequests возвращает список запросов на обучение
-func (r *UserRepoGRPC) ShowRequests(userID uuid.UUID) ([]UsersList, error) {
+func (r *UserRepoGRPC) ShowRequests(ctx context.Context, userID uuid.UUID) ([]UsersList, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	reqIDs, err := r.db.GetRequests(ctx, &userpb.UserIDRequest{Id: userID.String()})
 	if err != nil {
 		return nil, err
@@ -185,11 +185,11 @@ func (r *UserRepoGRPC) ShowRequests(userID uuid.UUID) ([]UsersList, error) {
 }
 
 // AddRating добавляет и усредняет оценку преподавателя
-func (r *UserRepoGRPC) AddRating(userID uuid.UUID, newRating float32) error {
+func (r *UserRepoGRPC) AddRating(ctx context.Context, userID uuid.UUID, newRating float32) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 
 	resp, err := r.db.GetRating(ctx, &userpb.UserIDRequest{Id: userID.String()})
 	if err != nil {
@@ -206,11 +206,11 @@ func (r *UserRepoGRPC) AddRating(userID uuid.UUID, newRating float32) error {
 }
 
 // HasThatTeacher проверяет связь студента с преподавателем
-func (r *UserRepoGRPC) HasThatTeacher(studentID, teacherID uuid.UUID) (bool, error) {
+func (r *UserRepoGRPC) HasThatTeacher(ctx context.Context, studentID, teacherID uuid.UUID) (bool, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.HasTeacher(ctx, &userpb.RelationRequest{
 		FromId: studentID.String(),
 		ToId:   teacherID.String(),
@@ -222,11 +222,11 @@ func (r *UserRepoGRPC) HasThatTeacher(studentID, teacherID uuid.UUID) (bool, err
 }
 
 // StudentsByTeacher возвращает список студентов преподавателя
-func (r *UserRepoGRPC) StudentsByTeacher(teacherID uuid.UUID) ([]UsersList, error) {
+func (r *UserRepoGRPC) StudentsByTeacher(ctx context.Context, teacherID uuid.UUID) ([]UsersList, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.GetStudentsByTeacher(ctx, &userpb.UserIDRequest{Id: teacherID.String()})
 	if err != nil {
 		return nil, err
@@ -244,11 +244,11 @@ func (r *UserRepoGRPC) StudentsByTeacher(teacherID uuid.UUID) ([]UsersList, erro
 }
 
 // TeachersByStudent возвращает список преподавателей студента
-func (r *UserRepoGRPC) TeachersByStudent(studentID uuid.UUID) ([]UsersList, error) {
+func (r *UserRepoGRPC) TeachersByStudent(ctx context.Context, studentID uuid.UUID) ([]UsersList, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	resp, err := r.db.GetTeachersByStudent(ctx, &userpb.UserIDRequest{Id: studentID.String()})
 	if err != nil {
 		return nil, err
@@ -268,11 +268,11 @@ func (r *UserRepoGRPC) TeachersByStudent(studentID uuid.UUID) ([]UsersList, erro
 }
 
 // EditGrade обновляет среднюю оценку студента
-func (r *UserRepoGRPC) EditGrade(studentID uuid.UUID, grade float32) error {
+func (r *UserRepoGRPC) EditGrade(ctx context.Context, studentID uuid.UUID, grade float32) error {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 	_, err := r.db.UpdateRating(ctx, &userpb.UpdateRatingRequest{
 		Id:        studentID.String(),
 		NewRating: grade,
@@ -284,11 +284,11 @@ func (r *UserRepoGRPC) EditGrade(studentID uuid.UUID, grade float32) error {
 }
 
 // outBySpecialty вспомогательная функция для сортировки преподавателей
-func (r *UserRepoGRPC) outBySpecialty(orderField, specialty string, studentID uuid.UUID, ascending bool) ([]UsersList, error) {
+func (r *UserRepoGRPC) outBySpecialty(ctx context.Context, orderField, specialty string, studentID uuid.UUID, ascending bool) ([]UsersList, error) {
 	md := metadata.New(map[string]string{
 		authorization: bearer + userToken,
 	})
-	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	ctx = metadata.NewOutgoingContext(context.WithoutCancel(ctx), md)
 
 	links, err := r.db.GetUserLinks(ctx, &userpb.UserIDRequest{Id: studentID.String()})
 	if err != nil {
@@ -339,11 +339,11 @@ func (r *UserRepoGRPC) outBySpecialty(orderField, specialty string, studentID uu
 }
 
 // OutAscendingBySpecialty возвращает отсортированный по возрастанию список преподавателей
-func (r *UserRepoGRPC) OutAscendingBySpecialty(orderField, specialty string, studentID uuid.UUID) ([]UsersList, error) {
-	return r.outBySpecialty(orderField, specialty, studentID, true)
+func (r *UserRepoGRPC) OutAscendingBySpecialty(ctx context.Context, orderField, specialty string, studentID uuid.UUID) ([]UsersList, error) {
+	return r.outBySpecialty(ctx, orderField, specialty, studentID, true)
 }
 
 // OutDescendingBySpecialty возвращает отсортированный по убыванию список преподавателей
-func (r *UserRepoGRPC) OutDescendingBySpecialty(orderField, specialty string, studentID uuid.UUID) ([]UsersList, error) {
-	return r.outBySpecialty(orderField, specialty, studentID, false)
+func (r *UserRepoGRPC) OutDescendingBySpecialty(ctx context.Context, orderField, specialty string, studentID uuid.UUID) ([]UsersList, error) {
+	return r.outBySpecialty(ctx, orderField, specialty, studentID, false)
 }
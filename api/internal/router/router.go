@@ -7,6 +7,7 @@ import (
 	loggergrpc "api/internal/loggerGRPC"
 	"api/internal/middleware"
 	"api/internal/repo"
+	"api/internal/tracing"
 	"context"
 	"log"
 	"net/http"
@@ -59,6 +60,7 @@ func CreateNewRouter() *mux.Router {
 	// Устанавливаем соединения с микросервисами
 	userConn, err := grpc.DialContext(ctx, userAddr, //nolint:staticcheck
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()),
 		grpc.WithBlock()) //nolint:staticcheck
 	if err != nil {
 		log.Fatalf("failed to connect to user service: %v", err)
@@ -66,6 +68,7 @@ func CreateNewRouter() *mux.Router {
 
 	chatConn, err := grpc.DialContext(ctx, chatAddr, //nolint:staticcheck
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()),
 		grpc.WithBlock()) //nolint:staticcheck
 	if err != nil {
 		log.Fatalf("failed to connect to chat service: %v", err)
@@ -73,6 +76,7 @@ func CreateNewRouter() *mux.Router {
 
 	sessionConn, err := grpc.DialContext(ctx, sessionAddr, //nolint:staticcheck
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()),
 		grpc.WithBlock()) //nolint:staticcheck
 	if err != nil {
 		log.Fatalf("failed to connect to session service: %v", err)
@@ -80,6 +84,7 @@ func CreateNewRouter() *mux.Router {
 
 	taskConn, err := grpc.DialContext(ctx, taskAddr, //nolint:staticcheck
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()),
 		grpc.WithBlock()) //nolint:staticcheck
 	if err != nil {
 		log.Fatalf("failed to connect to task service: %v", err)
@@ -141,6 +146,7 @@ func CreateNewRouter() *mux.Router {
 
 	// Создаем основной роутер
 	router := mux.NewRouter()
+	router.Use(tracing.Middleware)
 
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -148,7 +154,7 @@ func CreateNewRouter() *mux.Router {
 	}).Methods("GET")
 
 	// Настраиваем раздачу статических файлов
-	router.PathPrefix("/assets/").Handler(http.StripPrefix("/assets/", http.FileServer(http.Dir("assets"))))
+	router.PathPrefix("/assets/").Handler(handlers.CacheAssets(http.StripPrefix("/assets/", http.FileServer(http.Dir("assets")))))
 
 	// Маршруты для шифрования и аутентификации
 	router.HandleFunc("/api/key-exchange", authHandler.EncryptionKey).Methods("POST")
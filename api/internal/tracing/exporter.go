@@ -0,0 +1,264 @@
+// Package tracing - трассировка запросов в формате W3C Trace Context и экспорт операций
+// в коллектор OpenTelemetry по OTLP/HTTP.
+//
+// Пакет один и тот же в load_balancer/tracing (основная копия) и api/internal/tracing:
+// модули собираются в отдельных Docker-образах, и общий модуль к ним не подключить.
+// Изменения вносятся в основную копию и переносятся без правок, CI сравнивает каталоги
+// целиком. Свои у каждого модуля только middleware.go с middleware_test.go
+// (операция на входящий запрос) и grpc.go в api
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// путь приёма трасс коллектором OTLP/HTTP
+const tracesPath = "/v1/traces"
+
+// код статуса операции в OTLP: 2 - ошибка
+const statusCodeError = 2
+
+// Config - параметры экспорта операций в коллектор OpenTelemetry
+type Config struct {
+	Endpoint    string        // адрес коллектора OTLP/HTTP, например http://otel-collector:4318
+	ServiceName string        // service.name ресурса
+	BatchSize   int           // операций в одном запросе к коллектору
+	Interval    time.Duration // неполная пачка отправляется не реже
+	Timeout     time.Duration // таймаут запроса к коллектору
+	OnError     func(error)   // ошибка отправки пачки, nil - не сообщать; логирует модуль
+}
+
+// service.name по умолчанию по спецификации OpenTelemetry
+const defaultServiceName = "unknown_service"
+
+// Exporter - отправка завершённых операций пачками в формате OTLP/HTTP JSON.
+// Очередь ограничена: при недоступном коллекторе операции отбрасываются, а не копятся
+type Exporter struct {
+	cfg    Config
+	url    string
+	client *http.Client
+	queue  chan *Span
+	done   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+var exporter atomic.Pointer[Exporter]
+
+// NewExporter - запустить экспортёр; операции начинают отправляться после SetExporter
+func NewExporter(cfg Config) *Exporter {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaultServiceName
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 128
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+
+	e := &Exporter{
+		cfg:    cfg,
+		url:    strings.TrimRight(cfg.Endpoint, "/") + tracesPath,
+		client: &http.Client{Timeout: cfg.Timeout},
+		queue:  make(chan *Span, cfg.BatchSize*16),
+		done:   make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e
+}
+
+// SetExporter - экспортёр завершённых операций; nil - операции не экспортируются
+func SetExporter(e *Exporter) {
+	exporter.Store(e)
+}
+
+func (e *Exporter) enqueue(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+	}
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, e.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil && e.cfg.OnError != nil {
+			e.cfg.OnError(err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) == e.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			// отправляем то, что уже завершено
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+					if len(batch) == e.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown - отправить накопленные операции и остановить экспортёр
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.done) })
+
+	finished := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Exporter) send(batch []*Span) error {
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+// структуры ExportTraceServiceRequest в JSON-представлении OTLP: идентификаторы в hex,
+// 64-битные числа строками
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              SpanKind        `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code int `json:"code"`
+	}
+)
+
+func (e *Exporter) request(batch []*Span) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		spans = append(spans, s.otlp())
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: e.cfg.ServiceName}},
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: e.cfg.ServiceName},
+			Spans: spans,
+		}},
+	}}}
+}
+
+func (s *Span) otlp() otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := otlpSpan{
+		TraceID:           s.sc.TraceIDString(),
+		SpanID:            s.sc.SpanIDString(),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	if s.parent != [8]byte{} {
+		span.ParentSpanID = SpanContext{SpanID: s.parent}.SpanIDString()
+	}
+	keys := make([]string, 0, len(s.attrs))
+	for key := range s.attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		span.Attributes = append(span.Attributes, otlpAttribute{Key: key, Value: otlpValue{StringValue: s.attrs[key]}})
+	}
+	if s.err {
+		span.Status = &otlpStatus{Code: statusCodeError}
+	}
+	return span
+}
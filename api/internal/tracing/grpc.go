@@ -0,0 +1,30 @@
+package tracing
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor - операция на каждый вызов gRPC; её traceparent добавляется
+// в исходящие метаданные, чтобы сервис продолжил трассу запроса
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := Start(ctx, method, KindClient)
+		defer span.End()
+		span.SetAttribute("rpc.system", "grpc")
+		span.SetAttribute("rpc.method", method)
+		span.SetAttribute("server.address", cc.Target())
+
+		ctx = metadata.AppendToOutgoingContext(ctx, Header, span.Context().Traceparent())
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		span.SetAttribute("rpc.grpc.status_code", status.Code(err).String())
+		if err != nil {
+			span.SetError()
+		}
+		return err
+	}
+}
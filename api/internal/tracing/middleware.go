@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Middleware - операция на каждый запрос к роутеру: продолжает трассу из traceparent
+// балансировщика или начинает новую, если запрос пришёл напрямую
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if remote, ok := Parse(r.Header.Get(Header)); ok {
+			ctx = WithRemote(ctx, remote)
+		}
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		ctx, span := Start(ctx, r.Method+" "+route, KindServer)
+		defer span.End()
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("http.route", route)
+
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		span.SetAttribute("http.response.status_code", strconv.Itoa(sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetError()
+		}
+	})
+}
+
+// statusWriter - запоминает код ответа; Hijack нужен чату на WebSocket
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+var (
+	_ http.Flusher  = &statusWriter{}
+	_ http.Hijacker = &statusWriter{}
+)
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if sw.status == 0 && code >= http.StatusOK {
+		sw.status = code
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+func (sw *statusWriter) Flush() {
+	http.NewResponseController(sw.ResponseWriter).Flush() //nolint:errcheck
+}
+
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(sw.ResponseWriter).Hijack()
+	if err == nil {
+		sw.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const balancerParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// запрос от балансировщика проходит роутер и уходит в gRPC с traceparent той же трассы
+func TestPropagation(t *testing.T) {
+	c, srv := newCollector(t)
+	e := NewExporter(Config{Endpoint: srv.URL, ServiceName: "api", Interval: time.Hour})
+	SetExporter(e)
+	defer SetExporter(nil)
+
+	conn, err := grpc.NewClient("passthrough:///user-service", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close() //nolint:errcheck
+
+	var outgoing []string
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		outgoing = md.Get(Header)
+		return nil
+	}
+
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/api/get-profile", func(w http.ResponseWriter, r *http.Request) {
+		// как repo: свои метаданные поверх контекста запроса
+		ctx := metadata.NewOutgoingContext(r.Context(), metadata.Pairs("authorization", "Bearer user-token"))
+		err := UnaryClientInterceptor()(ctx, "/user.UserService/FindUser", nil, nil, conn, invoker)
+		if err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/get-profile", nil)
+	req.Header.Set(Header, balancerParent)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(outgoing) != 1 {
+		t.Fatalf("outgoing traceparent = %v, want one value", outgoing)
+	}
+	rpc, ok := Parse(outgoing[0])
+	if !ok || rpc.TraceIDString() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("outgoing traceparent %q does not continue the balancer trace", outgoing[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(c.spans))
+	}
+	for _, name := range c.names {
+		if name != "api" {
+			t.Errorf("service.name = %q", name)
+		}
+	}
+	client, server := c.spans[0], c.spans[1]
+	if server.Name != "GET /api/get-profile" || server.Kind != KindServer || server.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("server span = %+v", server)
+	}
+	if client.Kind != KindClient || client.SpanID != rpc.SpanIDString() || client.ParentSpanID != server.SpanID {
+		t.Errorf("client span = %+v, server span id %s", client, server.SpanID)
+	}
+	for _, attr := range server.Attributes {
+		if attr.Key == "http.response.status_code" && attr.Value.StringValue != "418" {
+			t.Errorf("status attribute = %s", attr.Value.StringValue)
+		}
+	}
+}
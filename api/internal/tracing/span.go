@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SpanKind - роль операции в обмене (значения из OTLP)
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Span - операция сервиса внутри трассы: обработка запроса или вызов другого сервиса
+type Span struct {
+	sc     SpanContext
+	parent [8]byte // SpanID родителя, нули - корень трассы
+	name   string
+	kind   SpanKind
+	start  time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs map[string]string
+	err   bool
+	ended bool
+}
+
+type spanKey struct{}
+
+// Start - начать операцию, дочернюю к операции из ctx (своей или пришедшей в traceparent);
+// без родителя начинается новая трасса
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	s := &Span{
+		name:  name,
+		kind:  kind,
+		start: time.Now(),
+		attrs: make(map[string]string),
+	}
+
+	if parent, ok := FromContext(ctx); ok {
+		s.sc = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+		s.parent = parent.SpanID
+	} else {
+		s.sc = SpanContext{TraceID: newTraceID(), Sampled: true}
+	}
+	s.sc.SpanID = newSpanID()
+
+	return context.WithValue(ctx, spanKey{}, s.sc), s
+}
+
+// WithRemote - контекст с операцией другого сервиса, от которой продолжается трасса
+func WithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+// FromContext - текущая операция
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// Context - идентификаторы операции для передачи дальше
+func (s *Span) Context() SpanContext {
+	return s.sc
+}
+
+// SetAttribute - атрибут операции (метод, маршрут, код ответа)
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs[key] = value
+}
+
+// SetError - операция завершилась ошибкой
+func (s *Span) SetError() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = true
+}
+
+// End - завершить операцию и передать её экспортёру; повторный вызов ничего не делает
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if !s.sc.Sampled {
+		return
+	}
+	if e := exporter.Load(); e != nil {
+		e.enqueue(s)
+	}
+}
@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		ok      bool
+		sampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"extra field in version 00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"short trace id", "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := Parse(tt.value)
+			if ok != tt.ok {
+				t.Fatalf("Parse(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if sc.Sampled != tt.sampled {
+				t.Errorf("sampled = %v, want %v", sc.Sampled, tt.sampled)
+			}
+			if sc.TraceIDString() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanIDString() != "00f067aa0ba902b7" {
+				t.Errorf("ids = %s %s", sc.TraceIDString(), sc.SpanIDString())
+			}
+		})
+	}
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	_, span := Start(context.Background(), "test", KindInternal)
+	sc, ok := Parse(span.Context().Traceparent())
+	if !ok || sc != span.Context() {
+		t.Fatalf("round trip = %+v %v, want %+v", sc, ok, span.Context())
+	}
+}
+
+// collector - заглушка коллектора OTLP/HTTP
+type collector struct {
+	mu    sync.Mutex
+	spans []otlpSpan
+	names []string
+	got   chan struct{}
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	c := &collector{got: make(chan struct{}, 16)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tracesPath || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected export request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode export request: %v", err)
+		}
+
+		c.mu.Lock()
+		for _, rs := range req.ResourceSpans {
+			for _, attr := range rs.Resource.Attributes {
+				if attr.Key == "service.name" {
+					c.names = append(c.names, attr.Value.StringValue)
+				}
+			}
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+		c.mu.Unlock()
+		c.got <- struct{}{}
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func TestExporter(t *testing.T) {
+	c, srv := newCollector(t)
+
+	e := NewExporter(Config{Endpoint: srv.URL, ServiceName: "test", BatchSize: 2, Interval: time.Hour})
+	SetExporter(e)
+	defer SetExporter(nil)
+
+	ctx, parent := Start(context.Background(), "GET", KindServer)
+	_, child := Start(ctx, "/user.UserService/FindUser", KindClient)
+	child.SetError()
+	child.End()
+	parent.SetAttribute("http.response.status_code", "200")
+	parent.End()
+	parent.End() // повторное завершение не экспортируется
+
+	// полная пачка уходит сразу
+	select {
+	case <-c.got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch was not exported")
+	}
+
+	// неполная пачка уходит при остановке
+	_, last := Start(context.Background(), "POST", KindServer)
+	last.End()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.spans) != 3 {
+		t.Fatalf("exported %d spans, want 3", len(c.spans))
+	}
+	for _, name := range c.names {
+		if name != "test" {
+			t.Errorf("service.name = %q", name)
+		}
+	}
+
+	gotChild, gotParent := c.spans[0], c.spans[1]
+	if gotChild.TraceID != gotParent.TraceID {
+		t.Errorf("child trace %s, parent trace %s", gotChild.TraceID, gotParent.TraceID)
+	}
+	if gotChild.ParentSpanID != gotParent.SpanID || gotParent.ParentSpanID != "" {
+		t.Errorf("parent links: child -> %q, parent %q -> %q", gotChild.ParentSpanID, gotParent.SpanID, gotParent.ParentSpanID)
+	}
+	if gotChild.Kind != KindClient || gotChild.Status == nil || gotChild.Status.Code != statusCodeError {
+		t.Errorf("child = %+v, want a failed client span", gotChild)
+	}
+	if gotParent.Status != nil || len(gotParent.Attributes) != 1 {
+		t.Errorf("parent = %+v", gotParent)
+	}
+}
+
+func TestExporterUnsampled(t *testing.T) {
+	c, srv := newCollector(t)
+
+	e := NewExporter(Config{Endpoint: srv.URL, BatchSize: 1, Interval: time.Hour})
+	SetExporter(e)
+	defer SetExporter(nil)
+
+	remote, _ := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, span := Start(WithRemote(context.Background(), remote), "GET", KindServer)
+	span.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.spans) != 0 {
+		t.Fatalf("exported %d spans of an unsampled trace", len(c.spans))
+	}
+}
+
+func TestExporterError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 1)
+	e := NewExporter(Config{Endpoint: srv.URL, BatchSize: 1, Interval: time.Hour, OnError: func(err error) { errs <- err }})
+	SetExporter(e)
+	defer SetExporter(nil)
+
+	_, span := Start(context.Background(), "GET", KindServer)
+	span.End()
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Fatal("OnError called without an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a failed export was not reported")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
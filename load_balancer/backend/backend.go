@@ -10,6 +10,7 @@ import (
 	"load_balancer/internal/logger"
 	"load_balancer/internal/messages"
 	"load_balancer/internal/response"
+	"load_balancer/internal/util"
 
 	"go.uber.org/zap"
 )
@@ -20,10 +21,35 @@ type backend struct {
 	activeConns  int64
 	mu           sync.RWMutex
 	alive        bool
+	draining     bool
+	weight       int
+	healthPath   string
+	healthStatus int
+	group        string
 }
 
+// группа серверов без явно заданной группы
+const DefaultGroup = "stable"
+
 var _ BackendIface = &backend{}
 
+// транспорт до серверов, общий для прокси и проверок; nil - http.DefaultTransport
+var transport atomic.Pointer[http.RoundTripper]
+
+// SetTransport - задать транспорт для серверов, созданных после вызова
+// (например, с CA для серверов по HTTPS)
+func SetTransport(rt http.RoundTripper) {
+	transport.Store(&rt)
+}
+
+// Transport - транспорт до серверов
+func Transport() http.RoundTripper {
+	if rt := transport.Load(); rt != nil {
+		return *rt
+	}
+	return http.DefaultTransport
+}
+
 func (back *backend) AddConn() {
 	atomic.AddInt64(&back.activeConns, 1)
 }
@@ -33,9 +59,7 @@ func (back *backend) RemoveConn() {
 }
 
 func (back *backend) GetConns() int64 {
-	back.mu.RLock()
-	defer back.mu.RUnlock()
-	return back.activeConns
+	return atomic.LoadInt64(&back.activeConns)
 }
 
 func (back *backend) SetStatus(alive bool) {
@@ -50,27 +74,93 @@ func (back *backend) IsAlive() bool {
 	return back.alive
 }
 
+func (back *backend) SetDraining(draining bool) {
+	back.mu.Lock()
+	defer back.mu.Unlock()
+	back.draining = draining
+}
+
+func (back *backend) IsDraining() bool {
+	back.mu.RLock()
+	defer back.mu.RUnlock()
+	return back.draining
+}
+
 func (back *backend) GetURL() string {
 	back.mu.RLock()
 	defer back.mu.RUnlock()
 	return back.url.String()
 }
 
+func (back *backend) GetWeight() int {
+	back.mu.RLock()
+	defer back.mu.RUnlock()
+	return back.weight
+}
+
+func (back *backend) SetWeight(weight int) {
+	back.mu.Lock()
+	defer back.mu.Unlock()
+	back.weight = weight
+}
+
+func (back *backend) SetHealthCheck(path string, status int) {
+	back.mu.Lock()
+	defer back.mu.Unlock()
+	back.healthPath = path
+	back.healthStatus = status
+}
+
+func (back *backend) GetHealthCheck() (path string, status int) {
+	back.mu.RLock()
+	defer back.mu.RUnlock()
+	return back.healthPath, back.healthStatus
+}
+
 func (back *backend) GetProxy() *httputil.ReverseProxy {
 	back.mu.RLock()
 	defer back.mu.RUnlock()
 	return back.reverseProxy
 }
 
-// создать структуру сервера
-func NewBackend(rawurl string) *backend {
-	parsedURL, err := url.Parse(rawurl)
-	if err != nil {
+func (back *backend) GetGroup() string {
+	back.mu.RLock()
+	defer back.mu.RUnlock()
+	return back.group
+}
+
+func (back *backend) SetGroup(group string) {
+	if group == "" {
+		group = DefaultGroup
+	}
+	back.mu.Lock()
+	defer back.mu.Unlock()
+	back.group = group
+}
+
+// ValidURL - адрес сервера с схемой http или https и хостом
+func ValidURL(rawurl string) bool {
+	u, err := url.Parse(rawurl)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// создать структуру сервера; неверный адрес (см. ValidURL) - nil
+func NewBackend(rawurl string, weight int) *backend {
+	if !ValidURL(rawurl) {
 		logger.Log.Error(messages.ErrInvalidBackendURL, zap.String(messages.URL, rawurl))
 		return nil
 	}
+	parsedURL, _ := url.Parse(rawurl)
 
 	proxy := httputil.NewSingleHostReverseProxy(parsedURL)
+	proxy.Transport = Transport()
+
+	// свой узел в Forwarded и IP клиента в X-Real-IP для сервера
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		util.AppendForwarded(r)
+	}
 
 	// переопределение обработчика ошибок прокси
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
@@ -82,5 +172,7 @@ func NewBackend(rawurl string) *backend {
 		url:          parsedURL,
 		reverseProxy: proxy,
 		alive:        true,
+		weight:       weight,
+		group:        DefaultGroup,
 	}
 }
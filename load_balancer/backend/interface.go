@@ -3,11 +3,19 @@ package backend
 import "net/http/httputil"
 
 type BackendIface interface {
-	AddConn()                         //увеличивает счётчик активных соединений
-	RemoveConn()                      //уменьшает счётчик активных соединений
-	GetConns() int64                  //получить количество активных соединений
-	SetStatus(alive bool)             //установить статус сервера (доступен - не доступен)
-	IsAlive() bool                    //получить статус сервера
-	GetURL() string                   //получить URL сервера
-	GetProxy() *httputil.ReverseProxy //получить reverse proxy сервера
+	AddConn()                                  //увеличивает счётчик активных соединений
+	RemoveConn()                               //уменьшает счётчик активных соединений
+	GetConns() int64                           //получить количество активных соединений
+	SetStatus(alive bool)                      //установить статус сервера (доступен - не доступен)
+	IsAlive() bool                             //получить статус сервера
+	SetDraining(draining bool)                 //перевести сервер в режим вывода из балансировки
+	IsDraining() bool                          //сервер не принимает новые запросы, ждёт завершения текущих
+	GetURL() string                            //получить URL сервера
+	GetWeight() int                            //получить вес сервера для взвешенной балансировки
+	SetWeight(weight int)                      //установить вес сервера
+	SetHealthCheck(path string, status int)    //задать путь проверки и ожидаемый код (пусто - по умолчанию)
+	GetHealthCheck() (path string, status int) //получить путь проверки и ожидаемый код
+	GetProxy() *httputil.ReverseProxy          //получить reverse proxy сервера
+	GetGroup() string                          //группа сервера для разделения трафика (stable, canary)
+	SetGroup(group string)                     //задать группу сервера, пусто - DefaultGroup
 }
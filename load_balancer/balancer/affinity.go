@@ -0,0 +1,86 @@
+package balancer
+
+import (
+	"hash/fnv"
+	"net/http"
+	"strconv"
+
+	"load_balancer/backend"
+)
+
+// affinity - привязка клиента к серверу: чат держит комнаты в памяти реплики api,
+// поэтому запросы одного пользователя должны попадать на одну и ту же реплику
+type affinity struct {
+	keyCookie string // cookie, по хешу которой выбирается сервер (токен авторизации)
+	cookie    string // cookie с идентификатором сервера, которую выдаёт балансировщик
+}
+
+// EnableAffinity - включить привязку клиента к серверу
+func (lb *loadBalancer) EnableAffinity(keyCookie, cookie string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.affinity = &affinity{
+		keyCookie: keyCookie,
+		cookie:    cookie,
+	}
+}
+
+// выбор закреплённого за клиентом сервера, second - нужно ли выдать новую cookie привязки
+func (a *affinity) pick(servers []backend.BackendIface, r *http.Request) (backend.BackendIface, bool) {
+	alive := make([]backend.BackendIface, 0, len(servers))
+	for _, back := range servers {
+		if back.IsAlive() {
+			alive = append(alive, back)
+		}
+	}
+
+	if len(alive) == 0 {
+		return nil, false
+	}
+
+	// сервер, ранее выданный балансировщиком, пока он доступен
+	if c, err := r.Cookie(a.cookie); err == nil {
+		for _, back := range alive {
+			if backendID(back) == c.Value {
+				return back, false
+			}
+		}
+	}
+
+	// rendezvous-хеширование по токену: при падении сервера переезжают только его клиенты
+	if c, err := r.Cookie(a.keyCookie); err == nil && c.Value != "" {
+		var selected backend.BackendIface
+		var best uint64
+		for _, back := range alive {
+			if score := hash64(c.Value + back.GetURL()); selected == nil || score > best {
+				selected, best = back, score
+			}
+		}
+		return selected, true
+	}
+
+	return nil, true
+}
+
+// добавить в заголовки ответа cookie привязки к серверу
+func (a *affinity) issue(h http.Header, back backend.BackendIface) {
+	cookie := &http.Cookie{
+		Name:     a.cookie,
+		Value:    backendID(back),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	h.Add("Set-Cookie", cookie.String())
+}
+
+// идентификатор сервера для cookie, чтобы не раскрывать внутренние адреса
+func backendID(back backend.BackendIface) string {
+	return strconv.FormatUint(hash64(back.GetURL()), 36)
+}
+
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s)) //nolint:errcheck
+	return h.Sum64()
+}
@@ -0,0 +1,145 @@
+package balancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"load_balancer/backend"
+	"load_balancer/strategy"
+)
+
+func testAffinity() *affinity {
+	return &affinity{keyCookie: "authToken", cookie: "lb_affinity"}
+}
+
+func affinityServers(n int) []backend.BackendIface {
+	servers := make([]backend.BackendIface, 0, n)
+	for i := range n {
+		servers = append(servers, backend.NewBackend("http://10.0.0."+strconv.Itoa(i+1)+":8080", 1))
+	}
+	return servers
+}
+
+func affinityRequest(token, pin string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		r.AddCookie(&http.Cookie{Name: "authToken", Value: token})
+	}
+	if pin != "" {
+		r.AddCookie(&http.Cookie{Name: "lb_affinity", Value: pin})
+	}
+	return r
+}
+
+func TestAffinityPin(t *testing.T) {
+	a := testAffinity()
+	servers := affinityServers(3)
+
+	// закреплённый сервер важнее хеша токена
+	hashed, _ := a.pick(servers, affinityRequest("token", ""))
+	for _, pinned := range servers {
+		if pinned == hashed {
+			continue
+		}
+		got, issue := a.pick(servers, affinityRequest("token", backendID(pinned)))
+		if got != pinned {
+			t.Fatalf("picked %s, want the pinned %s", got.GetURL(), pinned.GetURL())
+		}
+		if issue {
+			t.Fatal("a valid pin must not be issued again")
+		}
+	}
+}
+
+func TestAffinityDeadPin(t *testing.T) {
+	a := testAffinity()
+	servers := affinityServers(3)
+
+	pinned, _ := a.pick(servers, affinityRequest("token", ""))
+	pinned.SetStatus(false)
+
+	got, issue := a.pick(servers, affinityRequest("token", backendID(pinned)))
+	if got == nil || got == pinned {
+		t.Fatalf("picked %v, want another alive backend", got)
+	}
+	if !issue {
+		t.Fatal("the new backend must be pinned")
+	}
+
+	// тот же выбор, что и у хеширования без старой привязки
+	if want, _ := a.pick(servers, affinityRequest("token", "")); got != want {
+		t.Fatalf("picked %s, rendezvous hashing gives %s", got.GetURL(), want.GetURL())
+	}
+}
+
+func TestAffinityRemove(t *testing.T) {
+	a := testAffinity()
+	servers := affinityServers(4)
+	removed := servers[1]
+	rest := []backend.BackendIface{servers[0], servers[2], servers[3]}
+
+	moved := 0
+	for i := range 1000 {
+		r := affinityRequest("token-"+strconv.Itoa(i), "")
+		before, _ := a.pick(servers, r)
+		after, _ := a.pick(rest, r)
+
+		if before == removed {
+			moved++
+			continue
+		}
+		if after != before {
+			t.Fatalf("client %d moved from %s to %s", i, before.GetURL(), after.GetURL())
+		}
+	}
+
+	if moved < 150 || moved > 350 {
+		t.Fatalf("%d of 1000 clients were on the removed backend, want about 250", moved)
+	}
+}
+
+func TestAffinityIssue(t *testing.T) {
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewBalancer(strat)
+	for range 2 {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok") //nolint:errcheck
+		}))
+		t.Cleanup(srv.Close)
+		if err := lb.AddBack(backend.NewBackend(srv.URL, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lb.EnableAffinity("authToken", "lb_affinity")
+
+	pinOf := func(r *http.Request) string {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d", rec.Code)
+		}
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == "lb_affinity" {
+				return c.Value
+			}
+		}
+		return ""
+	}
+
+	pin := pinOf(affinityRequest("token", ""))
+	if pin == "" {
+		t.Fatal("a new client must get a pin")
+	}
+	if got := pinOf(affinityRequest("token", pin)); got != "" {
+		t.Fatalf("a pinned client got a new pin %q", got)
+	}
+	if got := pinOf(affinityRequest("token", "unknown")); got != pin {
+		t.Fatalf("a stale pin was replaced with %q, want %q", got, pin)
+	}
+}
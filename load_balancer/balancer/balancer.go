@@ -2,15 +2,16 @@ package balancer
 
 import (
 	"net/http"
-	"net/http/httptest"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"load_balancer/backend"
+	"load_balancer/breaker"
 	"load_balancer/internal/logger"
 	"load_balancer/internal/messages"
 	"load_balancer/internal/response"
-	"load_balancer/internal/util"
 	"load_balancer/metrics"
 	"load_balancer/strategy"
 
@@ -19,89 +20,141 @@ import (
 
 // BalancerIface - интерфейс балансировщика
 type loadBalancer struct {
-	mu      sync.RWMutex           // мьютекс для безопасного доступа к серверам
-	servers []backend.BackendIface // список серверов
+	mu       sync.RWMutex           // мьютекс для безопасного доступа к серверам
+	servers  []backend.BackendIface // список серверов
+	manual   map[string]bool        // серверы, добавленные через admin API: перезагрузка конфига их не удаляет
+	strategy strategy.Strategy      // стратегия выбора сервера
+	affinity *affinity              // привязка клиента к серверу, nil - выключена
+	split    *splitter              // разделение трафика между группами, nil - выключено
+	hedge    *hedger                // запасные запросы для GET, nil - выключены
+	body     bodyPolicy             // буферизация тела запроса для повторов
+	attempts int                    // предел попыток на запрос, 0 - по числу серверов
+	health   *healthChecker         // активная и пассивная проверка серверов
+	breaker  *breaker.Config        // параметры предохранителя серверов, nil - выключен
+
+	websockets wsConns // WebSocket-соединения клиентов для закрытия при остановке
 }
 
 var _ BalancerIface = &loadBalancer{} // проверяем, что loadBalancer реализует интерфейс BalancerIface
 
-func NewBalancer() *loadBalancer {
-	return &loadBalancer{}
+func NewBalancer(strat strategy.Strategy) *loadBalancer {
+	return &loadBalancer{
+		strategy: strat,
+		health:   newHealthChecker(defaultHealthConfig()),
+	}
 }
 
-// AddBack - добавление сервера в список
-func (lb *loadBalancer) AddBack(server backend.BackendIface) {
+// SetAttempts - предел попыток на запрос, 0 - по числу серверов
+func (lb *loadBalancer) SetAttempts(n int) {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
-	lb.servers = append(lb.servers, server)
+	lb.attempts = n
 }
 
-// GetBack - получение серверов
+// GetServers - получение серверов, принимающих новые запросы (без draining)
 func (lb *loadBalancer) GetServers() []backend.BackendIface {
 	lb.mu.RLock()
 	defer lb.mu.RUnlock()
-	return lb.servers
+
+	servers := make([]backend.BackendIface, 0, len(lb.servers))
+	for _, server := range lb.servers {
+		if !server.IsDraining() {
+			servers = append(servers, server)
+		}
+	}
+	return servers
 }
 
-// выбор сервера
-func (lb *loadBalancer) getNextBack() backend.BackendIface {
-	return strategy.GetLeastConns(lb)
+// выбор сервера, issue - нужно ли выдать клиенту cookie привязки к выбранному серверу
+func (lb *loadBalancer) getNextBack(r *http.Request) (server backend.BackendIface, issue bool) {
+	lb.mu.RLock()
+	aff := lb.affinity
+	split := lb.split
+	lb.mu.RUnlock()
+
+	servers := lb.GetServers()
+	if split != nil {
+		servers = inGroup(servers, split.group(r))
+	}
+
+	if aff != nil {
+		server, issue = aff.pick(servers, r)
+	}
+	if server == nil {
+		server = lb.strategy.Next(serverSlice(servers), r)
+	}
+
+	return server, issue && server != nil
 }
 
 // ServeHTTP - обработка HTTP-запросов
 func (lb *loadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	lb.mu.RLock()
 	maxRetries := len(lb.servers)
+	if lb.attempts > 0 && lb.attempts < maxRetries {
+		maxRetries = lb.attempts
+	}
+	policy := lb.body
+	hedge := lb.hedge
 	lb.mu.RUnlock()
 
+	body, err := policy.prepare(r)
+	if err != nil {
+		logger.Log.Info(messages.ErrReadBody, zap.Error(err))
+		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrReadBody, nil)
+		return
+	}
+
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		server := lb.getNextBack()
+		server, issue := lb.getNextBack(r)
 		if server == nil {
+			logger.Log.Error(messages.ErrNoBackends)
 			if attempt == 0 {
 				response.WriteAPIResponse(w, http.StatusServiceUnavailable, false, messages.ErrNoBackends, nil)
+				return
 			}
-			logger.Log.Error(messages.ErrNoBackends)
-			return
+			break
 		}
 
-		server.AddConn()
-		metrics.BackendConnections.
-			WithLabelValues(server.GetURL()).
-			Set(float64(server.GetConns()))
-		defer func(s backend.BackendIface) {
-			s.RemoveConn()
-			metrics.BackendConnections.
-				WithLabelValues(s.GetURL()).
-				Set(float64(s.GetConns()))
-		}(server)
-
-		metrics.ProxiedRequestCount.
-			WithLabelValues(server.GetURL()).
-			Inc()
+		br, guarded := server.(breaker.BreakerIface)
+		if guarded && !br.Allow() {
+			continue
+		}
 
-		logger.Log.Info(messages.InfoForwardingURL,
-			zap.String(messages.URL, server.GetURL()),
-			zap.String(messages.InfoForwardingActive, strconv.Itoa(int(server.GetConns()))),
+		var (
+			aw      *attemptWriter
+			latency time.Duration
 		)
+		if attempt == 0 && hedge != nil && hedge.eligible(r, body) {
+			// ответить мог запасной сервер: дальше учитывается он
+			server, aw, latency = lb.hedged(w, r, server, issue, hedge)
+			br, guarded = server.(breaker.BreakerIface)
+		} else {
+			aw, latency = lb.proxy(w, body.request(r), server, issue)
+		}
+		statusCode := aw.status
 
-		recorder := httptest.NewRecorder()
-		server.GetProxy().ServeHTTP(recorder, r)
+		// клиент ушёл: 502 от ErrorHandler прокси - не отказ сервера, и повторять некому
+		if !aw.done() && r.Context().Err() != nil {
+			if guarded {
+				br.Release()
+			}
+			logger.Log.Info(messages.InfoClientGone,
+				zap.String(messages.URL, server.GetURL()))
+			return
+		}
 
-		statusCode := recorder.Code
-		metrics.BackendResponseStatus.
-			WithLabelValues(server.GetURL(), strconv.Itoa(statusCode)).
-			Inc()
+		if guarded {
+			br.Report(statusCode, latency)
+		}
 
-		if statusCode >= 200 && statusCode < 500 {
-			util.CopyHeadersAndBody(w, recorder)
+		if aw.done() {
 			logger.Log.Info(messages.InfoSuccessfulProxy,
 				zap.String(messages.URL, server.GetURL()))
 			return
 		}
 
-		metrics.ProxiedFailuresTotal.
-			WithLabelValues(server.GetURL()).
-			Inc()
+		lb.failure(server, statusCode, guarded)
 
 		logger.Log.Error(messages.ErrAttemptFailed,
 			zap.String(messages.Number, strconv.Itoa(attempt+1)),
@@ -110,10 +163,80 @@ func (lb *loadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			zap.String(messages.URL, r.URL.String()),
 		)
 
-		// помечаем backend как «плохой», если код ≥ 500
-		server.SetStatus(statusCode < 500)
+		if !body.retryable(r) {
+			logger.Log.Error(messages.ErrNotRetryable,
+				zap.String(messages.Method, r.Method),
+				zap.String(messages.URL, r.URL.String()),
+			)
+			break
+		}
 	}
 
 	logger.Log.Error(messages.ErrAllAttemptsFailed)
 	response.WriteAPIResponse(w, http.StatusServiceUnavailable, false, messages.ErrServiceUnavailable, nil)
 }
+
+// учёт неудачной попытки; без предохранителя помечаем backend как «плохой», если код ≥ 500
+func (lb *loadBalancer) failure(server backend.BackendIface, statusCode int, guarded bool) {
+	metrics.ProxiedFailuresTotal.
+		WithLabelValues(server.GetURL(), server.GetGroup()).
+		Inc()
+
+	if !guarded {
+		server.SetStatus(statusCode < 500)
+	}
+}
+
+// одна попытка проксирования на сервер; latency - время до отправки заголовков ответа
+func (lb *loadBalancer) proxy(w http.ResponseWriter, r *http.Request, server backend.BackendIface, issue bool) (*attemptWriter, time.Duration) {
+	server.AddConn()
+	metrics.BackendConnections.
+		WithLabelValues(server.GetURL()).
+		Set(float64(server.GetConns()))
+	defer func() {
+		server.RemoveConn()
+		metrics.BackendConnections.
+			WithLabelValues(server.GetURL()).
+			Set(float64(server.GetConns()))
+	}()
+
+	metrics.ProxiedRequestCount.
+		WithLabelValues(server.GetURL(), server.GetGroup()).
+		Inc()
+
+	logger.Log.Info(messages.InfoForwardingURL,
+		zap.String(messages.URL, server.GetURL()),
+		zap.String(messages.InfoForwardingActive, strconv.Itoa(int(server.GetConns()))),
+	)
+
+	start := time.Now()
+	aw := newAttemptWriter(w, func(h http.Header) {
+		if issue {
+			lb.affinity.issue(h, server)
+		}
+	})
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		aw.ws = &lb.websockets
+	}
+	server.GetProxy().ServeHTTP(aw, r)
+	aw.trailers()
+
+	// прокси ничего не записал - отдаём клиенту пустой 200
+	if aw.status == 0 {
+		aw.WriteHeader(http.StatusOK)
+	}
+
+	latency := time.Since(start)
+	if !aw.committedAt.IsZero() {
+		latency = aw.committedAt.Sub(start)
+	}
+
+	// отменённый запрос (клиент ушёл или ответила запасная попытка) - не ответ сервера
+	if r.Context().Err() == nil {
+		metrics.BackendResponseStatus.
+			WithLabelValues(server.GetURL(), strconv.Itoa(aw.status)).
+			Inc()
+	}
+
+	return aw, latency
+}
@@ -0,0 +1,80 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"load_balancer/backend"
+	"load_balancer/breaker"
+	"load_balancer/metrics"
+	"load_balancer/strategy"
+)
+
+// клиент ушёл посреди запроса: сервер не считается упавшим, попытка не повторяется
+func TestClientGone(t *testing.T) {
+	for _, guarded := range []bool{true, false} {
+		name := "unguarded"
+		if guarded {
+			name = "guarded"
+		}
+		t.Run(name, func(t *testing.T) {
+			var hits atomic.Int32
+			started := make(chan struct{}, 2)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				started <- struct{}{}
+				<-r.Context().Done()
+			}))
+			defer srv.Close()
+
+			strat, err := strategy.New(strategy.RoundRobin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lb := NewBalancer(strat)
+			if guarded {
+				lb.SetBreaker(breaker.Config{ErrorRate: 0.5, MinRequests: 1, Window: time.Minute, OpenTimeout: time.Minute, HalfOpenProbes: 1})
+			}
+			// второй адрес того же сервера: повтор был бы возможен
+			for _, url := range []string{srv.URL, srv.URL + "/"} {
+				if err := lb.AddBack(backend.NewBackend(url, 1)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			servers := lb.GetServers()
+			failures := func() (sum float64) {
+				for _, server := range servers {
+					sum += metrics.CounterValue(metrics.ProxiedFailuresTotal.WithLabelValues(server.GetURL(), server.GetGroup()))
+				}
+				return sum
+			}
+			before := failures()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				<-started
+				cancel()
+			}()
+			lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+			if got := hits.Load(); got != 1 {
+				t.Fatalf("%d attempts, want 1", got)
+			}
+			for _, server := range servers {
+				if !server.IsAlive() {
+					t.Fatalf("%s marked dead after the client left", server.GetURL())
+				}
+				if br, ok := server.(breaker.BreakerIface); ok && br.State() != breaker.Closed {
+					t.Fatalf("breaker %v after the client left, want closed", br.State())
+				}
+			}
+			if failures() != before {
+				t.Fatal("the client leaving was counted as a backend failure")
+			}
+		})
+	}
+}
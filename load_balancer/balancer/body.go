@@ -0,0 +1,92 @@
+package balancer
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// заголовок, которым клиент помечает POST-запрос как безопасный для повтора
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// bodyPolicy - политика буферизации тела запроса для повторных попыток
+type bodyPolicy struct {
+	enabled bool  // буферизовать тело запроса в памяти
+	maxSize int64 // максимальный размер буферизуемого тела в байтах
+}
+
+// requestBody - тело запроса, которое можно отправить повторно
+type requestBody struct {
+	data     []byte
+	buffered bool // тело целиком прочитано в память
+	empty    bool // у запроса нет тела
+}
+
+// SetBodyBuffering - задать политику буферизации тела запроса для повторов
+func (lb *loadBalancer) SetBodyBuffering(enabled bool, maxSize int64) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.body = bodyPolicy{
+		enabled: enabled,
+		maxSize: maxSize,
+	}
+}
+
+// чтение тела запроса в память, если это разрешено политикой и тело не больше лимита.
+// Если тело оказалось больше лимита, прочитанная часть склеивается с остатком
+// и запрос уходит на сервер без возможности повтора
+func (p bodyPolicy) prepare(r *http.Request) (*requestBody, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return &requestBody{empty: true}, nil
+	}
+
+	if !p.enabled || r.ContentLength > p.maxSize {
+		return &requestBody{}, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, p.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > p.maxSize {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+		return &requestBody{}, nil
+	}
+
+	r.Body.Close() //nolint:errcheck
+	return &requestBody{data: data, buffered: true}, nil
+}
+
+// запрос для очередной попытки со свежей копией тела
+func (b *requestBody) request(r *http.Request) *http.Request {
+	if !b.buffered {
+		return r
+	}
+
+	req := r.Clone(r.Context())
+	req.ContentLength = int64(len(b.data))
+	req.Body = io.NopCloser(bytes.NewReader(b.data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b.data)), nil
+	}
+	return req
+}
+
+// можно ли повторить запрос на другом сервере:
+// GET, HEAD и OPTIONS - всегда, остальные - только с буферизованным телом
+// или с заголовком Idempotency-Key, если тело можно отправить повторно
+func (b *requestBody) retryable(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return b.buffered || b.empty
+	}
+
+	if b.buffered {
+		return true
+	}
+	return b.empty && r.Header.Get(idempotencyKeyHeader) != ""
+}
@@ -0,0 +1,116 @@
+package balancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"load_balancer/backend"
+	"load_balancer/strategy"
+)
+
+func TestBodyPrepare(t *testing.T) {
+	policy := bodyPolicy{enabled: true, maxSize: 8}
+
+	tests := []struct {
+		name      string
+		body      string
+		length    int64 // -1 - размер неизвестен (chunked)
+		buffered  bool
+		retryable bool
+	}{
+		{name: "under the cap", body: "12345678", length: 8, buffered: true, retryable: true},
+		{name: "declared over the cap", body: "123456789", length: 9},
+		{name: "chunked under the cap", body: "1234", length: -1, buffered: true, retryable: true},
+		{name: "chunked over the cap", body: strings.Repeat("x", 100), length: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/upload", strings.NewReader(tt.body))
+			r.ContentLength = tt.length
+
+			body, err := policy.prepare(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if body.buffered != tt.buffered {
+				t.Fatalf("buffered=%v, want %v", body.buffered, tt.buffered)
+			}
+			if got := body.retryable(r); got != tt.retryable {
+				t.Fatalf("retryable=%v, want %v", got, tt.retryable)
+			}
+
+			// тело сверх лимита склеено из прочитанной части и остатка
+			data, err := io.ReadAll(body.request(r).Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.body {
+				t.Fatalf("server would get %q, want %q", data, tt.body)
+			}
+		})
+	}
+}
+
+func TestBodyDisabled(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPut, "/api/upload", strings.NewReader("data"))
+	body, err := bodyPolicy{maxSize: 1 << 20}.prepare(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.buffered || body.retryable(r) {
+		t.Fatal("the body must not be buffered when buffering is off")
+	}
+
+	// без тела запрос с Idempotency-Key можно повторить
+	r = httptest.NewRequest(http.MethodPost, "/api/logout", nil)
+	r.Header.Set(idempotencyKeyHeader, "key")
+	if body, _ := (bodyPolicy{}).prepare(r); !body.retryable(r) {
+		t.Fatal("an empty POST with Idempotency-Key must be retryable")
+	}
+}
+
+func TestBodyRetry(t *testing.T) {
+	// первая попытка (на любом сервере) падает, прочитав тело
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(data) //nolint:errcheck
+	})
+
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewBalancer(strat)
+	lb.SetBodyBuffering(true, 1<<20)
+	for range 2 {
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+		if err := lb.AddBack(backend.NewBackend(srv.URL, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	upload := strings.Repeat("chunk of a file ", 4096)
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/upload", strings.NewReader(upload)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("%d attempts, want 2", calls.Load())
+	}
+	if rec.Body.String() != upload {
+		t.Fatalf("the retry resent %d bytes, want %d", rec.Body.Len(), len(upload))
+	}
+}
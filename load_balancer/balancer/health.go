@@ -2,16 +2,65 @@ package balancer
 
 import (
 	"context"
+	"math/rand/v2"
 	"net/http"
+	"sync"
 	"time"
 
 	"load_balancer/backend"
+	"load_balancer/breaker"
+	configloading "load_balancer/config_loading"
 	"load_balancer/internal/logger"
 	"load_balancer/internal/messages"
+	"load_balancer/metrics"
 
 	"go.uber.org/zap"
 )
 
+// состояние проверок одного сервера
+type healthState struct {
+	successes    int       // успешных активных проверок подряд
+	failures     int       // неудачных активных проверок подряд
+	probing      bool      // проверка уже идёт
+	measured     bool      // счётчики прокси уже запомнены
+	requests     float64   // значение счётчика запросов на прошлой оценке
+	failed       float64   // значение счётчика 5xx на прошлой оценке
+	ejections    int       // исключений подряд, определяет время следующего
+	ejectedUntil time.Time // сервер исключён пассивной проверкой до этого момента
+}
+
+type healthChecker struct {
+	mu     sync.Mutex
+	cfg    configloading.HealthConfig
+	client *http.Client
+	states map[string]*healthState
+}
+
+func defaultHealthConfig() configloading.HealthConfig {
+	return configloading.HealthConfig{
+		Rise:    1,
+		Fall:    1,
+		Timeout: 2 * time.Second,
+		Path:    "/health",
+		Status:  http.StatusOK,
+	}
+}
+
+func newHealthChecker(cfg configloading.HealthConfig) *healthChecker {
+	return &healthChecker{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout, Transport: backend.Transport()},
+		states: make(map[string]*healthState),
+	}
+}
+
+// SetHealthConfig - задать параметры проверки серверов
+func (lb *loadBalancer) SetHealthConfig(cfg configloading.HealthConfig) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.health = newHealthChecker(cfg)
+}
+
 // периодическая проверка доступности серверов обработки запросов
 func (lb *loadBalancer) HealthCheck(ctx context.Context, tick <-chan time.Time) {
 	for {
@@ -22,22 +71,165 @@ func (lb *loadBalancer) HealthCheck(ctx context.Context, tick <-chan time.Time)
 			return
 
 		case <-tick:
-			for _, b := range lb.servers {
-				go func(backend backend.BackendIface) {
-					client := http.Client{Timeout: 2 * time.Second}
-					resp, err := client.Get(backend.GetURL() + "/health")
-
-					if err != nil || resp.StatusCode != http.StatusOK {
-						logger.Log.Info(messages.InfoUnreachable, zap.String(messages.URL, backend.GetURL()))
-						backend.SetStatus(false)
-					} else {
-						if !backend.IsAlive() {
-							logger.Log.Info(messages.InfoReachable, zap.String(messages.URL, backend.GetURL()))
-						}
-						backend.SetStatus(true)
-					}
-				}(b)
+			lb.mu.RLock()
+			hc := lb.health
+			lb.mu.RUnlock()
+
+			servers := lb.allServers()
+			hc.forget(servers)
+
+			for _, b := range servers {
+				// статус сервера проверяется без учёта предохранителя
+				if br, ok := b.(breaker.BreakerIface); ok {
+					b = br.Unwrap()
+				}
+				hc.evaluate(b)
+				go hc.probe(ctx, b)
 			}
 		}
 	}
 }
+
+// активная проверка: GET на путь проверки сервера после случайной задержки
+func (hc *healthChecker) probe(ctx context.Context, back backend.BackendIface) {
+	url := back.GetURL()
+
+	hc.mu.Lock()
+	st := hc.state(url)
+	if st.probing {
+		hc.mu.Unlock()
+		return
+	}
+	st.probing = true
+	hc.mu.Unlock()
+
+	defer func() {
+		hc.mu.Lock()
+		st.probing = false
+		hc.mu.Unlock()
+	}()
+
+	if hc.cfg.Jitter > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(rand.N(hc.cfg.Jitter)):
+		}
+	}
+
+	path, status := back.GetHealthCheck()
+	if path == "" {
+		path = hc.cfg.Path
+	}
+	if status == 0 {
+		status = hc.cfg.Status
+	}
+
+	ok := false
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+path, nil)
+	if err == nil {
+		resp, err := hc.client.Do(req)
+		if err == nil {
+			resp.Body.Close() //nolint:errcheck
+			ok = resp.StatusCode == status
+		}
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if !ok {
+		st.successes = 0
+		st.failures++
+		if back.IsAlive() && st.failures >= hc.cfg.Fall {
+			logger.Log.Info(messages.InfoUnreachable, zap.String(messages.URL, url))
+			back.SetStatus(false)
+		}
+		return
+	}
+
+	st.failures = 0
+	st.successes++
+	if !back.IsAlive() && st.successes >= hc.cfg.Rise && time.Now().After(st.ejectedUntil) {
+		logger.Log.Info(messages.InfoReachable, zap.String(messages.URL, url))
+		back.SetStatus(true)
+	}
+}
+
+// пассивная проверка: доля 5xx за прошедший интервал по счётчикам прокси
+func (hc *healthChecker) evaluate(back backend.BackendIface) {
+	if hc.cfg.EjectThreshold <= 0 {
+		return
+	}
+
+	url, group := back.GetURL(), back.GetGroup()
+	requests := metrics.CounterValue(metrics.ProxiedRequestCount.WithLabelValues(url, group))
+	failed := metrics.CounterValue(metrics.ProxiedFailuresTotal.WithLabelValues(url, group))
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	st := hc.state(url)
+	dRequests, dFailed := requests-st.requests, failed-st.failed
+	st.requests, st.failed = requests, failed
+
+	// первая оценка только запоминает текущие значения счётчиков
+	if !st.measured {
+		st.measured = true
+		return
+	}
+
+	if time.Now().Before(st.ejectedUntil) {
+		return
+	}
+
+	if dRequests < float64(hc.cfg.EjectMinRequests) || dRequests == 0 || dFailed/dRequests < hc.cfg.EjectThreshold {
+		// чистый интервал после исключения - сбрасываем счётчик исключений
+		if dRequests > 0 && dFailed == 0 {
+			st.ejections = 0
+		}
+		return
+	}
+
+	ejection := hc.cfg.EjectBase << min(st.ejections, 16)
+	if hc.cfg.EjectMax > 0 && ejection > hc.cfg.EjectMax {
+		ejection = hc.cfg.EjectMax
+	}
+	st.ejections++
+
+	st.ejectedUntil = time.Now().Add(ejection)
+	st.successes = 0
+	back.SetStatus(false)
+
+	metrics.BackendEjections.WithLabelValues(url).Inc()
+	logger.Log.Info(messages.InfoEjected,
+		zap.String(messages.URL, url),
+		zap.Duration(messages.Duration, ejection),
+	)
+}
+
+// удаление состояния серверов, которых больше нет в списке
+func (hc *healthChecker) forget(servers []backend.BackendIface) {
+	known := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		known[server.GetURL()] = true
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	for url := range hc.states {
+		if !known[url] {
+			delete(hc.states, url)
+		}
+	}
+}
+
+// состояние сервера, вызывается под hc.mu
+func (hc *healthChecker) state(url string) *healthState {
+	st, ok := hc.states[url]
+	if !ok {
+		st = &healthState{}
+		hc.states[url] = st
+	}
+	return st
+}
@@ -0,0 +1,178 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"load_balancer/backend"
+	configloading "load_balancer/config_loading"
+	"load_balancer/metrics"
+)
+
+func healthConfig() configloading.HealthConfig {
+	cfg := defaultHealthConfig()
+	cfg.Timeout = time.Second
+	return cfg
+}
+
+func TestHealthRiseFall(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := healthConfig()
+	cfg.Rise, cfg.Fall = 2, 3
+	hc := newHealthChecker(cfg)
+	back := backend.NewBackend(srv.URL, 1)
+	ctx := context.Background()
+
+	// сервер выводится из работы только после Fall неудачных проверок подряд
+	healthy.Store(false)
+	for i := range cfg.Fall {
+		if !back.IsAlive() {
+			t.Fatalf("marked dead after %d failed checks, want %d", i, cfg.Fall)
+		}
+		hc.probe(ctx, back)
+	}
+	if back.IsAlive() {
+		t.Fatalf("alive after %d failed checks", cfg.Fall)
+	}
+
+	// и возвращается после Rise успешных
+	healthy.Store(true)
+	hc.probe(ctx, back)
+	if back.IsAlive() {
+		t.Fatal("revived after a single successful check")
+	}
+	hc.probe(ctx, back)
+	if !back.IsAlive() {
+		t.Fatalf("dead after %d successful checks", cfg.Rise)
+	}
+
+	// успешная проверка обнуляет счётчик неудач
+	healthy.Store(false)
+	hc.probe(ctx, back)
+	hc.probe(ctx, back)
+	healthy.Store(true)
+	hc.probe(ctx, back)
+	healthy.Store(false)
+	hc.probe(ctx, back)
+	if !back.IsAlive() {
+		t.Fatal("failures must be counted in a row")
+	}
+}
+
+func TestHealthPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ready":
+			w.WriteHeader(http.StatusNoContent)
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	hc := newHealthChecker(healthConfig())
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		alive  bool
+	}{
+		{name: "defaults", alive: true},
+		{name: "own path and status", path: "/ready", status: http.StatusNoContent, alive: true},
+		{name: "own path, default status", path: "/ready", alive: false},
+		{name: "own status, default path", status: http.StatusNoContent, alive: false},
+		{name: "unknown path", path: "/live", alive: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			back := backend.NewBackend(srv.URL, 1)
+			back.SetHealthCheck(tt.path, tt.status)
+			hc.probe(context.Background(), back)
+			if back.IsAlive() != tt.alive {
+				t.Fatalf("alive=%v, want %v", back.IsAlive(), tt.alive)
+			}
+		})
+	}
+}
+
+func TestHealthEjection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cfg := healthConfig()
+	cfg.EjectThreshold = 0.5
+	cfg.EjectMinRequests = 10
+	cfg.EjectBase = 40 * time.Millisecond
+	cfg.EjectMax = 100 * time.Millisecond
+	hc := newHealthChecker(cfg)
+
+	back := backend.NewBackend(srv.URL, 1)
+	url := back.GetURL()
+	ctx := context.Background()
+
+	// интервал с requests запросами к серверу, из них failed - 5xx
+	interval := func(requests, failed int) {
+		metrics.ProxiedRequestCount.WithLabelValues(url, back.GetGroup()).Add(float64(requests))
+		metrics.ProxiedFailuresTotal.WithLabelValues(url, back.GetGroup()).Add(float64(failed))
+		hc.evaluate(back)
+	}
+	ejectedFor := func() time.Duration {
+		hc.mu.Lock()
+		defer hc.mu.Unlock()
+		return time.Until(hc.states[url].ejectedUntil)
+	}
+
+	interval(0, 0) // запоминаем счётчики
+
+	// мало запросов или мало ошибок - сервер остаётся
+	interval(5, 5)
+	interval(20, 9)
+	if !back.IsAlive() {
+		t.Fatal("ejected below the threshold or the minimum of requests")
+	}
+
+	// время исключения удваивается с каждым исключением подряд, но не больше EjectMax
+	for _, want := range []time.Duration{40 * time.Millisecond, 80 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond} {
+		interval(10, 6)
+		if back.IsAlive() {
+			t.Fatal("not ejected over the threshold")
+		}
+		if got := ejectedFor(); got > want || got < want-20*time.Millisecond {
+			t.Fatalf("ejected for %v, want %v", got, want)
+		}
+
+		// до конца исключения успешная проверка сервер не возвращает
+		hc.probe(ctx, back)
+		if back.IsAlive() {
+			t.Fatal("revived during the ejection")
+		}
+		time.Sleep(ejectedFor())
+		hc.probe(ctx, back)
+		if !back.IsAlive() {
+			t.Fatal("not revived after the ejection")
+		}
+	}
+
+	// интервал без ошибок сбрасывает счётчик исключений
+	interval(10, 0)
+	interval(10, 6)
+	if got := ejectedFor(); got > 40*time.Millisecond {
+		t.Fatalf("ejected for %v after a clean interval, want the base time", got)
+	}
+}
@@ -0,0 +1,333 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"load_balancer/backend"
+	"load_balancer/breaker"
+	configloading "load_balancer/config_loading"
+	"load_balancer/metrics"
+)
+
+// запас бюджета: сколько запасных запросов можно отправить подряд после затишья
+const hedgeBurst = 10
+
+// hedger - задержка запасного запроса по недавним ответам и бюджет запасных запросов:
+// каждый подходящий запрос добавляет Budget, запасной запрос тратит единицу
+type hedger struct {
+	cfg configloading.HedgeConfig
+
+	mu      sync.Mutex
+	samples []time.Duration // кольцевой буфер времени ответа
+	next    int
+	fresh   int           // ответов с прошлого пересчёта задержки
+	delay   time.Duration // 0 - ответов пока мало, запасные запросы не отправляются
+	tokens  float64
+}
+
+// SetHedging - включить запасные запросы; без бюджета или процентиля - выключить
+func (lb *loadBalancer) SetHedging(cfg configloading.HedgeConfig) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.hedge = newHedger(cfg)
+}
+
+func newHedger(cfg configloading.HedgeConfig) *hedger {
+	if cfg.Budget <= 0 || cfg.Percentile <= 0 || cfg.Percentile > 100 {
+		return nil
+	}
+	cfg.Window = max(cfg.Window, 10)
+	return &hedger{
+		cfg:     cfg,
+		samples: make([]time.Duration, 0, cfg.Window),
+	}
+}
+
+// подходит ли запрос для дублирования: GET или HEAD без тела и Upgrade
+func (h *hedger) eligible(r *http.Request, body *requestBody) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if !body.empty || r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	return len(h.cfg.Prefixes) == 0 || slices.ContainsFunc(h.cfg.Prefixes, func(prefix string) bool {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	})
+}
+
+// задержка запасного запроса; подходящий запрос пополняет бюджет
+func (h *hedger) start() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokens = min(h.tokens+h.cfg.Budget, hedgeBurst)
+	return h.delay
+}
+
+// списать запасной запрос из бюджета
+func (h *hedger) spend() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+// время ответа клиенту; процентиль пересчитывается раз в десятую часть окна
+func (h *hedger) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < h.cfg.Window {
+		h.samples = append(h.samples, latency)
+	} else {
+		h.samples[h.next] = latency
+		h.next = (h.next + 1) % h.cfg.Window
+	}
+
+	h.fresh++
+	if h.fresh < h.cfg.Window/10 {
+		return
+	}
+	h.fresh = 0
+
+	sorted := slices.Clone(h.samples)
+	slices.Sort(sorted)
+	i := int(float64(len(sorted)-1) * h.cfg.Percentile / 100)
+	h.delay = max(sorted[i], h.cfg.MinDelay)
+	metrics.HedgeDelay.Set(h.delay.Seconds())
+}
+
+// результат одной из параллельных попыток
+type hedgeResult struct {
+	server  backend.BackendIface
+	aw      *attemptWriter
+	latency time.Duration
+	hedged  bool // запасная попытка
+	lost    bool // клиенту ответила другая попытка
+}
+
+// hedgeRace - право ответить клиенту получает попытка, первой отправившая заголовки;
+// остальные отменяются, их ответ отбрасывается
+type hedgeRace struct {
+	w http.ResponseWriter
+
+	mu      sync.Mutex
+	winner  int // номер попытки, -1 - клиенту ещё никто не ответил
+	cancels []context.CancelFunc
+}
+
+func (hr *hedgeRace) claim(n int) bool {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	if hr.winner == -1 {
+		hr.winner = n
+		for i, cancel := range hr.cancels {
+			if i != n {
+				cancel()
+			}
+		}
+	}
+	return hr.winner == n
+}
+
+func (hr *hedgeRace) owner(n int) bool {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	return hr.winner == n
+}
+
+func (hr *hedgeRace) claimed() bool {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	return hr.winner != -1
+}
+
+// попытка с отменой, регистрируется до запуска
+func (hr *hedgeRace) add(r *http.Request) (int, *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.cancels = append(hr.cancels, cancel)
+	return len(hr.cancels) - 1, r.WithContext(ctx)
+}
+
+func (hr *hedgeRace) cancelAll() {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	for _, cancel := range hr.cancels {
+		cancel()
+	}
+}
+
+// raceWriter - ResponseWriter попытки в гонке: до победы ответ никуда не пишется
+type raceWriter struct {
+	race   *hedgeRace
+	n      int
+	header http.Header
+}
+
+// после победы заголовки попытки - заголовки клиента: так доходят трейлеры
+func (rw *raceWriter) Header() http.Header {
+	if rw.race.owner(rw.n) {
+		return rw.race.w.Header()
+	}
+	return rw.header
+}
+
+func (rw *raceWriter) WriteHeader(code int) {
+	// промежуточные ответы не определяют победителя и клиенту не передаются
+	if code < 200 || !rw.race.claim(rw.n) {
+		return
+	}
+	copyHeader(rw.race.w.Header(), rw.header)
+	rw.race.w.WriteHeader(code)
+}
+
+func (rw *raceWriter) Write(b []byte) (int, error) {
+	if !rw.race.owner(rw.n) {
+		return len(b), nil
+	}
+	return rw.race.w.Write(b)
+}
+
+func (rw *raceWriter) Flush() {
+	if rw.race.owner(rw.n) {
+		http.NewResponseController(rw.race.w).Flush() //nolint:errcheck
+	}
+}
+
+// первая попытка GET с запасной: если primary не отправил заголовки за задержку hedger,
+// тот же запрос уходит на другой сервер. Возвращается попытка, ответившая клиенту,
+// а если обе неудачны - попытка primary; запасная попытка учитывается здесь же
+func (lb *loadBalancer) hedged(w http.ResponseWriter, r *http.Request, primary backend.BackendIface, issue bool, h *hedger) (backend.BackendIface, *attemptWriter, time.Duration) {
+	start := time.Now()
+	delay := h.start()
+
+	race := &hedgeRace{w: w, winner: -1}
+	defer race.cancelAll()
+	results := make(chan hedgeResult, 2)
+
+	run := func(server backend.BackendIface, issue, hedged bool) {
+		n, req := race.add(r)
+		go func() {
+			rw := &raceWriter{race: race, n: n, header: make(http.Header)}
+			aw, latency := lb.proxy(rw, req, server, issue)
+			results <- hedgeResult{
+				server:  server,
+				aw:      aw,
+				latency: latency,
+				hedged:  hedged,
+				lost:    race.claimed() && !race.owner(n),
+			}
+		}()
+	}
+
+	run(primary, issue, false)
+	pending := 1
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case res := <-results:
+			timer.Stop()
+			return lb.hedgeOutcome(r.Context(), start, h, []hedgeResult{res})
+		case <-timer.C:
+		}
+
+		second := lb.hedgeTarget(r, primary)
+		switch {
+		case second == nil || race.claimed():
+		case !h.spend():
+			metrics.HedgesBudgetExhausted.Inc()
+		case allow(second):
+			metrics.HedgesFired.Inc()
+			run(second, false, true)
+			pending++
+		}
+	}
+
+	collected := make([]hedgeResult, 0, pending)
+	for ; pending > 0; pending-- {
+		collected = append(collected, <-results)
+	}
+	return lb.hedgeOutcome(r.Context(), start, h, collected)
+}
+
+// итог гонки: победитель (или неудачная основная попытка) возвращается в ServeHTTP
+func (lb *loadBalancer) hedgeOutcome(ctx context.Context, start time.Time, h *hedger, results []hedgeResult) (backend.BackendIface, *attemptWriter, time.Duration) {
+	chosen := results[0]
+	for _, res := range results {
+		if !res.hedged && !res.lost {
+			chosen = res
+		}
+	}
+	for _, res := range results {
+		if res.aw.done() && !res.lost {
+			chosen = res
+		}
+	}
+
+	for _, res := range results {
+		if res.aw == chosen.aw {
+			continue
+		}
+		br, guarded := res.server.(breaker.BreakerIface)
+		if res.lost || ctx.Err() != nil {
+			// отменённая попытка (или клиент ушёл) не успех и не отказ: только освобождает пробный слот
+			if guarded {
+				br.Release()
+			}
+			continue
+		}
+		// неудачная запасная попытка учитывается как обычная
+		if guarded {
+			br.Report(res.aw.status, res.latency)
+		}
+		lb.failure(res.server, res.aw.status, guarded)
+	}
+
+	if chosen.aw.done() && !chosen.lost {
+		h.observe(chosen.aw.committedAt.Sub(start))
+		if chosen.hedged {
+			metrics.HedgesWon.Inc()
+		}
+	}
+	return chosen.server, chosen.aw, chosen.latency
+}
+
+// сервер для запасной попытки: доступный сервер той же группы, кроме primary;
+// у сервера за разомкнутым предохранителем IsAlive ложно
+func (lb *loadBalancer) hedgeTarget(r *http.Request, primary backend.BackendIface) backend.BackendIface {
+	lb.mu.RLock()
+	split := lb.split
+	lb.mu.RUnlock()
+
+	servers := lb.GetServers()
+	if split != nil {
+		servers = inGroup(servers, split.group(r))
+	}
+	others := slices.DeleteFunc(servers, func(server backend.BackendIface) bool {
+		return server.GetURL() == primary.GetURL() || !server.IsAlive()
+	})
+	if len(others) == 0 {
+		return nil
+	}
+
+	return lb.strategy.Next(serverSlice(others), r)
+}
+
+// пропустит ли предохранитель сервера запрос
+func allow(server backend.BackendIface) bool {
+	br, guarded := server.(breaker.BreakerIface)
+	return !guarded || br.Allow()
+}
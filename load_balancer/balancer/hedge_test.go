@@ -0,0 +1,168 @@
+package balancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"load_balancer/backend"
+	"load_balancer/breaker"
+	configloading "load_balancer/config_loading"
+	"load_balancer/metrics"
+	"load_balancer/strategy"
+)
+
+// стратегия, выбирающая первый доступный сервер: запрос всегда уходит медленному
+type firstAlive struct{}
+
+func (firstAlive) Next(lb strategy.ServerSlice, r *http.Request) backend.BackendIface {
+	for _, server := range lb.GetServers() {
+		if server.IsAlive() {
+			return server
+		}
+	}
+	return nil
+}
+
+// пул из медленного и быстрого серверов; серверы отвечают своим именем
+func hedgePool(t *testing.T, cfg configloading.HedgeConfig) (*loadBalancer, backend.BackendIface) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		io.WriteString(w, "slow") //nolint:errcheck
+	}))
+	t.Cleanup(slow.Close)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "fast") //nolint:errcheck
+	}))
+	t.Cleanup(fast.Close)
+
+	lb := NewBalancer(firstAlive{})
+	slowBack := backend.NewBackend(slow.URL, 1)
+	for _, server := range []backend.BackendIface{slowBack, backend.NewBackend(fast.URL, 1)} {
+		if err := lb.AddBack(server); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lb.SetHedging(cfg)
+	// накопленные ответы быстрее minDelay: задержка 100ms
+	for range cfg.Window {
+		lb.hedge.observe(20 * time.Millisecond)
+	}
+	return lb, slowBack
+}
+
+func hedgeConfig(budget float64) configloading.HedgeConfig {
+	return configloading.HedgeConfig{
+		Prefixes:   []string{"/api/get-tasks"},
+		Percentile: 95,
+		MinDelay:   100 * time.Millisecond,
+		Budget:     budget,
+		Window:     1000,
+	}
+}
+
+func TestHedge(t *testing.T) {
+	lb, slow := hedgePool(t, hedgeConfig(1))
+
+	fired, won := metrics.CounterValue(metrics.HedgesFired), metrics.CounterValue(metrics.HedgesWon)
+
+	for i := range 4 {
+		r := httptest.NewRequest(http.MethodGet, "/api/get-tasks", nil)
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		lb.ServeHTTP(rec, r)
+
+		if rec.Code != http.StatusOK || rec.Body.String() != "fast" {
+			t.Fatalf("request %d: %d %q", i, rec.Code, rec.Body.String())
+		}
+		if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+			t.Fatalf("request %d took %v, the hedge did not answer", i, elapsed)
+		}
+	}
+
+	if got := metrics.CounterValue(metrics.HedgesFired) - fired; got != 4 {
+		t.Fatalf("fired %v hedges, want 4", got)
+	}
+	if got := metrics.CounterValue(metrics.HedgesWon) - won; got != 4 {
+		t.Fatalf("won %v hedges, want 4", got)
+	}
+	// проигравший сервер не считается упавшим
+	if !slow.IsAlive() {
+		t.Fatal("the slow backend must stay alive")
+	}
+}
+
+// проигравшая пробная попытка не замыкает предохранитель медленного сервера
+func TestHedgeBreaker(t *testing.T) {
+	lb, _ := hedgePool(t, hedgeConfig(1))
+	lb.SetBreaker(breaker.Config{
+		ErrorRate:      0.5,
+		MinRequests:    1,
+		Window:         time.Minute,
+		OpenTimeout:    50 * time.Millisecond,
+		HalfOpenProbes: 1,
+	})
+
+	slow := lb.GetServers()[0].(breaker.BreakerIface)
+	slow.Report(http.StatusBadGateway, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	if slow.State() != breaker.HalfOpen {
+		t.Fatalf("state %v, want half-open", slow.State())
+	}
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/get-tasks", nil))
+	if rec.Body.String() != "fast" {
+		t.Fatalf("body %q, want the hedge to answer", rec.Body.String())
+	}
+
+	if slow.State() != breaker.HalfOpen {
+		t.Fatalf("state %v after a lost probe, want half-open", slow.State())
+	}
+	// слот пробного запроса освобождён
+	if !slow.IsAlive() {
+		t.Fatal("the lost probe still holds the half-open slot")
+	}
+}
+
+func TestHedgeSkipped(t *testing.T) {
+	lb, _ := hedgePool(t, hedgeConfig(1))
+	fired := metrics.CounterValue(metrics.HedgesFired)
+
+	// не подходящий путь и не GET не дублируются
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/get-solutions", nil),
+		httptest.NewRequest(http.MethodPost, "/api/get-tasks", nil),
+	} {
+		lb.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if got := metrics.CounterValue(metrics.HedgesFired) - fired; got != 0 {
+		t.Fatalf("fired %v hedges, want none", got)
+	}
+}
+
+func TestHedgeBudget(t *testing.T) {
+	lb, _ := hedgePool(t, hedgeConfig(0.5))
+	fired := metrics.CounterValue(metrics.HedgesFired)
+	exhausted := metrics.CounterValue(metrics.HedgesBudgetExhausted)
+
+	// каждый запрос добавляет полединицы бюджета: дублируется каждый второй
+	for range 6 {
+		lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/get-tasks", nil))
+	}
+
+	if got := metrics.CounterValue(metrics.HedgesFired) - fired; got != 3 {
+		t.Fatalf("fired %v hedges, want 3", got)
+	}
+	if got := metrics.CounterValue(metrics.HedgesBudgetExhausted) - exhausted; got != 3 {
+		t.Fatalf("budget exhausted %v times, want 3", got)
+	}
+}
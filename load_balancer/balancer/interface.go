@@ -6,10 +6,24 @@ import (
 	"time"
 
 	"load_balancer/backend"
+	configloading "load_balancer/config_loading"
 )
 
 type BalancerIface interface {
-	AddBack(server backend.BackendIface)                    //добавить сервер в список доступных
+	AddBack(server backend.BackendIface) error              //добавить сервер в список доступных
+	RemoveBack(url string) error                            //удалить сервер из списка
+	DrainBack(url string, draining bool) error              //перевести сервер в режим draining или вернуть в работу
+	ListBacks() []BackendState                              //состояние всех серверов
+	SyncBacks(servers []backend.BackendIface)               //привести список серверов к заданному
 	ServeHTTP(w http.ResponseWriter, r *http.Request)       //обработка запросов
 	HealthCheck(ctx context.Context, tick <-chan time.Time) //проверка статуса серверов
+	InFlight() int64                                        //число проксируемых запросов без WebSocket
+	CloseWebSockets(ctx context.Context) int                //закрыть WebSocket-соединения клиентов
+	SetSplit(cfg configloading.SplitConfig)                 //задать разделение трафика между группами серверов
+}
+
+type RouterIface interface {
+	BalancerIface
+	Pool(name string) (BalancerIface, bool) //пул серверов по имени, "" - пул по умолчанию
+	SetRoutes(routes []Route) error         //заменить таблицу маршрутизации
 }
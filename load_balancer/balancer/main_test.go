@@ -0,0 +1,15 @@
+package balancer
+
+import (
+	"os"
+	"testing"
+
+	"load_balancer/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
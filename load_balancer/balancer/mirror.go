@@ -0,0 +1,212 @@
+package balancer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	configloading "load_balancer/config_loading"
+	"load_balancer/metrics"
+)
+
+// причины, по которым запрос не скопирован в теневой пул
+const (
+	mirrorSkipBusy = "busy" // достигнут предел одновременных копий
+	mirrorSkipBody = "body" // тело больше maxBodySize
+)
+
+// Mirror - асинхронная копия запросов в теневой пул. Клиент получает ответ основного
+// пула и не ждёт теневой; ответ теневого пула отбрасывается, в метрики попадает
+// разница кодов и времени ответа
+type Mirror struct {
+	cfg    configloading.MirrorConfig
+	shadow http.Handler
+	sem    chan struct{}
+}
+
+// результат обработки запроса одним из пулов
+type mirrorResult struct {
+	status  int
+	latency time.Duration
+}
+
+func NewMirror(cfg configloading.MirrorConfig, shadow http.Handler) *Mirror {
+	return &Mirror{
+		cfg:    cfg,
+		shadow: shadow,
+		sem:    make(chan struct{}, max(cfg.Concurrency, 1)),
+	}
+}
+
+// Middleware - копирование запросов, проходящих к основному пулу
+func (m *Mirror) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.sampled(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, ok := m.copyBody(r)
+		if !ok {
+			metrics.MirrorSkipped.WithLabelValues(mirrorSkipBody).Inc()
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		select {
+		case m.sem <- struct{}{}:
+		default:
+			metrics.MirrorSkipped.WithLabelValues(mirrorSkipBusy).Inc()
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// копия не зависит от соединения клиента: он может уйти раньше теневого ответа
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), m.cfg.Timeout)
+		shadow := r.Clone(ctx)
+		shadow.Header.Set(m.cfg.Header, "1")
+		shadow.ContentLength = int64(len(body))
+		shadow.Body = http.NoBody
+		if body != nil {
+			shadow.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		primary := make(chan mirrorResult, 1)
+		go func() {
+			defer cancel()
+			m.send(shadow, primary)
+		}()
+
+		mw := &mirrorWriter{ResponseWriter: w}
+		start := time.Now()
+		defer func() {
+			primary <- mirrorResult{status: mw.code(), latency: time.Since(start)}
+		}()
+		next.ServeHTTP(mw, r)
+	})
+}
+
+// запрос копируется с вероятностью Percent; Upgrade не копируется - соединение
+// теневого пула некому обслуживать
+func (m *Mirror) sampled(r *http.Request) bool {
+	if m.cfg.Percent <= 0 || r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	if len(m.cfg.Methods) > 0 && !slices.ContainsFunc(m.cfg.Methods, func(method string) bool {
+		return strings.EqualFold(method, r.Method)
+	}) {
+		return false
+	}
+	return m.cfg.Percent >= 100 || rand.Float64()*100 < m.cfg.Percent
+}
+
+// чтение тела для копии; тело больше лимита склеивается с остатком для основного
+// пула и не копируется
+func (m *Mirror) copyBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	if r.ContentLength > m.cfg.MaxBodySize {
+		return nil, false
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, m.cfg.MaxBodySize+1))
+	if err != nil || int64(len(data)) > m.cfg.MaxBodySize {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+		return nil, false
+	}
+
+	r.Body.Close() //nolint:errcheck
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	return data, true
+}
+
+// отправка копии в теневой пул и сравнение с ответом основного
+func (m *Mirror) send(r *http.Request, primary <-chan mirrorResult) {
+	defer func() { <-m.sem }()
+
+	sw := &shadowWriter{header: make(http.Header)}
+	start := time.Now()
+	m.shadow.ServeHTTP(sw, r)
+	shadow := mirrorResult{status: sw.code(), latency: time.Since(start)}
+
+	served := <-primary
+	metrics.MirrorRequests.
+		WithLabelValues(strconv.Itoa(served.status), strconv.Itoa(shadow.status)).
+		Inc()
+	metrics.MirrorLatencyDiff.Observe((shadow.latency - served.latency).Seconds())
+}
+
+// mirrorWriter - запоминает код ответа основного пула
+type mirrorWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (mw *mirrorWriter) WriteHeader(code int) {
+	if mw.status == 0 && code >= 200 {
+		mw.status = code
+	}
+	mw.ResponseWriter.WriteHeader(code)
+}
+
+func (mw *mirrorWriter) Write(b []byte) (int, error) {
+	if mw.status == 0 {
+		mw.status = http.StatusOK
+	}
+	return mw.ResponseWriter.Write(b)
+}
+
+func (mw *mirrorWriter) Flush() {
+	http.NewResponseController(mw.ResponseWriter).Flush() //nolint:errcheck
+}
+
+func (mw *mirrorWriter) Unwrap() http.ResponseWriter {
+	return mw.ResponseWriter
+}
+
+func (mw *mirrorWriter) code() int {
+	if mw.status == 0 {
+		return http.StatusOK
+	}
+	return mw.status
+}
+
+// shadowWriter - отбрасывает ответ теневого пула, запоминая код
+type shadowWriter struct {
+	header http.Header
+	status int
+}
+
+func (sw *shadowWriter) Header() http.Header {
+	return sw.header
+}
+
+func (sw *shadowWriter) WriteHeader(code int) {
+	if sw.status == 0 && code >= 200 {
+		sw.status = code
+	}
+}
+
+func (sw *shadowWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (sw *shadowWriter) code() int {
+	if sw.status == 0 {
+		return http.StatusOK
+	}
+	return sw.status
+}
@@ -0,0 +1,170 @@
+package balancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	configloading "load_balancer/config_loading"
+	"load_balancer/metrics"
+)
+
+// теневой пул, передающий в канал полученные копии
+type shadowRecorder struct {
+	requests chan *http.Request
+	bodies   chan string
+	delay    time.Duration
+	status   int
+}
+
+func (s *shadowRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	time.Sleep(s.delay)
+	w.WriteHeader(s.status)
+	s.requests <- r
+	s.bodies <- string(body)
+}
+
+func newShadowRecorder(delay time.Duration, status int) *shadowRecorder {
+	return &shadowRecorder{
+		requests: make(chan *http.Request, 16),
+		bodies:   make(chan string, 16),
+		delay:    delay,
+		status:   status,
+	}
+}
+
+func mirrorConfig(percent float64) configloading.MirrorConfig {
+	return configloading.MirrorConfig{
+		Pool:        "shadow",
+		Percent:     percent,
+		Header:      "X-Mirrored",
+		MaxBodySize: 1024,
+		Timeout:     time.Second,
+		Concurrency: 4,
+	}
+}
+
+func TestMirror(t *testing.T) {
+	shadow := newShadowRecorder(300*time.Millisecond, http.StatusInternalServerError)
+	primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Mirrored") != "" {
+			t.Error("primary request must not be tagged")
+		}
+		io.WriteString(w, "primary:"+string(body)) //nolint:errcheck
+	})
+	handler := NewMirror(mirrorConfig(100), shadow).Middleware(primary)
+
+	counter := metrics.MirrorRequests.WithLabelValues("200", "500")
+	before := metrics.CounterValue(counter)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/solution", strings.NewReader("payload"))
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(rec, r)
+
+	// клиент не ждёт теневой пул
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("client waited %v for the shadow pool", elapsed)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "primary:payload" {
+		t.Fatalf("client got %d %q", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case req := <-shadow.requests:
+		if req.Header.Get("X-Mirrored") != "1" {
+			t.Fatal("mirrored request is not tagged")
+		}
+		if body := <-shadow.bodies; body != "payload" {
+			t.Fatalf("shadow body %q", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request was not mirrored")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for metrics.CounterValue(counter)-before != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("status difference was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMirrorSkip(t *testing.T) {
+	primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body) //nolint:errcheck
+	})
+
+	tests := []struct {
+		name    string
+		cfg     configloading.MirrorConfig
+		request func() *http.Request
+	}{
+		{"zero percent", mirrorConfig(0), func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/", nil)
+		}},
+		{"method", func() configloading.MirrorConfig {
+			cfg := mirrorConfig(100)
+			cfg.Methods = []string{"GET"}
+			return cfg
+		}(), func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/", nil)
+		}},
+		{"upgrade", mirrorConfig(100), func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+			r.Header.Set("Upgrade", "websocket")
+			return r
+		}},
+		{"large body", mirrorConfig(100), func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 2048)))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shadow := newShadowRecorder(0, http.StatusOK)
+			handler := NewMirror(tt.cfg, shadow).Middleware(primary)
+
+			r := tt.request()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			// большое тело доходит до основного пула целиком
+			if tt.name == "large body" && rec.Body.Len() != 2048 {
+				t.Fatalf("primary got %d bytes", rec.Body.Len())
+			}
+
+			select {
+			case <-shadow.requests:
+				t.Fatal("request must not be mirrored")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestMirrorBusy(t *testing.T) {
+	shadow := newShadowRecorder(200*time.Millisecond, http.StatusOK)
+	cfg := mirrorConfig(100)
+	cfg.Concurrency = 1
+	handler := NewMirror(cfg, shadow).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	busy := metrics.MirrorSkipped.WithLabelValues(mirrorSkipBusy)
+	before := metrics.CounterValue(busy)
+
+	for range 3 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if got := metrics.CounterValue(busy) - before; got != 2 {
+		t.Fatalf("skipped %v requests, want 2", got)
+	}
+	<-shadow.requests
+}
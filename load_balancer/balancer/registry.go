@@ -0,0 +1,188 @@
+package balancer
+
+import (
+	"fmt"
+
+	"load_balancer/backend"
+	"load_balancer/breaker"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/metrics"
+
+	"go.uber.org/zap"
+)
+
+// BackendState - состояние сервера для admin API
+type BackendState struct {
+	Pool        string `json:"pool,omitempty"`
+	URL         string `json:"url"`
+	Group       string `json:"group"`
+	Alive       bool   `json:"alive"`
+	Draining    bool   `json:"draining"`
+	Weight      int    `json:"weight"`
+	ActiveConns int64  `json:"activeConns"`
+}
+
+// AddBack - добавление сервера в список
+func (lb *loadBalancer) AddBack(server backend.BackendIface) error {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if lb.indexOf(server.GetURL()) >= 0 {
+		return fmt.Errorf(messages.ErrBackendExists, server.GetURL())
+	}
+
+	lb.servers = append(lb.servers, lb.guard(server))
+	if lb.manual == nil {
+		lb.manual = make(map[string]bool)
+	}
+	lb.manual[server.GetURL()] = true
+	return nil
+}
+
+// RemoveBack - удаление сервера из списка. Запросы, которые уже обрабатываются
+// сервером, доработают до конца; чтобы не обрывать их, сервер сначала переводят в draining
+func (lb *loadBalancer) RemoveBack(url string) error {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	idx := lb.indexOf(url)
+	if idx < 0 {
+		return fmt.Errorf(messages.ErrBackendNotFound, url)
+	}
+
+	// новый срез, чтобы не портить копии, полученные через GetServers
+	servers := make([]backend.BackendIface, 0, len(lb.servers)-1)
+	servers = append(servers, lb.servers[:idx]...)
+	servers = append(servers, lb.servers[idx+1:]...)
+	lb.servers = servers
+	delete(lb.manual, url)
+
+	metrics.BackendConnections.DeleteLabelValues(url)
+	metrics.BreakerState.DeleteLabelValues(url)
+	return nil
+}
+
+// DrainBack - draining: сервер не получает новых запросов, текущие завершаются
+func (lb *loadBalancer) DrainBack(url string, draining bool) error {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	idx := lb.indexOf(url)
+	if idx < 0 {
+		return fmt.Errorf(messages.ErrBackendNotFound, url)
+	}
+
+	lb.servers[idx].SetDraining(draining)
+	return nil
+}
+
+// ListBacks - состояние всех серверов, включая draining
+func (lb *loadBalancer) ListBacks() []BackendState {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	states := make([]BackendState, 0, len(lb.servers))
+	for _, server := range lb.servers {
+		states = append(states, BackendState{
+			URL:         server.GetURL(),
+			Group:       server.GetGroup(),
+			Alive:       server.IsAlive(),
+			Draining:    server.IsDraining(),
+			Weight:      server.GetWeight(),
+			ActiveConns: server.GetConns(),
+		})
+	}
+	return states
+}
+
+// SyncBacks - привести список серверов к заданному (перезагрузка конфига).
+// Уже известные серверы сохраняют своё состояние и счётчики соединений,
+// у них обновляются только вес, группа и параметры проверки. Серверы, добавленные
+// через AddBack (admin API), остаются, пока их не удалят через RemoveBack
+func (lb *loadBalancer) SyncBacks(servers []backend.BackendIface) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	synced := make([]backend.BackendIface, 0, len(servers))
+	keep := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		url := server.GetURL()
+		if keep[url] {
+			continue
+		}
+		keep[url] = true
+		// сервер из конфига больше не считается добавленным вручную
+		delete(lb.manual, url)
+
+		if idx := lb.indexOf(url); idx >= 0 {
+			lb.servers[idx].SetWeight(server.GetWeight())
+			lb.servers[idx].SetGroup(server.GetGroup())
+			lb.servers[idx].SetHealthCheck(server.GetHealthCheck())
+			server = lb.servers[idx]
+		} else {
+			server = lb.guard(server)
+		}
+		synced = append(synced, server)
+	}
+
+	var kept []string
+	for _, server := range lb.servers {
+		url := server.GetURL()
+		switch {
+		case keep[url]:
+		case lb.manual[url]:
+			synced = append(synced, server)
+			kept = append(kept, url)
+		default:
+			metrics.BackendConnections.DeleteLabelValues(url)
+			metrics.BreakerState.DeleteLabelValues(url)
+		}
+	}
+	if len(kept) > 0 {
+		logger.Log.Info(messages.InfoBackendsKept, zap.Strings(messages.URL, kept))
+	}
+
+	lb.servers = synced
+}
+
+// SetBreaker - включить предохранитель для всех серверов, в том числе добавленных позже
+func (lb *loadBalancer) SetBreaker(cfg breaker.Config) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.breaker = &cfg
+	servers := make([]backend.BackendIface, 0, len(lb.servers))
+	for _, server := range lb.servers {
+		servers = append(servers, lb.guard(server))
+	}
+	lb.servers = servers
+}
+
+// обернуть сервер предохранителем, если он включён; вызывается под lb.mu
+func (lb *loadBalancer) guard(server backend.BackendIface) backend.BackendIface {
+	if lb.breaker == nil {
+		return server
+	}
+	if _, ok := server.(breaker.BreakerIface); ok {
+		return server
+	}
+	return breaker.Wrap(server, *lb.breaker)
+}
+
+// все серверы, включая draining; для проверки здоровья
+func (lb *loadBalancer) allServers() []backend.BackendIface {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	return lb.servers
+}
+
+// поиск сервера по URL, вызывается под lb.mu
+func (lb *loadBalancer) indexOf(url string) int {
+	for i, server := range lb.servers {
+		if server.GetURL() == url {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,145 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"load_balancer/backend"
+	"load_balancer/breaker"
+	"load_balancer/strategy"
+)
+
+// добавление, удаление и draining серверов во время проверки здоровья и запросов;
+// гонки под lb.mu ловит go test -race
+func TestRegistryRace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewBalancer(strat)
+	lb.SetBreaker(breaker.Config{ErrorRate: 0.5, MinRequests: 10, Window: time.Second, OpenTimeout: time.Second, HalfOpenProbes: 1})
+	if err := lb.AddBack(backend.NewBackend(srv.URL, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tick := make(chan time.Time)
+	go lb.HealthCheck(ctx, tick)
+
+	// адреса разные, а запросы уходят на один и тот же сервер
+	url := func(worker, i int) string {
+		return srv.URL + "/w" + strconv.Itoa(worker) + "-" + strconv.Itoa(i)
+	}
+
+	var wg sync.WaitGroup
+	for worker := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				if err := lb.AddBack(backend.NewBackend(url(worker, i), 1)); err != nil {
+					t.Error(err)
+					return
+				}
+				lb.DrainBack(url(worker, i), true) //nolint:errcheck
+				lb.ListBacks()
+				if i%2 == 0 {
+					if err := lb.RemoveBack(url(worker, i)); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			tick <- time.Now()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+	}()
+	wg.Wait()
+
+	// исходный сервер и нечётные серверы каждого потока
+	if got := len(lb.ListBacks()); got != 1+4*25 {
+		t.Fatalf("%d backends, want %d", got, 1+4*25)
+	}
+	if got := len(lb.GetServers()); got != 1 {
+		t.Fatalf("%d backends take requests, want only the one not draining", got)
+	}
+}
+
+func TestSyncBacks(t *testing.T) {
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewBalancer(strat)
+
+	configured := func(url string, weight int) backend.BackendIface {
+		server := backend.NewBackend(url, weight)
+		server.SetGroup(backend.DefaultGroup)
+		return server
+	}
+	lb.SyncBacks([]backend.BackendIface{
+		configured("http://10.0.0.1:8080", 1),
+		configured("http://10.0.0.2:8080", 1),
+	})
+	if err := lb.AddBack(backend.NewBackend("http://10.0.0.9:8080", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// состояние известного сервера переживает перезагрузку
+	kept := lb.GetServers()[0]
+	kept.SetStatus(false)
+	kept.AddConn()
+	defer kept.RemoveConn()
+
+	lb.SyncBacks([]backend.BackendIface{
+		configured("http://10.0.0.1:8080", 5),
+		configured("http://10.0.0.3:8080", 1),
+	})
+
+	states := make(map[string]BackendState)
+	for _, state := range lb.ListBacks() {
+		states[state.URL] = state
+	}
+	if len(states) != 3 {
+		t.Fatalf("backends %v, want 10.0.0.1, 10.0.0.3 and the one added through the admin API", states)
+	}
+	if _, ok := states["http://10.0.0.2:8080"]; ok {
+		t.Fatal("a backend removed from config must be removed")
+	}
+	if _, ok := states["http://10.0.0.9:8080"]; !ok {
+		t.Fatal("a backend added through the admin API must be kept")
+	}
+	if state := states["http://10.0.0.1:8080"]; state.Weight != 5 || state.Alive || state.ActiveConns != 1 {
+		t.Fatalf("kept backend %+v, want weight 5 with its state and connections", state)
+	}
+
+	// удалённый через admin API сервер не возвращается
+	if err := lb.RemoveBack("http://10.0.0.9:8080"); err != nil {
+		t.Fatal(err)
+	}
+	lb.SyncBacks([]backend.BackendIface{configured("http://10.0.0.1:8080", 5)})
+	if got := len(lb.ListBacks()); got != 1 {
+		t.Fatalf("%d backends, want 1", got)
+	}
+}
@@ -0,0 +1,240 @@
+package balancer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"load_balancer/backend"
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/messages"
+)
+
+// Route - правило таблицы маршрутизации: запросы, подходящие под все заданные условия,
+// уходят в пул Pool
+type Route struct {
+	Pool    string
+	Prefix  string   // префикс пути, пусто - любой путь
+	Host    string   // хост без порта, пусто - любой
+	Methods []string // методы, пусто - любой
+}
+
+func (rt *Route) matches(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, rt.Prefix) {
+		return false
+	}
+	if rt.Host != "" && !strings.EqualFold(rt.Host, hostname(r.Host)) {
+		return false
+	}
+	return len(rt.Methods) == 0 || slices.ContainsFunc(rt.Methods, func(m string) bool {
+		return strings.EqualFold(m, r.Method)
+	})
+}
+
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// Router - пулы серверов и таблица маршрутизации между ними. У каждого пула свои
+// стратегия, проверка и повторы; запросы без подходящего правила идут в пул по умолчанию.
+// Операции admin API без указания пула относятся к пулу по умолчанию
+type Router struct {
+	mu     sync.RWMutex
+	pools  map[string]BalancerIface
+	names  []string // порядок пулов для ListBacks
+	routes []Route  // от более конкретных к менее конкретным
+}
+
+var _ RouterIface = &Router{}
+
+func NewRouter(def BalancerIface) *Router {
+	return &Router{
+		pools: map[string]BalancerIface{configloading.DefaultPool: def},
+		names: []string{configloading.DefaultPool},
+	}
+}
+
+// AddPool - добавить именованный пул; пулы задаются до запуска HealthCheck
+func (rt *Router) AddPool(name string, pool BalancerIface) error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if _, ok := rt.pools[name]; ok {
+		return fmt.Errorf(messages.ErrPoolExists, name)
+	}
+	rt.pools[name] = pool
+	rt.names = append(rt.names, name)
+	return nil
+}
+
+// SetRoutes - заменить таблицу маршрутизации. Правила упорядочиваются как политики
+// лимитера: сначала длинный префикс, при равных - с хостом, затем с методами
+func (rt *Router) SetRoutes(routes []Route) error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	for _, route := range routes {
+		if _, ok := rt.pools[route.Pool]; !ok {
+			return fmt.Errorf(messages.ErrUnknownPool, route.Pool)
+		}
+	}
+
+	sorted := slices.Clone(routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if len(a.Prefix) != len(b.Prefix) {
+			return len(a.Prefix) > len(b.Prefix)
+		}
+		if (a.Host != "") != (b.Host != "") {
+			return a.Host != ""
+		}
+		return len(a.Methods) > 0 && len(b.Methods) == 0
+	})
+	rt.routes = sorted
+	return nil
+}
+
+// Pool - пул по имени, "" - пул по умолчанию
+func (rt *Router) Pool(name string) (BalancerIface, bool) {
+	if name == "" {
+		name = configloading.DefaultPool
+	}
+
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	pool, ok := rt.pools[name]
+	return pool, ok
+}
+
+// пул для запроса по таблице маршрутизации
+func (rt *Router) route(r *http.Request) BalancerIface {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	for i := range rt.routes {
+		if rt.routes[i].matches(r) {
+			return rt.pools[rt.routes[i].Pool]
+		}
+	}
+	return rt.pools[configloading.DefaultPool]
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.route(r).ServeHTTP(w, r)
+}
+
+// пулы в порядке добавления
+func (rt *Router) allPools() []BalancerIface {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	pools := make([]BalancerIface, 0, len(rt.names))
+	for _, name := range rt.names {
+		pools = append(pools, rt.pools[name])
+	}
+	return pools
+}
+
+func (rt *Router) defaultPool() BalancerIface {
+	pool, _ := rt.Pool(configloading.DefaultPool)
+	return pool
+}
+
+func (rt *Router) AddBack(server backend.BackendIface) error {
+	return rt.defaultPool().AddBack(server)
+}
+
+func (rt *Router) RemoveBack(url string) error {
+	return rt.defaultPool().RemoveBack(url)
+}
+
+func (rt *Router) DrainBack(url string, draining bool) error {
+	return rt.defaultPool().DrainBack(url, draining)
+}
+
+func (rt *Router) SyncBacks(servers []backend.BackendIface) {
+	rt.defaultPool().SyncBacks(servers)
+}
+
+func (rt *Router) SetSplit(cfg configloading.SplitConfig) {
+	rt.defaultPool().SetSplit(cfg)
+}
+
+// ListBacks - серверы всех пулов с именем пула
+func (rt *Router) ListBacks() []BackendState {
+	rt.mu.RLock()
+	names := slices.Clone(rt.names)
+	rt.mu.RUnlock()
+
+	var states []BackendState
+	for _, name := range names {
+		pool, _ := rt.Pool(name)
+		for _, state := range pool.ListBacks() {
+			state.Pool = name
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+// HealthCheck - проверка серверов всех пулов; каждый пул проверяется своими параметрами
+func (rt *Router) HealthCheck(ctx context.Context, tick <-chan time.Time) {
+	pools := rt.allPools()
+	ticks := make([]chan time.Time, len(pools))
+	for i, pool := range pools {
+		ticks[i] = make(chan time.Time, 1)
+		go pool.HealthCheck(ctx, ticks[i])
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-tick:
+			// пул, не успевший закончить прошлый обход, этот тик пропускает
+			for _, c := range ticks {
+				select {
+				case c <- t:
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (rt *Router) InFlight() int64 {
+	var n int64
+	for _, pool := range rt.allPools() {
+		n += pool.InFlight()
+	}
+	return n
+}
+
+func (rt *Router) CloseWebSockets(ctx context.Context) int {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for _, pool := range rt.allPools() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := pool.CloseWebSockets(ctx)
+			mu.Lock()
+			total += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return total
+}
@@ -0,0 +1,127 @@
+package balancer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"load_balancer/backend"
+	configloading "load_balancer/config_loading"
+	"load_balancer/strategy"
+)
+
+// пул из одного сервера, отвечающего своим именем
+func namedPool(t *testing.T, name string) *loadBalancer {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, name) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewBalancer(strat)
+	if err := lb.AddBack(backend.NewBackend(srv.URL, 1)); err != nil {
+		t.Fatal(err)
+	}
+	return lb
+}
+
+func TestRouter(t *testing.T) {
+	rt := NewRouter(namedPool(t, configloading.DefaultPool))
+	for _, name := range []string{"chat", "static", "api", "admin"} {
+		if err := rt.AddPool(name, namedPool(t, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rt.AddPool("chat", namedPool(t, "chat")); err == nil {
+		t.Fatal("expected an error for a duplicate pool")
+	}
+
+	err := rt.SetRoutes([]Route{
+		{Pool: "api", Prefix: "/api/"},
+		{Pool: "chat", Prefix: "/ws"},
+		{Pool: "static", Prefix: "/assets/", Methods: []string{"GET", "HEAD"}},
+		{Pool: "admin", Prefix: "/api/", Host: "admin.example.com"},
+		{Pool: "chat", Prefix: "/api/create-chat-room"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, host, path string
+		pool               string
+	}{
+		{http.MethodGet, "example.com", "/ws?room=1", "chat"},
+		{http.MethodGet, "example.com", "/assets/app.js", "static"},
+		{http.MethodPost, "example.com", "/assets/app.js", configloading.DefaultPool},
+		{http.MethodGet, "example.com", "/api/get-tasks", "api"},
+		{http.MethodPost, "example.com", "/api/create-chat-room", "chat"},
+		{http.MethodGet, "admin.example.com:8080", "/api/get-tasks", "admin"},
+		{http.MethodGet, "example.com", "/login", configloading.DefaultPool},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.host+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			rt.ServeHTTP(rec, req)
+
+			if rec.Body.String() != tt.pool {
+				t.Fatalf("served by %q, want %q", rec.Body.String(), tt.pool)
+			}
+		})
+	}
+}
+
+func TestRouterUnknownPool(t *testing.T) {
+	rt := NewRouter(namedPool(t, configloading.DefaultPool))
+	if err := rt.SetRoutes([]Route{{Pool: configloading.DefaultPool, Prefix: "/api/"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rt.SetRoutes([]Route{{Pool: "chat", Prefix: "/ws"}}); err == nil {
+		t.Fatal("expected an error for a route to an unknown pool")
+	}
+	// прежняя таблица остаётся в силе
+	if len(rt.routes) != 1 || rt.routes[0].Pool != configloading.DefaultPool {
+		t.Fatalf("routes = %+v", rt.routes)
+	}
+}
+
+func TestRouterBackends(t *testing.T) {
+	def, chat := namedPool(t, configloading.DefaultPool), namedPool(t, "chat")
+	rt := NewRouter(def)
+	if err := rt.AddPool("chat", chat); err != nil {
+		t.Fatal(err)
+	}
+
+	// операции без пула относятся к пулу по умолчанию
+	if err := rt.AddBack(backend.NewBackend("http://127.0.0.1:1", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(def.ListBacks()) != 2 || len(chat.ListBacks()) != 1 {
+		t.Fatalf("default has %d servers, chat %d", len(def.ListBacks()), len(chat.ListBacks()))
+	}
+
+	states := rt.ListBacks()
+	if len(states) != 3 || states[0].Pool != configloading.DefaultPool || states[2].Pool != "chat" {
+		t.Fatalf("states = %+v", states)
+	}
+
+	if pool, ok := rt.Pool(""); !ok || pool != def {
+		t.Fatal("empty name must select the default pool")
+	}
+	if _, ok := rt.Pool("static"); ok {
+		t.Fatal("unknown pool found")
+	}
+
+	if n := rt.CloseWebSockets(context.Background()); n != 0 {
+		t.Fatalf("closed %d websockets", n)
+	}
+}
@@ -0,0 +1,114 @@
+package balancer
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"load_balancer/backend"
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/util"
+)
+
+// значения заголовка и cookie принудительного выбора группы, кроме имени группы
+const (
+	SplitAlways = "always" // группа Canary
+	SplitNever  = "never"  // группа Stable
+)
+
+// splitter - выбор группы для запроса. Клиент попадает в группу по хешу ключа, а не
+// случайно: иначе соседние запросы одной страницы уходили бы в разные версии api
+type splitter struct {
+	cfg    configloading.SplitConfig
+	groups []string // группы с положительным весом по порядку
+	total  int
+}
+
+// SetSplit - включить разделение трафика между группами; без весов - выключить
+func (lb *loadBalancer) SetSplit(cfg configloading.SplitConfig) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.split = newSplitter(cfg)
+}
+
+func newSplitter(cfg configloading.SplitConfig) *splitter {
+	s := &splitter{cfg: cfg}
+	for group, weight := range cfg.Weights {
+		if weight > 0 {
+			s.groups = append(s.groups, group)
+			s.total += weight
+		}
+	}
+	if s.total == 0 {
+		return nil
+	}
+	sort.Strings(s.groups)
+	return s
+}
+
+// группа для запроса: принудительная из заголовка или cookie, иначе по весам
+func (s *splitter) group(r *http.Request) string {
+	if forced := s.forced(r); forced != "" {
+		return forced
+	}
+
+	key := util.GetClientIP(r)
+	if c, err := r.Cookie(s.cfg.KeyCookie); err == nil && c.Value != "" {
+		key = c.Value
+	}
+
+	n := int(hash64(key) % uint64(s.total))
+	for _, group := range s.groups {
+		n -= s.cfg.Weights[group]
+		if n < 0 {
+			return group
+		}
+	}
+	return s.groups[len(s.groups)-1]
+}
+
+func (s *splitter) forced(r *http.Request) string {
+	value := ""
+	if s.cfg.Header != "" {
+		value = r.Header.Get(s.cfg.Header)
+	}
+	if value == "" && s.cfg.Cookie != "" {
+		if c, err := r.Cookie(s.cfg.Cookie); err == nil {
+			value = c.Value
+		}
+	}
+
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "":
+		return ""
+	case SplitAlways:
+		return s.cfg.Canary
+	case SplitNever:
+		return s.cfg.Stable
+	default:
+		return value
+	}
+}
+
+// серверы группы; если в группе нет доступных, запрос обслуживает весь пул
+func inGroup(servers []backend.BackendIface, group string) []backend.BackendIface {
+	selected := make([]backend.BackendIface, 0, len(servers))
+	alive := false
+	for _, server := range servers {
+		if server.GetGroup() == group {
+			selected = append(selected, server)
+			alive = alive || server.IsAlive()
+		}
+	}
+	if !alive {
+		return servers
+	}
+	return selected
+}
+
+// serverSlice - серверы группы для стратегии выбора
+type serverSlice []backend.BackendIface
+
+func (s serverSlice) GetServers() []backend.BackendIface {
+	return s
+}
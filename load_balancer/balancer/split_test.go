@@ -0,0 +1,146 @@
+package balancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"load_balancer/backend"
+	configloading "load_balancer/config_loading"
+	"load_balancer/metrics"
+	"load_balancer/strategy"
+)
+
+// пул с сервером в каждой группе; сервер отвечает именем своей группы
+func splitPool(t *testing.T, groups ...string) (*loadBalancer, map[string]backend.BackendIface) {
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewBalancer(strat)
+
+	servers := make(map[string]backend.BackendIface, len(groups))
+	for _, group := range groups {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, group) //nolint:errcheck
+		}))
+		t.Cleanup(srv.Close)
+
+		server := backend.NewBackend(srv.URL, 1)
+		server.SetGroup(group)
+		if err := lb.AddBack(server); err != nil {
+			t.Fatal(err)
+		}
+		servers[group] = server
+	}
+	return lb, servers
+}
+
+func splitConfig(weights map[string]int) configloading.SplitConfig {
+	return configloading.SplitConfig{
+		Weights:   weights,
+		Canary:    "canary",
+		Stable:    backend.DefaultGroup,
+		Header:    "X-Canary",
+		Cookie:    "canary",
+		KeyCookie: "authToken",
+	}
+}
+
+func serveGroup(t *testing.T, lb *loadBalancer, r *http.Request) string {
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func TestSplitWeights(t *testing.T) {
+	lb, _ := splitPool(t, backend.DefaultGroup, "canary")
+	lb.SetSplit(splitConfig(map[string]int{backend.DefaultGroup: 80, "canary": 20}))
+
+	counts := make(map[string]int)
+	for i := range 2000 {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "authToken", Value: "token-" + strconv.Itoa(i)})
+		group := serveGroup(t, lb, r)
+		counts[group]++
+
+		// клиент остаётся в своей группе
+		if again := serveGroup(t, lb, r); again != group {
+			t.Fatalf("client %d moved from %s to %s", i, group, again)
+		}
+	}
+
+	if share := float64(counts["canary"]) / 2000; share < 0.15 || share > 0.25 {
+		t.Fatalf("canary share %.3f, want about 0.2", share)
+	}
+}
+
+func TestSplitOverride(t *testing.T) {
+	lb, _ := splitPool(t, backend.DefaultGroup, "canary", "beta")
+	lb.SetSplit(splitConfig(map[string]int{backend.DefaultGroup: 100}))
+
+	tests := []struct {
+		header, cookie string
+		group          string
+	}{
+		{"", "", backend.DefaultGroup},
+		{"always", "", "canary"},
+		{"Always", "", "canary"},
+		{"never", "always", backend.DefaultGroup},
+		{"", "always", "canary"},
+		{"beta", "", "beta"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("X-Canary", tt.header)
+		}
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "canary", Value: tt.cookie})
+		}
+		if got := serveGroup(t, lb, r); got != tt.group {
+			t.Errorf("header %q cookie %q: group %s, want %s", tt.header, tt.cookie, got, tt.group)
+		}
+	}
+}
+
+func TestSplitFallback(t *testing.T) {
+	lb, servers := splitPool(t, backend.DefaultGroup, "canary")
+	lb.SetSplit(splitConfig(map[string]int{"canary": 100}))
+
+	servers["canary"].SetStatus(false)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := serveGroup(t, lb, r); got != backend.DefaultGroup {
+		t.Fatalf("group %s, want %s while canary is down", got, backend.DefaultGroup)
+	}
+
+	// без весов разделение выключено
+	lb.SetSplit(splitConfig(nil))
+	if lb.split != nil {
+		t.Fatal("split must be disabled without weights")
+	}
+}
+
+func TestSplitMetrics(t *testing.T) {
+	lb, servers := splitPool(t, backend.DefaultGroup, "canary")
+	lb.SetSplit(splitConfig(map[string]int{backend.DefaultGroup: 100}))
+
+	counter := metrics.ProxiedRequestCount.WithLabelValues(servers["canary"].GetURL(), "canary")
+	before := metrics.CounterValue(counter)
+
+	for range 3 {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Canary", "always")
+		serveGroup(t, lb, r)
+	}
+
+	if got := metrics.CounterValue(counter) - before; got != 3 {
+		t.Fatalf("canary requests %v, want 3", got)
+	}
+}
@@ -0,0 +1,232 @@
+package balancer
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// код закрытия WebSocket 1001 Going Away (RFC 6455, 7.4.1) - сервер уходит
+const closeGoingAway = 1001
+
+// wsConns - клиентские соединения, перехваченные для Upgrade (чат /ws)
+type wsConns struct {
+	mu    sync.Mutex
+	conns map[*wsConn]struct{}
+}
+
+func (t *wsConns) add(c *wsConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conns == nil {
+		t.conns = make(map[*wsConn]struct{})
+	}
+	t.conns[c] = struct{}{}
+}
+
+func (t *wsConns) remove(c *wsConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, c)
+}
+
+func (t *wsConns) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.conns)
+}
+
+func (t *wsConns) list() []*wsConn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	conns := make([]*wsConn, 0, len(t.conns))
+	for c := range t.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// wsConn - соединение клиента после Upgrade. Прокси пишет в него кадры сервера кусками
+// произвольной длины, поэтому границы кадров отслеживаются: кадр закрытия вставляется
+// только между кадрами, иначе клиент получит испорченный поток
+type wsConn struct {
+	net.Conn
+	owner *wsConns
+
+	mu      sync.Mutex
+	frame   frameTracker
+	closing bool // после границы кадра отправить кадр закрытия
+	closed  bool // кадр закрытия отправлен, дальнейшие кадры сервера отбрасываются
+	gone    chan struct{}
+	once    sync.Once
+}
+
+func newWSConn(conn net.Conn, owner *wsConns) *wsConn {
+	c := &wsConn{Conn: conn, owner: owner, gone: make(chan struct{})}
+	owner.add(c)
+	return c
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return len(p), nil
+	}
+	if !c.closing {
+		n, err := c.Conn.Write(p)
+		c.frame.feed(p[:n])
+		return n, err
+	}
+
+	// дописываем текущий кадр и сразу за ним отправляем закрытие
+	k := c.frame.untilBoundary(p)
+	n, err := c.Conn.Write(p[:k])
+	c.frame.feed(p[:n])
+	if err != nil {
+		return n, err
+	}
+	if c.frame.atBoundary() {
+		c.sendClose()
+	}
+	return len(p), nil
+}
+
+// ReadFrom - io.Copy прокси иначе взял бы ReadFrom исходного соединения (splice)
+// и кадры прошли бы мимо Write
+func (c *wsConn) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{c}, r)
+}
+
+func (c *wsConn) Close() error {
+	c.owner.remove(c)
+	c.once.Do(func() { close(c.gone) })
+	return c.Conn.Close()
+}
+
+// goAway - отправить клиенту кадр закрытия 1001 на ближайшей границе кадра и дать
+// клиенту ответить; соединение закрывает прокси, когда обмен завершён, или мы по ctx
+func (c *wsConn) goAway(ctx context.Context) {
+	c.mu.Lock()
+	c.closing = true
+	if c.frame.atBoundary() {
+		c.sendClose()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-c.gone:
+	case <-ctx.Done():
+		c.Close() //nolint:errcheck
+	}
+}
+
+// вызывается под c.mu
+func (c *wsConn) sendClose() {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, closeGoingAway)
+	// кадры сервера клиенту не маскируются: FIN + opcode close, длина полезной нагрузки
+	c.Conn.Write(append([]byte{0x88, byte(len(payload))}, payload...)) //nolint:errcheck
+	c.closed = true
+}
+
+// frameTracker - разбор заголовков кадров WebSocket (RFC 6455, 5.2) в потоке байтов
+type frameTracker struct {
+	header    []byte // накопленный неполный заголовок
+	remaining uint64 // байт полезной нагрузки текущего кадра
+}
+
+func (f *frameTracker) atBoundary() bool {
+	return len(f.header) == 0 && f.remaining == 0
+}
+
+// длина заголовка по первым двум байтам, 0 - их ещё нет
+func headerLen(h []byte) int {
+	if len(h) < 2 {
+		return 0
+	}
+	n := 2
+	switch h[1] & 0x7f {
+	case 126:
+		n += 2
+	case 127:
+		n += 8
+	}
+	if h[1]&0x80 != 0 {
+		n += 4 // ключ маски
+	}
+	return n
+}
+
+func payloadLen(h []byte) uint64 {
+	switch l := h[1] & 0x7f; l {
+	case 126:
+		return uint64(binary.BigEndian.Uint16(h[2:4]))
+	case 127:
+		return binary.BigEndian.Uint64(h[2:10])
+	default:
+		return uint64(l)
+	}
+}
+
+func (f *frameTracker) feed(p []byte) {
+	for len(p) > 0 {
+		if f.remaining > 0 {
+			k := min(uint64(len(p)), f.remaining)
+			f.remaining -= k
+			p = p[k:]
+			continue
+		}
+
+		f.header = append(f.header, p[0])
+		p = p[1:]
+		if n := headerLen(f.header); n > 0 && len(f.header) == n {
+			f.remaining = payloadLen(f.header)
+			f.header = f.header[:0]
+		}
+	}
+}
+
+// сколько байт p нужно записать, чтобы дойти до границы кадра (len(p), если её в p нет)
+func (f *frameTracker) untilBoundary(p []byte) int {
+	probe := frameTracker{header: append([]byte(nil), f.header...), remaining: f.remaining}
+	if probe.atBoundary() {
+		return 0
+	}
+	for i := range p {
+		probe.feed(p[i : i+1])
+		if probe.atBoundary() {
+			return i + 1
+		}
+	}
+	return len(p)
+}
+
+// InFlight - проксируемые сейчас запросы без WebSocket-соединений
+func (lb *loadBalancer) InFlight() int64 {
+	var n int64
+	for _, server := range lb.allServers() {
+		n += server.GetConns()
+	}
+	return n - int64(lb.websockets.count())
+}
+
+// CloseWebSockets - закрыть все WebSocket-соединения клиентов кадром 1001,
+// дождавшись ответа клиентов не дольше ctx; возвращает число закрытых соединений
+func (lb *loadBalancer) CloseWebSockets(ctx context.Context) int {
+	conns := lb.websockets.list()
+
+	var wg sync.WaitGroup
+	for _, c := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.goAway(ctx)
+		}()
+	}
+	wg.Wait()
+	return len(conns)
+}
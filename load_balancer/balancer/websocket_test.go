@@ -0,0 +1,164 @@
+package balancer
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"load_balancer/backend"
+	"load_balancer/strategy"
+)
+
+// кадр сервера без маски
+func frame(opcode byte, payload []byte) []byte {
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) <= 0xffff:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(payload)))
+	}
+	return append(header, payload...)
+}
+
+func TestFrameTracker(t *testing.T) {
+	stream := append(frame(0x1, []byte("hi")), frame(0x2, make([]byte, 300))...)
+	stream = append(stream, frame(0x2, make([]byte, 70000))...)
+	boundaries := map[int]bool{4: true, 4 + 304: true, len(stream): true}
+
+	// поток приходит по одному байту: граница только после последнего байта каждого кадра
+	var f frameTracker
+	for i := range stream {
+		f.feed(stream[i : i+1])
+		if f.atBoundary() != boundaries[i+1] {
+			t.Fatalf("offset %d: expected boundary=%v", i+1, boundaries[i+1])
+		}
+	}
+
+	f = frameTracker{}
+	f.feed(stream[:10])
+	if got := f.untilBoundary(stream[10:]); got != 4+304-10 {
+		t.Fatalf("expected %d bytes to the boundary, got %d", 4+304-10, got)
+	}
+}
+
+// сервер WebSocket без библиотек: рукопожатие и поток кадров, пока соединение живо
+func wsBackend(t *testing.T, frames chan []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n") //nolint:errcheck
+		brw.Flush() //nolint:errcheck
+
+		go io.Copy(io.Discard, conn) //nolint:errcheck
+		for f := range frames {
+			if _, err := conn.Write(f); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestCloseWebSocketsSendsGoingAway(t *testing.T) {
+	frames := make(chan []byte)
+	back := wsBackend(t, frames)
+	defer back.Close()
+	defer close(frames)
+
+	strat, err := strategy.New("round_robin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewBalancer(strat)
+	if err := lb.AddBack(backend.NewBackend(back.URL, 1)); err != nil {
+		t.Fatal(err)
+	}
+	front := httptest.NewServer(lb)
+	defer front.Close()
+
+	conn, err := net.Dial("tcp", front.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: lb\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" + //nolint:errcheck
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %v %v", resp, err)
+	}
+
+	// половина кадра уже у клиента: закрытие должно дождаться его конца
+	big := frame(0x2, make([]byte, 1000))
+	frames <- big[:500]
+	head := make([]byte, 500)
+	if _, err := io.ReadFull(reader, head); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return lb.websockets.count() == 1 })
+
+	if n := lb.InFlight(); n != 0 {
+		t.Fatalf("expected websocket not to count as in-flight request, got %d", n)
+	}
+
+	done := make(chan int)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	go func() { done <- lb.CloseWebSockets(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	frames <- append(big[500:], frame(0x1, []byte("after close"))...)
+
+	got := make([]byte, len(big)+4)
+	copy(got, head)
+	if _, err := io.ReadFull(reader, got[500:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(got[:len(big)]) != string(big) {
+		t.Fatal("expected the interrupted frame to be delivered intact")
+	}
+	if got[len(big)] != 0x88 || got[len(big)+1] != 2 || binary.BigEndian.Uint16(got[len(big)+2:]) != closeGoingAway {
+		t.Fatalf("expected close frame 1001, got % x", got[len(big):])
+	}
+
+	// кадры сервера после закрытия клиенту не отправляются, соединение закрывается по ctx
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second)) //nolint:errcheck
+	if n, err := reader.Read(make([]byte, 64)); err == nil {
+		t.Fatalf("expected connection to be closed without more data, read %d bytes", n)
+	}
+	if n := <-done; n != 1 {
+		t.Fatalf("expected 1 closed websocket, got %d", n)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
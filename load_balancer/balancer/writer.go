@@ -0,0 +1,153 @@
+package balancer
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// attemptWriter - ResponseWriter одной попытки проксирования.
+// Ответ сервера сразу стримится клиенту, кроме ответов с кодом ≥ 500:
+// они отбрасываются, чтобы запрос можно было повторить на другом сервере.
+// Повтор возможен, только пока клиенту ничего не отправлено.
+type attemptWriter struct {
+	w         http.ResponseWriter
+	header    http.Header
+	status    int
+	committed bool                // заголовки ответа уже отправлены клиенту
+	failed    bool                // сервер ответил ошибкой, тело отбрасывается
+	hijacked  bool                // соединение перехвачено (Upgrade, например /ws)
+	onCommit  func(h http.Header) // вызывается перед отправкой заголовков клиенту
+	ws        *wsConns            // учёт соединения после Upgrade до WebSocket, nil - не WebSocket
+
+	committedAt time.Time // момент отправки заголовков клиенту
+}
+
+var (
+	_ http.ResponseWriter = &attemptWriter{}
+	_ http.Flusher        = &attemptWriter{}
+	_ http.Hijacker       = &attemptWriter{}
+)
+
+func newAttemptWriter(w http.ResponseWriter, onCommit func(h http.Header)) *attemptWriter {
+	return &attemptWriter{
+		w:        w,
+		header:   make(http.Header),
+		onCommit: onCommit,
+	}
+}
+
+func (aw *attemptWriter) Header() http.Header {
+	return aw.header
+}
+
+func (aw *attemptWriter) WriteHeader(code int) {
+	if aw.status != 0 {
+		return
+	}
+
+	// промежуточные ответы (103 Early Hints и т.п.) передаём как есть
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		copyHeader(aw.w.Header(), aw.header)
+		aw.w.WriteHeader(code)
+		return
+	}
+
+	aw.status = code
+	if code >= http.StatusInternalServerError {
+		aw.failed = true
+		return
+	}
+
+	aw.commit()
+	aw.w.WriteHeader(code)
+}
+
+func (aw *attemptWriter) Write(b []byte) (int, error) {
+	if aw.status == 0 {
+		aw.WriteHeader(http.StatusOK)
+	}
+	if aw.failed {
+		return len(b), nil
+	}
+	return aw.w.Write(b)
+}
+
+// Flush - пробрасываем сброс буфера клиенту для chunked и event-stream ответов
+func (aw *attemptWriter) Flush() {
+	if !aw.committed || aw.failed {
+		return
+	}
+	http.NewResponseController(aw.w).Flush() //nolint:errcheck
+}
+
+// Hijack - перехват соединения для Upgrade, после него повтор невозможен
+func (aw *attemptWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(aw.w).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aw.status = http.StatusSwitchingProtocols
+	aw.hijacked = true
+	aw.commit()
+	if aw.ws != nil {
+		conn = newWSConn(conn, aw.ws)
+	}
+	return conn, brw, nil
+}
+
+func (aw *attemptWriter) Unwrap() http.ResponseWriter {
+	return aw.w
+}
+
+// trailers - передать клиенту трейлеры, которые сервер задал после заголовков:
+// объявленные в Trailer и с префиксом http.TrailerPrefix
+func (aw *attemptWriter) trailers() {
+	if !aw.committed || aw.failed || aw.hijacked {
+		return
+	}
+
+	dst := aw.w.Header()
+	for _, declared := range aw.header.Values("Trailer") {
+		for _, k := range strings.Split(declared, ",") {
+			k = http.CanonicalHeaderKey(strings.TrimSpace(k))
+			if vs, ok := aw.header[k]; ok {
+				dst[k] = append([]string(nil), vs...)
+			}
+		}
+	}
+	for k, vs := range aw.header {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			dst[k] = append([]string(nil), vs...)
+		}
+	}
+}
+
+// done - клиент уже получил ответ, повторять попытку нельзя
+func (aw *attemptWriter) done() bool {
+	return aw.committed || aw.hijacked
+}
+
+func (aw *attemptWriter) commit() {
+	if aw.committed {
+		return
+	}
+	aw.committed = true
+	aw.committedAt = time.Now()
+
+	if aw.onCommit != nil {
+		aw.onCommit(aw.header)
+	}
+	if !aw.hijacked {
+		copyHeader(aw.w.Header(), aw.header)
+	}
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, vs := range src {
+		dst[k] = append([]string(nil), vs...)
+	}
+}
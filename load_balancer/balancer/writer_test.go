@@ -0,0 +1,146 @@
+package balancer
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"load_balancer/backend"
+	"load_balancer/strategy"
+)
+
+func TestAttemptWriterDiscard(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	// ответ ≥ 500 не доходит до клиента, попытку можно повторить
+	aw := newAttemptWriter(rec, nil)
+	aw.Header().Set("X-Attempt", "1")
+	aw.WriteHeader(http.StatusBadGateway)
+	aw.Write([]byte("bad gateway")) //nolint:errcheck
+	aw.Flush()
+	if aw.done() {
+		t.Fatal("a failed attempt must be retryable")
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("X-Attempt") != "" || rec.Flushed {
+		t.Fatal("a failed attempt leaked to the client")
+	}
+
+	aw = newAttemptWriter(rec, func(h http.Header) { h.Set("X-Issued", "yes") })
+	aw.Header().Set("X-Attempt", "2")
+	aw.Write([]byte("ok")) //nolint:errcheck
+	if !aw.done() {
+		t.Fatal("a committed attempt must not be retried")
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Fatalf("client got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Attempt") != "2" || rec.Header().Get("X-Issued") != "yes" {
+		t.Fatalf("client got headers %v", rec.Header())
+	}
+}
+
+func TestAttemptWriterFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	aw := newAttemptWriter(rec, nil)
+
+	// до отправки заголовков сбрасывать нечего
+	aw.Flush()
+	if rec.Flushed {
+		t.Fatal("flushed before the headers were sent")
+	}
+
+	aw.WriteHeader(http.StatusOK)
+	aw.Write([]byte("data: 1\n\n")) //nolint:errcheck
+	aw.Flush()
+	if !rec.Flushed {
+		t.Fatal("flush was not passed to the client")
+	}
+}
+
+func TestAttemptWriterHijack(t *testing.T) {
+	// у recorder нет Hijack: ошибка, попытка не считается завершённой
+	aw := newAttemptWriter(httptest.NewRecorder(), nil)
+	if _, _, err := aw.Hijack(); err == nil {
+		t.Fatal("hijack must fail without a hijackable writer")
+	}
+	if aw.done() {
+		t.Fatal("a failed hijack must not commit the attempt")
+	}
+
+	hijacked := make(chan *attemptWriter, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aw := newAttemptWriter(w, nil)
+		conn, brw, err := aw.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n") //nolint:errcheck
+		brw.Flush()                                                                                         //nolint:errcheck
+		hijacked <- aw
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n") //nolint:errcheck
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status %d, want 101", resp.StatusCode)
+	}
+
+	aw = <-hijacked
+	if !aw.done() || aw.status != http.StatusSwitchingProtocols {
+		t.Fatalf("hijacked attempt: done=%v status=%d", aw.done(), aw.status)
+	}
+}
+
+func TestAttemptWriterTrailers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "body") //nolint:errcheck
+		w.Header().Set("X-Checksum", "abc")
+		w.Header().Set(http.TrailerPrefix+"X-Elapsed", "5ms")
+	}))
+	defer srv.Close()
+
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewBalancer(strat)
+	if err := lb.AddBack(backend.NewBackend(srv.URL, 1)); err != nil {
+		t.Fatal(err)
+	}
+	front := httptest.NewServer(lb)
+	defer front.Close()
+
+	resp, err := http.Get(front.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "body" {
+		t.Fatalf("body %q", body)
+	}
+
+	// трейлеры известны только после чтения тела
+	if got := resp.Trailer.Get("X-Checksum"); got != "abc" {
+		t.Fatalf("declared trailer %q, want abc", got)
+	}
+	if got := resp.Trailer.Get("X-Elapsed"); got != "5ms" {
+		t.Fatalf("undeclared trailer %q, want 5ms", got)
+	}
+}
@@ -0,0 +1,215 @@
+package breaker
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"load_balancer/backend"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/metrics"
+
+	"go.uber.org/zap"
+)
+
+// State - состояние предохранителя
+type State int
+
+const (
+	Closed   State = iota // запросы проходят, ошибки считаются
+	Open                  // запросы не проходят до истечения OpenTimeout
+	HalfOpen              // проходит ограниченное число пробных запросов
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Config - параметры предохранителя
+type Config struct {
+	ErrorRate      float64       // доля ошибок в окне (0, 1], при которой предохранитель размыкается
+	MinRequests    int           // минимум запросов в окне для оценки доли ошибок, не меньше 1
+	Window         time.Duration // окно подсчёта ошибок
+	SlowThreshold  time.Duration // запрос дольше считается ошибкой, 0 - не учитывать время
+	OpenTimeout    time.Duration // время в open до перехода в half-open
+	HalfOpenProbes int           // число пробных запросов в half-open
+}
+
+// circuitBreaker - предохранитель вокруг сервера: при большой доле 5xx или медленных
+// ответов сервер перестаёт получать запросы, через OpenTimeout на него уходят
+// пробные запросы, и если все они успешны, сервер возвращается в работу
+type circuitBreaker struct {
+	backend.BackendIface
+
+	mu          sync.Mutex
+	cfg         Config
+	state       State
+	openedAt    time.Time
+	windowStart time.Time
+	requests    int // запросов в текущем окне
+	failures    int // ошибок в текущем окне
+	probes      int // выданных пробных запросов в half-open
+	successes   int // успешных пробных запросов в half-open
+}
+
+var _ BreakerIface = &circuitBreaker{}
+
+// Wrap - обернуть сервер предохранителем. Параметры вне допустимых значений
+// приводятся к ближайшим: доля ошибок ≤ 0 - размыкание при любой ошибке
+func Wrap(back backend.BackendIface, cfg Config) *circuitBreaker {
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	if cfg.MinRequests < 1 {
+		cfg.MinRequests = 1
+	}
+	if cfg.ErrorRate <= 0 {
+		cfg.ErrorRate = math.SmallestNonzeroFloat64
+	}
+	cfg.ErrorRate = min(cfg.ErrorRate, 1)
+
+	cb := &circuitBreaker{
+		BackendIface: back,
+		cfg:          cfg,
+		windowStart:  time.Now(),
+	}
+	metrics.BreakerState.WithLabelValues(back.GetURL()).Set(float64(Closed))
+	return cb
+}
+
+// IsAlive - сервер доступен и предохранитель пропустит запрос
+func (cb *circuitBreaker) IsAlive() bool {
+	if !cb.BackendIface.IsAlive() {
+		return false
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.advance(time.Now())
+
+	switch cb.state {
+	case Open:
+		return false
+	case HalfOpen:
+		return cb.probes < cb.cfg.HalfOpenProbes
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.advance(time.Now())
+
+	switch cb.state {
+	case Open:
+		return false
+	case HalfOpen:
+		if cb.probes >= cb.cfg.HalfOpenProbes {
+			return false
+		}
+		cb.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) Report(statusCode int, latency time.Duration) {
+	failed := statusCode >= 500 || (cb.cfg.SlowThreshold > 0 && latency > cb.cfg.SlowThreshold)
+	now := time.Now()
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.advance(now)
+
+	switch cb.state {
+	case HalfOpen:
+		if failed {
+			cb.setState(Open, now)
+			return
+		}
+		cb.successes++
+		if cb.successes >= cb.cfg.HalfOpenProbes {
+			cb.setState(Closed, now)
+		}
+
+	case Closed:
+		cb.requests++
+		if failed {
+			cb.failures++
+		}
+		if cb.requests >= cb.cfg.MinRequests && float64(cb.failures)/float64(cb.requests) >= cb.cfg.ErrorRate {
+			cb.setState(Open, now)
+		}
+	}
+}
+
+// отменённый запрос ничего не говорит о сервере: в half-open его слот
+// отдаётся следующему пробному запросу, а счётчики не меняются
+func (cb *circuitBreaker) Release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.advance(time.Now())
+
+	if cb.state == HalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+func (cb *circuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.advance(time.Now())
+	return cb.state
+}
+
+func (cb *circuitBreaker) Unwrap() backend.BackendIface {
+	return cb.BackendIface
+}
+
+// переходы по времени: open -> half-open и сброс окна подсчёта, вызывается под cb.mu
+func (cb *circuitBreaker) advance(now time.Time) {
+	if cb.state == Open && now.Sub(cb.openedAt) >= cb.cfg.OpenTimeout {
+		cb.setState(HalfOpen, now)
+	}
+
+	if cb.state == Closed && cb.cfg.Window > 0 && now.Sub(cb.windowStart) >= cb.cfg.Window {
+		cb.windowStart = now
+		cb.requests = 0
+		cb.failures = 0
+	}
+}
+
+// смена состояния, вызывается под cb.mu
+func (cb *circuitBreaker) setState(state State, now time.Time) {
+	if cb.state == state {
+		return
+	}
+
+	cb.state = state
+	cb.probes = 0
+	cb.successes = 0
+	cb.requests = 0
+	cb.failures = 0
+	cb.windowStart = now
+	if state == Open {
+		cb.openedAt = now
+	}
+
+	url := cb.BackendIface.GetURL()
+	metrics.BreakerState.WithLabelValues(url).Set(float64(state))
+	logger.Log.Info(messages.InfoBreakerState,
+		zap.String(messages.URL, url),
+		zap.String(messages.State, state.String()),
+	)
+}
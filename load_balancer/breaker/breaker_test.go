@@ -0,0 +1,239 @@
+package breaker
+
+import (
+	"math"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"load_balancer/backend"
+	"load_balancer/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func testConfig() Config {
+	return Config{
+		ErrorRate:      0.5,
+		MinRequests:    4,
+		Window:         time.Minute,
+		SlowThreshold:  100 * time.Millisecond,
+		OpenTimeout:    50 * time.Millisecond,
+		HalfOpenProbes: 2,
+	}
+}
+
+func wrap(cfg Config) *circuitBreaker {
+	return Wrap(backend.NewBackend("http://10.0.0.1:8080", 1), cfg)
+}
+
+// разомкнуть предохранитель и дождаться half-open
+func halfOpen(t *testing.T, cb *circuitBreaker) {
+	t.Helper()
+	for range cb.cfg.MinRequests {
+		cb.Report(http.StatusBadGateway, time.Millisecond)
+	}
+	if cb.State() != Open {
+		t.Fatalf("state %v, want open", cb.State())
+	}
+	time.Sleep(cb.cfg.OpenTimeout)
+	if cb.State() != HalfOpen {
+		t.Fatalf("state %v after OpenTimeout, want half-open", cb.State())
+	}
+}
+
+func TestBreakerOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		latency time.Duration
+		opens   bool
+	}{
+		{name: "error rate", status: http.StatusInternalServerError, latency: time.Millisecond, opens: true},
+		{name: "latency", status: http.StatusOK, latency: time.Second, opens: true},
+		{name: "client errors", status: http.StatusNotFound, latency: time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := wrap(testConfig())
+
+			// половина плохих ответов, но меньше MinRequests - ещё рано
+			cb.Report(http.StatusOK, time.Millisecond)
+			cb.Report(tt.status, tt.latency)
+			cb.Report(http.StatusOK, time.Millisecond)
+			if cb.State() != Closed {
+				t.Fatal("opened below MinRequests")
+			}
+
+			cb.Report(tt.status, tt.latency)
+			if opened := cb.State() == Open; opened != tt.opens {
+				t.Fatalf("opened=%v, want %v", opened, tt.opens)
+			}
+			if tt.opens && (cb.Allow() || cb.IsAlive()) {
+				t.Fatal("an open breaker must not let requests through")
+			}
+		})
+	}
+}
+
+func TestBreakerWindow(t *testing.T) {
+	cfg := testConfig()
+	cfg.Window = 30 * time.Millisecond
+	cb := wrap(cfg)
+
+	// ошибки прошлого окна не учитываются
+	for range 3 {
+		cb.Report(http.StatusBadGateway, time.Millisecond)
+	}
+	time.Sleep(cfg.Window)
+	cb.Report(http.StatusBadGateway, time.Millisecond)
+	if cb.State() != Closed {
+		t.Fatal("failures from the previous window were counted")
+	}
+}
+
+func TestBreakerHalfOpenProbes(t *testing.T) {
+	cb := wrap(testConfig())
+	halfOpen(t, cb)
+
+	// пробных запросов не больше HalfOpenProbes
+	for i := range cb.cfg.HalfOpenProbes {
+		if !cb.IsAlive() || !cb.Allow() {
+			t.Fatalf("probe %d rejected", i)
+		}
+	}
+	if cb.IsAlive() || cb.Allow() {
+		t.Fatal("a probe over the limit was let through")
+	}
+}
+
+func TestBreakerHalfOpenClose(t *testing.T) {
+	cb := wrap(testConfig())
+	halfOpen(t, cb)
+
+	cb.Allow()
+	cb.Allow()
+	cb.Report(http.StatusOK, time.Millisecond)
+	if cb.State() != HalfOpen {
+		t.Fatal("closed before all probes succeeded")
+	}
+	cb.Report(http.StatusOK, time.Millisecond)
+	if cb.State() != Closed {
+		t.Fatalf("state %v after successful probes, want closed", cb.State())
+	}
+
+	// после замыкания ошибки считаются заново
+	for range cb.cfg.MinRequests - 1 {
+		cb.Report(http.StatusBadGateway, time.Millisecond)
+	}
+	if cb.State() != Closed {
+		t.Fatal("failures before closing were counted")
+	}
+}
+
+func TestBreakerRelease(t *testing.T) {
+	cb := wrap(testConfig())
+	halfOpen(t, cb)
+
+	// отменённые пробные запросы возвращают слот и не замыкают предохранитель
+	for range 3 {
+		if !cb.Allow() || !cb.Allow() {
+			t.Fatal("a released probe slot was not returned")
+		}
+		cb.Release()
+		cb.Release()
+	}
+	if cb.State() != HalfOpen {
+		t.Fatalf("state %v after released probes, want half-open", cb.State())
+	}
+
+	// в closed освобождать нечего
+	cb.Allow()
+	cb.Allow()
+	cb.Report(http.StatusOK, time.Millisecond)
+	cb.Report(http.StatusOK, time.Millisecond)
+	cb.Release()
+	if cb.State() != Closed {
+		t.Fatalf("state %v, want closed", cb.State())
+	}
+}
+
+func TestBreakerHalfOpenReopen(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		status  int
+		latency time.Duration
+	}{
+		{name: "error", status: http.StatusServiceUnavailable, latency: time.Millisecond},
+		{name: "slow", status: http.StatusOK, latency: time.Second},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := wrap(testConfig())
+			halfOpen(t, cb)
+
+			cb.Allow()
+			cb.Report(http.StatusOK, time.Millisecond)
+			cb.Allow()
+			cb.Report(tt.status, tt.latency)
+			if cb.State() != Open {
+				t.Fatalf("state %v after a failed probe, want open", cb.State())
+			}
+
+			// новый отсчёт OpenTimeout
+			time.Sleep(cb.cfg.OpenTimeout / 2)
+			if cb.State() != Open {
+				t.Fatal("OpenTimeout was not restarted")
+			}
+		})
+	}
+}
+
+func TestBreakerConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		errorRate   float64
+		minRequests int
+		wantRate    float64
+		wantMin     int
+	}{
+		{name: "valid", errorRate: 0.3, minRequests: 5, wantRate: 0.3, wantMin: 5},
+		{name: "rate above one", errorRate: 2, minRequests: 5, wantRate: 1, wantMin: 5},
+		{name: "zero rate", errorRate: 0, minRequests: 5, wantRate: math.SmallestNonzeroFloat64, wantMin: 5},
+		{name: "negative rate", errorRate: -0.5, minRequests: 5, wantRate: math.SmallestNonzeroFloat64, wantMin: 5},
+		{name: "zero min requests", errorRate: 0.5, minRequests: 0, wantRate: 0.5, wantMin: 1},
+		{name: "negative min requests", errorRate: 0.5, minRequests: -3, wantRate: 0.5, wantMin: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.ErrorRate, cfg.MinRequests = tt.errorRate, tt.minRequests
+			cb := wrap(cfg)
+			if cb.cfg.ErrorRate != tt.wantRate || cb.cfg.MinRequests != tt.wantMin {
+				t.Fatalf("errorRate %v, minRequests %d; want %v, %d", cb.cfg.ErrorRate, cb.cfg.MinRequests, tt.wantRate, tt.wantMin)
+			}
+		})
+	}
+
+	// при нулевой доле размыкает любая ошибка, а успешные ответы - нет
+	cfg := testConfig()
+	cfg.ErrorRate, cfg.MinRequests = 0, 0
+	cb := wrap(cfg)
+	for range 10 {
+		cb.Report(http.StatusOK, time.Millisecond)
+	}
+	if cb.State() != Closed {
+		t.Fatal("opened without errors")
+	}
+	cb.Report(http.StatusBadGateway, time.Millisecond)
+	if cb.State() != Open {
+		t.Fatal("an error did not open the breaker with zero error rate")
+	}
+}
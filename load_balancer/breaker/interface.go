@@ -0,0 +1,16 @@
+package breaker
+
+import (
+	"time"
+
+	"load_balancer/backend"
+)
+
+type BreakerIface interface {
+	backend.BackendIface
+	Allow() bool                                  //можно ли отправить запрос (в half-open занимает слот пробного запроса)
+	Report(statusCode int, latency time.Duration) //сообщить результат запроса
+	Release()                                     //запрос отменён без результата: освободить слот пробного запроса
+	State() State                                 //текущее состояние
+	Unwrap() backend.BackendIface                 //исходный сервер без предохранителя
+}
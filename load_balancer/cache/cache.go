@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"container/list"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"load_balancer/metrics"
+)
+
+// Config - параметры кэша ответов
+type Config struct {
+	MaxSize       int64 // предел суммарного размера записей в байтах
+	MaxObjectSize int64 // ответы больше не кэшируются
+}
+
+// entry - сохранённый ответ сервера
+type entry struct {
+	key      string
+	status   int
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+	expires  time.Time
+	size     int64
+}
+
+// Cache - LRU-кэш ответов в памяти балансировщика. Кэшируются только ответы, которые
+// сервер явно разрешил хранить (Cache-Control: max-age/s-maxage или Expires),
+// с учётом Vary; при превышении MaxSize вытесняются давно не запрошенные записи
+type Cache struct {
+	mu    sync.Mutex
+	cfg   Config
+	lru   *list.List               // от недавно запрошенных к давно запрошенным
+	items map[string]*list.Element // ключ варианта -> запись
+	vary  map[string][]string      // ключ ресурса -> заголовки Vary последнего ответа
+	size  int64
+	now   func() time.Time
+}
+
+func New(cfg Config) *Cache {
+	if cfg.MaxObjectSize <= 0 || cfg.MaxObjectSize > cfg.MaxSize {
+		cfg.MaxObjectSize = cfg.MaxSize
+	}
+
+	return &Cache{
+		cfg:   cfg,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+		vary:  make(map[string][]string),
+		now:   time.Now,
+	}
+}
+
+// ключ ресурса: GET и HEAD обслуживаются одной записью
+func resourceKey(r *http.Request) string {
+	return r.Host + r.URL.RequestURI()
+}
+
+// ключ варианта ресурса по значениям заголовков запроса из Vary
+func variantKey(resource string, vary []string, r *http.Request) string {
+	if len(vary) == 0 {
+		return resource
+	}
+
+	var b strings.Builder
+	b.WriteString(resource)
+	for _, name := range vary {
+		b.WriteByte(0)
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// get - свежая запись для запроса; просроченная удаляется
+func (c *Cache) get(r *http.Request) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resource := resourceKey(r)
+	el, ok := c.items[variantKey(resource, c.vary[resource], r)]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	return e, true
+}
+
+// put - сохранить ответ; vary - нормализованные имена заголовков из Vary
+func (c *Cache) put(r *http.Request, vary []string, e *entry) {
+	e.size = int64(len(e.body)) + headerSize(e.header)
+	if e.size > c.cfg.MaxObjectSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resource := resourceKey(r)
+	c.vary[resource] = vary
+	e.key = variantKey(resource, vary, r)
+
+	if el, ok := c.items[e.key]; ok {
+		c.remove(el)
+	}
+	c.items[e.key] = c.lru.PushFront(e)
+	c.size += e.size
+
+	for c.size > c.cfg.MaxSize {
+		c.remove(c.lru.Back())
+	}
+	c.report()
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.items, e.key)
+	c.size -= e.size
+	c.report()
+}
+
+func (c *Cache) report() {
+	metrics.CacheSize.Set(float64(c.size))
+	metrics.CacheEntries.Set(float64(len(c.items)))
+}
+
+// приблизительный размер заголовков
+func headerSize(h http.Header) int64 {
+	var n int64
+	for name, values := range h {
+		for _, v := range values {
+			n += int64(len(name) + len(v) + 4)
+		}
+	}
+	return n
+}
+
+// имена заголовков из Vary в каноническом виде и по порядку; ok=false для Vary: *
+func parseVary(h http.Header) (names []string, ok bool) {
+	for _, value := range h.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, true
+}
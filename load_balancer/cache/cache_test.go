@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"load_balancer/metrics"
+)
+
+// сервер-заглушка: считает запросы и отвечает заданными заголовками и телом
+type origin struct {
+	calls  int
+	header http.Header
+	body   string
+}
+
+func (o *origin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.calls++
+	for name, values := range o.header {
+		w.Header()[name] = values
+	}
+	w.Write([]byte(o.body + r.Header.Get("Accept-Language"))) //nolint:errcheck
+}
+
+func newTestCache(cfg Config) (*Cache, *time.Time) {
+	now := time.Now()
+	c := New(cfg)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func do(h http.Handler, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestCachesUntilExpiry(t *testing.T) {
+	c, now := newTestCache(Config{MaxSize: 1 << 20})
+	o := &origin{header: http.Header{"Cache-Control": {"public, max-age=60"}}, body: "app.js"}
+	h := c.Middleware(o)
+
+	hits, misses := metrics.CounterValue(metrics.CacheHits), metrics.CounterValue(metrics.CacheMisses)
+
+	first := do(h, http.MethodGet, "/assets/app.js", nil)
+	if first.Header().Get("X-Cache") != "MISS" || first.Body.String() != "app.js" {
+		t.Fatalf("expected MISS with body, got %q %q", first.Header().Get("X-Cache"), first.Body.String())
+	}
+
+	*now = now.Add(30 * time.Second)
+	second := do(h, http.MethodGet, "/assets/app.js", nil)
+	if o.calls != 1 || second.Header().Get("X-Cache") != "HIT" || second.Body.String() != "app.js" {
+		t.Fatalf("expected HIT without a backend call, calls=%d header=%q", o.calls, second.Header().Get("X-Cache"))
+	}
+	if second.Header().Get("Age") != "30" {
+		t.Fatalf("expected Age 30, got %q", second.Header().Get("Age"))
+	}
+
+	head := do(h, http.MethodHead, "/assets/app.js", nil)
+	if o.calls != 1 || head.Body.Len() != 0 {
+		t.Fatalf("expected HEAD to be served from the GET entry without body")
+	}
+
+	*now = now.Add(31 * time.Second)
+	do(h, http.MethodGet, "/assets/app.js", nil)
+	if o.calls != 2 {
+		t.Fatalf("expected expired entry to be refetched, calls=%d", o.calls)
+	}
+
+	if got := metrics.CounterValue(metrics.CacheHits) - hits; got != 2 {
+		t.Fatalf("expected 2 hits, got %v", got)
+	}
+	if got := metrics.CounterValue(metrics.CacheMisses) - misses; got != 2 {
+		t.Fatalf("expected 2 misses, got %v", got)
+	}
+}
+
+func TestDoesNotCacheWithoutPermission(t *testing.T) {
+	cases := map[string]http.Header{
+		"no headers":  {},
+		"no-store":    {"Cache-Control": {"no-store, max-age=60"}},
+		"private":     {"Cache-Control": {"private, max-age=60"}},
+		"set-cookie":  {"Cache-Control": {"max-age=60"}, "Set-Cookie": {"lb_affinity=x"}},
+		"vary star":   {"Cache-Control": {"max-age=60"}, "Vary": {"*"}},
+		"age too old": {"Cache-Control": {"max-age=60"}, "Age": {"60"}},
+	}
+
+	for name, header := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, _ := newTestCache(Config{MaxSize: 1 << 20})
+			o := &origin{header: header, body: "page"}
+			h := c.Middleware(o)
+
+			do(h, http.MethodGet, "/main", nil)
+			do(h, http.MethodGet, "/main", nil)
+			if o.calls != 2 {
+				t.Fatalf("expected response not to be cached, calls=%d", o.calls)
+			}
+		})
+	}
+
+	t.Run("authorization", func(t *testing.T) {
+		c, _ := newTestCache(Config{MaxSize: 1 << 20})
+		o := &origin{header: http.Header{"Cache-Control": {"max-age=60"}}}
+		h := c.Middleware(o)
+
+		auth := map[string]string{"Authorization": "Bearer x"}
+		do(h, http.MethodGet, "/main", auth)
+		do(h, http.MethodGet, "/main", auth)
+		if o.calls != 2 {
+			t.Fatalf("expected requests with Authorization to bypass the cache, calls=%d", o.calls)
+		}
+	})
+}
+
+func TestExpiresHeader(t *testing.T) {
+	c, now := newTestCache(Config{MaxSize: 1 << 20})
+	o := &origin{header: http.Header{
+		"Date":    {now.UTC().Format(http.TimeFormat)},
+		"Expires": {now.Add(time.Minute).UTC().Format(http.TimeFormat)},
+	}}
+	h := c.Middleware(o)
+
+	do(h, http.MethodGet, "/", nil)
+	do(h, http.MethodGet, "/", nil)
+	if o.calls != 1 {
+		t.Fatalf("expected Expires to make the response cacheable, calls=%d", o.calls)
+	}
+}
+
+func TestVary(t *testing.T) {
+	c, _ := newTestCache(Config{MaxSize: 1 << 20})
+	o := &origin{header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"accept-language"}}, body: "page-"}
+	h := c.Middleware(o)
+
+	en := map[string]string{"Accept-Language": "en"}
+	ru := map[string]string{"Accept-Language": "ru"}
+
+	do(h, http.MethodGet, "/login", en)
+	if got := do(h, http.MethodGet, "/login", ru).Body.String(); got != "page-ru" {
+		t.Fatalf("expected separate variant for ru, got %q", got)
+	}
+	if got := do(h, http.MethodGet, "/login", en).Body.String(); got != "page-en" || o.calls != 2 {
+		t.Fatalf("expected cached en variant, got %q calls=%d", got, o.calls)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	c, now := newTestCache(Config{MaxSize: 1 << 20})
+	modified := now.Add(-time.Hour).UTC().Format(http.TimeFormat)
+	o := &origin{header: http.Header{
+		"Cache-Control": {"max-age=60"},
+		"Etag":          {`"v1"`},
+		"Last-Modified": {modified},
+		"Content-Type":  {"text/css"},
+	}, body: "body{}"}
+	h := c.Middleware(o)
+	do(h, http.MethodGet, "/assets/app.css", nil)
+
+	cases := []struct {
+		name   string
+		header map[string]string
+		want   int
+	}{
+		{"matching etag", map[string]string{"If-None-Match": `"v0", W/"v1"`}, http.StatusNotModified},
+		{"any etag", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"stale etag", map[string]string{"If-None-Match": `"v0"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": modified}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": now.Add(-2 * time.Hour).UTC().Format(http.TimeFormat)}, http.StatusOK},
+		{"etag wins over date", map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": modified}, http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		rec := do(h, http.MethodGet, "/assets/app.css", tc.header)
+		if rec.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, rec.Code)
+		}
+		if rec.Code == http.StatusNotModified && (rec.Body.Len() != 0 || rec.Header().Get("ETag") != `"v1"`) {
+			t.Fatalf("%s: expected empty 304 with ETag", tc.name)
+		}
+	}
+	if o.calls != 1 {
+		t.Fatalf("expected conditional requests to be answered from the cache, calls=%d", o.calls)
+	}
+}
+
+func TestClientNoCacheBypassesLookup(t *testing.T) {
+	c, _ := newTestCache(Config{MaxSize: 1 << 20})
+	o := &origin{header: http.Header{"Cache-Control": {"max-age=60"}}}
+	h := c.Middleware(o)
+
+	do(h, http.MethodGet, "/", nil)
+	do(h, http.MethodGet, "/", map[string]string{"Cache-Control": "no-cache"})
+	do(h, http.MethodGet, "/", nil)
+	if o.calls != 2 {
+		t.Fatalf("expected only the no-cache request to reach the backend, calls=%d", o.calls)
+	}
+}
+
+func TestSizeCapEvictsLeastRecentlyUsed(t *testing.T) {
+	body := strings.Repeat("x", 400)
+	c, _ := newTestCache(Config{MaxSize: 1000, MaxObjectSize: 500})
+	o := &origin{header: http.Header{"Cache-Control": {"max-age=60"}}, body: body}
+	h := c.Middleware(o)
+
+	do(h, http.MethodGet, "/a", nil)
+	do(h, http.MethodGet, "/b", nil)
+	do(h, http.MethodGet, "/a", nil) // /a становится недавно запрошенным
+	do(h, http.MethodGet, "/c", nil) // вытесняет /b
+	if o.calls != 3 {
+		t.Fatalf("expected 3 backend calls, got %d", o.calls)
+	}
+	if c.size > 1000 {
+		t.Fatalf("expected cache size within cap, got %d", c.size)
+	}
+
+	do(h, http.MethodGet, "/a", nil)
+	if o.calls != 3 {
+		t.Fatal("expected /a to stay cached")
+	}
+	do(h, http.MethodGet, "/b", nil)
+	if o.calls != 4 {
+		t.Fatal("expected /b to be evicted")
+	}
+
+	// ответ больше maxObjectSize отдаётся клиенту целиком, но не кэшируется
+	o.body = strings.Repeat("y", 600)
+	if got := do(h, http.MethodGet, "/big", nil).Body.Len(); got != 600 {
+		t.Fatalf("expected full body for a large response, got %d bytes", got)
+	}
+	do(h, http.MethodGet, "/big", nil)
+	if o.calls != 6 {
+		t.Fatalf("expected large response not to be cached, calls=%d", o.calls)
+	}
+}
+
+func TestKeepsHeadersSetBeforeCache(t *testing.T) {
+	c, _ := newTestCache(Config{MaxSize: 1 << 20})
+	o := &origin{header: http.Header{"Cache-Control": {"max-age=60"}}}
+	limited := func(remaining string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit-Remaining", remaining)
+			c.Middleware(o).ServeHTTP(w, r)
+		})
+	}
+
+	do(limited("5"), http.MethodGet, "/", nil)
+	rec := do(limited("4"), http.MethodGet, "/", nil)
+	if got := rec.Header().Get("RateLimit-Remaining"); got != "4" {
+		t.Fatalf("expected per-request RateLimit-Remaining, got %q", got)
+	}
+}
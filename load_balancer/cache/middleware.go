@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"load_balancer/metrics"
+)
+
+// Middleware - ответы из кэша для GET и HEAD, остальные запросы проходят к next
+func (c *Cache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cacheableRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// клиент требует ответ от сервера (принудительное обновление страницы)
+		cc := cacheControl(r.Header)
+		_, noCache := cc["no-cache"]
+		if !noCache && r.Header.Get("Pragma") != "no-cache" {
+			if e, ok := c.get(r); ok {
+				metrics.CacheHits.Inc()
+				c.serve(w, r, e)
+				return
+			}
+		}
+		metrics.CacheMisses.Inc()
+
+		if _, noStore := cc["no-store"]; noStore || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &recorder{w: w, header: make(http.Header), limit: c.cfg.MaxObjectSize}
+		next.ServeHTTP(rec, r)
+		c.store(r, rec)
+	})
+}
+
+// сохранение ответа, если сервер разрешил его хранить и он поместился целиком
+func (c *Cache) store(r *http.Request, rec *recorder) {
+	if rec.overflow {
+		return
+	}
+
+	now := c.now()
+	ttl, ok := lifetime(rec.status, rec.header, now)
+	if !ok {
+		return
+	}
+	vary, ok := parseVary(rec.header)
+	if !ok {
+		return
+	}
+
+	age, _ := strconv.Atoi(rec.header.Get("Age"))
+	header := rec.header.Clone()
+	header.Del("Age")
+	header.Del("X-Cache")
+
+	c.put(r, vary, &entry{
+		status:   rec.status,
+		header:   header,
+		body:     rec.body,
+		storedAt: now.Add(-time.Duration(age) * time.Second),
+		expires:  now.Add(ttl),
+	})
+}
+
+// ответ из записи кэша; заголовки, уже выставленные до кэша (RateLimit-*), сохраняются
+func (c *Cache) serve(w http.ResponseWriter, r *http.Request, e *entry) {
+	h := w.Header()
+	for name, values := range e.header {
+		h[name] = append([]string(nil), values...)
+	}
+	h.Set("Age", strconv.Itoa(int(c.now().Sub(e.storedAt)/time.Second)))
+	h.Set("X-Cache", "HIT")
+
+	if notModified(r, e) {
+		h.Del("Content-Length")
+		h.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Length", strconv.Itoa(len(e.body)))
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		w.Write(e.body) //nolint:errcheck
+	}
+}
+
+// recorder - ответ сервера стримится клиенту и одновременно копируется для кэша,
+// пока укладывается в limit
+type recorder struct {
+	w        http.ResponseWriter
+	header   http.Header // только заголовки сервера, без выставленных до кэша
+	status   int
+	body     []byte
+	limit    int64
+	overflow bool
+}
+
+var (
+	_ http.ResponseWriter = &recorder{}
+	_ http.Flusher        = &recorder{}
+)
+
+func (rec *recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if rec.status != 0 {
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		rec.copyHeader()
+		rec.w.WriteHeader(code)
+		return
+	}
+
+	rec.status = code
+	rec.copyHeader()
+	rec.w.Header().Set("X-Cache", "MISS")
+	rec.w.WriteHeader(code)
+}
+
+func (rec *recorder) copyHeader() {
+	h := rec.w.Header()
+	for name, values := range rec.header {
+		h[name] = append([]string(nil), values...)
+	}
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	if !rec.overflow {
+		if int64(len(rec.body)+len(b)) > rec.limit {
+			rec.overflow = true
+			rec.body = nil
+		} else {
+			rec.body = append(rec.body, b...)
+		}
+	}
+	return rec.w.Write(b)
+}
+
+func (rec *recorder) Flush() {
+	http.NewResponseController(rec.w).Flush() //nolint:errcheck
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.w
+}
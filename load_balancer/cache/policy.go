@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// директивы Cache-Control: имя в нижнем регистре -> значение без кавычек
+func cacheControl(h http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range h.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// можно ли ответить на запрос из кэша и сохранить ответ на него
+func cacheableRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	// WebSocket и прочие Upgrade проходят мимо кэша
+	if r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	// ответ для конкретного пользователя общему кэшу хранить нельзя (RFC 9111, 3.5)
+	return r.Header.Get("Authorization") == ""
+}
+
+// lifetime - сколько ответ остаётся свежим по заголовкам сервера; ok=false - не кэшировать.
+// Без явного срока (эвристика RFC 9111, 4.2.2) ответ не кэшируется: сервер отдаёт
+// и пользовательские данные, и статику, и хранить стоит только то, что он разрешил
+func lifetime(status int, h http.Header, now time.Time) (time.Duration, bool) {
+	if status != http.StatusOK {
+		return 0, false
+	}
+	// cookie ответа (в том числе привязки к серверу) адресованы одному клиенту
+	if h.Get("Set-Cookie") != "" {
+		return 0, false
+	}
+
+	cc := cacheControl(h)
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, found := cc[directive]; found {
+			return 0, false
+		}
+	}
+
+	var ttl time.Duration
+	if v, found := cc["s-maxage"]; found {
+		ttl = seconds(v)
+	} else if v, found := cc["max-age"]; found {
+		ttl = seconds(v)
+	} else if expires, err := http.ParseTime(h.Get("Expires")); err == nil {
+		date, err := http.ParseTime(h.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		ttl = expires.Sub(date)
+	}
+
+	// уже проведённое в кэшах выше время
+	if age, err := strconv.Atoi(h.Get("Age")); err == nil {
+		ttl -= time.Duration(age) * time.Second
+	}
+
+	return ttl, ttl > 0
+}
+
+func seconds(v string) time.Duration {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// notModified - условный запрос клиента выполняется для сохранённой записи (RFC 9110, 13.2.2):
+// If-None-Match сравнивается с ETag слабым сравнением, If-Modified-Since - только без него
+func notModified(r *http.Request, e *entry) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := e.header.Get("ETag")
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakTag(candidate) == weakTag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(e.header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+func weakTag(tag string) string {
+	return strings.TrimPrefix(tag, "W/")
+}
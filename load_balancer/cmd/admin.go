@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/handler"
+	"load_balancer/internal/messages"
+	"load_balancer/internal/middleware"
+)
+
+// админский API за проверкой токена или клиентского сертификата
+func adminHandler(cfg configloading.AdminConfig, backends *handler.BackendsHandler, limiter *handler.LimiterHandler) http.Handler {
+	auth := &middleware.AdminAuth{Token: cfg.Token}
+	return auth.Middleware(adminRoutes(backends, limiter))
+}
+
+// маршруты админского API: управление серверами и лимитером
+func adminRoutes(backends *handler.BackendsHandler, limiter *handler.LimiterHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.Handle("GET /backends", backends.ListHandler())
+	mux.Handle("POST /backends", backends.AddHandler())
+	mux.Handle("DELETE /backends", backends.RemoveHandler())
+	mux.Handle("POST /backends/drain", backends.DrainHandler())
+
+	mux.Handle("GET /limiter/buckets", limiter.ListHandler())
+	mux.Handle("GET /limiter/bucket", limiter.GetHandler())
+	mux.Handle("POST /limiter/bucket", limiter.SetHandler())
+	mux.Handle("DELETE /limiter/bucket", limiter.DeleteHandler())
+	mux.Handle("POST /limiter/bucket/reset", limiter.ResetHandler())
+	mux.Handle("GET /limiter/acl", limiter.ACLListHandler())
+	mux.Handle("POST /limiter/acl", limiter.ACLAddHandler())
+	mux.Handle("DELETE /limiter/acl", limiter.ACLRemoveHandler())
+	mux.Handle("POST /limiter/bans", limiter.BanHandler())
+	mux.Handle("DELETE /limiter/bans", limiter.UnbanHandler())
+
+	return mux
+}
+
+// отдельный сервер админского API; с clientCA клиентский сертификат,
+// подписанный этим CA, заменяет токен (mTLS)
+func newAdminServer(cfg configloading.AdminConfig, h http.Handler) (*http.Server, error) {
+	server := &http.Server{
+		Addr:    cfg.Address,
+		Handler: h,
+	}
+
+	if cfg.ClientCAFile == "" {
+		return server, nil
+	}
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New(messages.ErrAdminNoCert)
+	}
+
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf(messages.ErrAdminClientCA, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf(messages.ErrAdminClientCA, errors.New(cfg.ClientCAFile))
+	}
+
+	server.TLSConfig = &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		MinVersion: tls.VersionTLS12,
+	}
+	return server, nil
+}
+
+// запуск админского сервера, с сертификатом - по TLS
+func serveAdmin(server *http.Server, cfg configloading.AdminConfig) error {
+	if cfg.CertFile != "" {
+		return server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+	}
+	return server.ListenAndServe()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"load_balancer/balancer"
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/handler"
+	"load_balancer/internal/logger"
+	"load_balancer/strategy"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestAdminAuth(t *testing.T) {
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := balancer.NewRouter(balancer.NewBalancer(strat))
+	h := adminHandler(configloading.AdminConfig{Token: "secret"}, &handler.BackendsHandler{Balancer: router}, &handler.LimiterHandler{})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		want   int
+	}{
+		{name: "add without token", method: http.MethodPost, target: "/backends?url=http://10.0.0.1:8080", want: http.StatusUnauthorized},
+		{name: "add with a wrong token", method: http.MethodPost, target: "/backends?url=http://10.0.0.1:8080", token: "guess", want: http.StatusUnauthorized},
+		{name: "drain without token", method: http.MethodPost, target: "/backends/drain?url=http://10.0.0.1:8080", want: http.StatusUnauthorized},
+		{name: "remove without token", method: http.MethodDelete, target: "/backends?url=http://10.0.0.1:8080", want: http.StatusUnauthorized},
+		{name: "list without token", method: http.MethodGet, target: "/backends", want: http.StatusUnauthorized},
+		{name: "add with token", method: http.MethodPost, target: "/backends?url=http://10.0.0.1:8080", token: "secret", want: http.StatusCreated},
+		{name: "list with token", method: http.MethodGet, target: "/backends", token: "secret", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	if got := len(router.ListBacks()); got != 1 {
+		t.Fatalf("%d backends, want only the one added with the token", got)
+	}
+}
+
+func TestAdminAddBackendURL(t *testing.T) {
+	strat, err := strategy.New(strategy.RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := balancer.NewRouter(balancer.NewBalancer(strat))
+	h := adminHandler(configloading.AdminConfig{Token: "secret"}, &handler.BackendsHandler{Balancer: router}, &handler.LimiterHandler{})
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{name: "no scheme", url: "foo", want: http.StatusBadRequest},
+		{name: "other scheme", url: "ftp://10.0.0.1", want: http.StatusBadRequest},
+		{name: "no host", url: "http://", want: http.StatusBadRequest},
+		{name: "http", url: "http://10.0.0.1:8080", want: http.StatusCreated},
+		{name: "https", url: "https://10.0.0.2", want: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/backends?url="+url.QueryEscape(tt.url), nil)
+			r.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	if got := len(router.ListBacks()); got != 2 {
+		t.Fatalf("%d backends, want only the two valid ones", got)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,13 +11,17 @@ import (
 
 	"load_balancer/backend"
 	"load_balancer/balancer"
+	"load_balancer/cache"
 	configloading "load_balancer/config_loading"
 	"load_balancer/internal/handler"
 	"load_balancer/internal/logger"
 	"load_balancer/internal/messages"
 	"load_balancer/internal/middleware"
+	"load_balancer/internal/util"
 	"load_balancer/metrics"
 	ratelimiter "load_balancer/rate_limiter"
+	tlsconfig "load_balancer/tls_config"
+	"load_balancer/tracing"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
@@ -35,24 +40,58 @@ func init() {
 func main() {
 	metrics.Init()
 	defer logger.Log.Sync() //nolint:errcheck
-	serverAddr, backendAddr, interval, dbAddr, salt, defaultMaxTokens, defaultRate := configloading.SetParams()
+	serverAddr, interval, salt := configloading.SetParams()
 
-	rl := ratelimiter.NewBucket(dbAddr, defaultMaxTokens, defaultRate)
+	if err := util.SetTrustedProxies(configloading.TrustedProxiesParams()); err != nil {
+		logger.Log.Fatal(messages.ErrTrustedProxies, zap.Error(err))
+	}
+
+	tlsCfg := configloading.TLSParams()
+	if tlsCfg.BackendCAFile != "" {
+		transport, err := tlsconfig.BackendTransport(tlsCfg.BackendCAFile)
+		if err != nil {
+			logger.Log.Fatal(messages.ErrBackendTLS, zap.Error(err))
+		}
+		backend.SetTransport(transport)
+	}
+
+	limiterCfg := configloading.LimiterParams()
+	rl, err := ratelimiter.New(limiterCfg)
+	if err != nil {
+		logger.Log.Fatal(messages.ErrLimiterInit, zap.Error(err))
+	}
+
+	policies, sessionCookie := configloading.PolicyParams()
 	middlewareHandler := &middleware.MiddlewareHandler{
-		Limiter: rl,
-		Salt:    salt,
+		Limiter:       rl,
+		Salt:          salt,
+		SessionCookie: sessionCookie,
+	}
+	middlewareHandler.SetPolicies(newPolicies(limiterCfg, policies))
+
+	acl := ratelimiter.NewAccessList()
+	allow, deny := configloading.ACLParams()
+	for list, entries := range map[string][]string{ratelimiter.ListAllow: allow, ratelimiter.ListDeny: deny} {
+		for _, entry := range entries {
+			if err := acl.Add(list, entry, 0); err != nil {
+				logger.Log.Error(messages.ErrACL, zap.Error(err))
+			}
+		}
 	}
+	middlewareHandler.ACL = acl
 
-	setupHandler := &handler.LimiterHandler{
-		Limiter: rl,
+	limiterHandler := &handler.LimiterHandler{
+		Limits: middlewareHandler,
+		ACL:    acl,
 	}
 
-	lb := balancer.NewBalancer()
-	for _, addr := range backendAddr {
-		lb.AddBack(backend.NewBackend(addr))
+	lb := newRouter()
+
+	backendsHandler := &handler.BackendsHandler{
+		Balancer: lb,
 	}
 
-	// контекст для завершения работы тикеров
+	// контекст для завершения работы проверки серверов
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -60,7 +99,11 @@ func main() {
 	defer ticker.Stop()
 
 	go lb.HealthCheck(ctx, ticker.C)
-	go middlewareHandler.Limiter.StopAllTickers(ctx)
+
+	metrics.ConfigLastReloadSuccess.Set(1)
+	if err := configloading.WatchConfig(applyConfig(lb, rl, ticker)); err != nil {
+		logger.Log.Error(messages.ErrWatchConfig, zap.Error(err))
+	}
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
@@ -68,17 +111,93 @@ func main() {
 	mux := http.NewServeMux()
 
 	mux.Handle("/metrics", promhttp.Handler())
-	mux.Handle("/set_rate", setupHandler.SetRateHandler())
-	mux.Handle("/set_max", setupHandler.SetMaxHandler())
+	readiness := &handler.ReadinessHandler{}
+	mux.Handle("/ready", readiness.ReadyHandler())
 	mux.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
 
-	mux.Handle("/", middlewareHandler.LimitMiddleware(lb))
+	adminCfg := configloading.AdminParams()
+	if adminCfg.Token == "" && adminCfg.ClientCAFile == "" {
+		logger.Log.Warn(messages.ErrNoAdminAuth)
+	}
+	admin := adminHandler(adminCfg, backendsHandler, limiterHandler)
+
+	// без отдельного адреса админский API обслуживается основным сервером
+	var adminServer *http.Server
+	if adminCfg.Address == "" {
+		mux.Handle("/backends", admin)
+		mux.Handle("/backends/", admin)
+		mux.Handle("/limiter/", admin)
+	} else {
+		adminServer, err = newAdminServer(adminCfg, admin)
+		if err != nil {
+			logger.Log.Fatal(messages.ErrAdminServer, zap.Error(err))
+		}
+	}
+
+	// кэш за лимитером: ответы из кэша тоже расходуют лимит клиента
+	var proxy http.Handler = lb
+	// копируются только запросы, дошедшие до серверов, ответы из кэша - нет
+	if enabled, cfg := configloading.MirrorParams(); enabled {
+		shadow, ok := lb.Pool(cfg.Pool)
+		if !ok {
+			logger.Log.Fatal(messages.ErrMirror, zap.Error(fmt.Errorf(messages.ErrUnknownPool, cfg.Pool)))
+		}
+		proxy = balancer.NewMirror(cfg, shadow).Middleware(proxy)
+		logger.Log.Info(messages.InfoMirrorON,
+			zap.String(messages.Pool, cfg.Pool),
+			zap.Float64(messages.Percent, cfg.Percent),
+		)
+	}
+	// предел одновременных запросов считает только запросы к серверам, ответы из кэша - нет
+	if enabled, cfg := configloading.SheddingParams(); enabled {
+		shedding := &middleware.Shedding{
+			Limiter:      ratelimiter.NewAdaptive(cfg.Limit),
+			Classes:      cfg.Classes,
+			DefaultShare: cfg.DefaultShare,
+			RetryAfter:   cfg.RetryAfter,
+		}
+		proxy = shedding.Middleware(proxy)
+		logger.Log.Info(messages.InfoSheddingON, zap.Int(messages.Limit, cfg.Limit.InitialLimit))
+	}
+	if enabled, cfg := configloading.CacheParams(); enabled {
+		proxy = cache.New(cfg).Middleware(proxy)
+	}
+	// сжатие поверх кэша: в кэше один несжатый вариант для любых Accept-Encoding
+	if enabled, cfg := configloading.CompressionParams(); enabled {
+		compression := &middleware.Compression{
+			MinSize:      cfg.MinSize,
+			ContentTypes: cfg.ContentTypes,
+			SkipPrefixes: cfg.SkipPrefixes,
+		}
+		proxy = compression.Middleware(proxy)
+	}
+	// операция балансировщика охватывает и отказы лимитера
+	mux.Handle("/", tracing.Middleware(middlewareHandler.LimitMiddleware(proxy)))
+
+	var exporter *tracing.Exporter
+	if enabled, cfg := configloading.TracingParams(); enabled {
+		cfg.OnError = func(err error) {
+			logger.Log.Warn(messages.ErrExportSpans, zap.Error(err))
+		}
+		exporter = tracing.NewExporter(cfg)
+		tracing.SetExporter(exporter)
+		logger.Log.Info(messages.InfoTracingON, zap.String(messages.URL, cfg.Endpoint))
+	}
 
 	server := &http.Server{
 		Addr:    serverAddr,
 		Handler: mux,
 	}
 
+	var tlsServer *http.Server
+	if tlsCfg.Enabled {
+		tlsServer, err = newTLSServer(ctx, tlsCfg, mux)
+		if err != nil {
+			logger.Log.Fatal(messages.ErrTLSServer, zap.Error(err))
+		}
+		server.Handler = plainHandler(tlsCfg, mux)
+	}
+
 	go func() {
 		logger.Log.Info(messages.InfoBalancerON, zap.String(messages.Port, server.Addr))
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -86,12 +205,33 @@ func main() {
 		}
 	}()
 
+	if tlsServer != nil {
+		go func() {
+			logger.Log.Info(messages.InfoTLSON, zap.String(messages.Port, tlsServer.Addr))
+			// сертификат задан в TLSConfig.GetCertificate
+			if err := tlsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				logger.Log.Error(messages.ErrLAS, zap.Error(err))
+			}
+		}()
+	}
+
+	if adminServer != nil {
+		go func() {
+			logger.Log.Info(messages.InfoAdminON, zap.String(messages.Port, adminServer.Addr))
+			if err := serveAdmin(adminServer, adminCfg); err != nil && err != http.ErrServerClosed {
+				logger.Log.Error(messages.ErrLAS, zap.Error(err))
+			}
+		}()
+	}
+
 	<-stop
 
 	logger.Log.Info(messages.InfoGracefulStopStart)
-	cancel()
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Log.Error(messages.ErrShutdown, zap.Error(err))
+	shutdownCfg := configloading.ShutdownParams()
+	drain(shutdownCfg, lb, readiness, server, tlsServer, adminServer)
+	if exporter != nil {
+		flushTraces(exporter, shutdownCfg.Timeout)
 	}
+	cancel()
 	logger.Log.Info(messages.InfoGracefulStopFinish)
 }
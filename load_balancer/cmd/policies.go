@@ -0,0 +1,39 @@
+package main
+
+import (
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/internal/middleware"
+	ratelimiter "load_balancer/rate_limiter"
+
+	"go.uber.org/zap"
+)
+
+// политики ограничения запросов: у каждой свой лимитер с теми же алгоритмом и хранилищем,
+// что у общего, но со своими лимитами
+func newPolicies(base ratelimiter.Config, configs []configloading.PolicyConfig) []middleware.Policy {
+	policies := make([]middleware.Policy, 0, len(configs))
+
+	for _, cfg := range configs {
+		limiterCfg := base
+		limiterCfg.Name = cfg.Name // свой префикс ключей в Redis
+		limiterCfg.MaxTokens = cfg.MaxTokens
+		limiterCfg.Rate = cfg.Rate
+
+		rl, err := ratelimiter.New(limiterCfg)
+		if err != nil {
+			logger.Log.Fatal(messages.ErrLimiterInit, zap.String(messages.Policy, cfg.Name), zap.Error(err))
+		}
+
+		policies = append(policies, middleware.Policy{
+			Name:    cfg.Name,
+			Prefix:  cfg.Prefix,
+			Methods: cfg.Methods,
+			Key:     cfg.Key,
+			Limiter: rl,
+		})
+	}
+
+	return policies
+}
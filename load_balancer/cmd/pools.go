@@ -0,0 +1,103 @@
+package main
+
+import (
+	"load_balancer/backend"
+	"load_balancer/balancer"
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/strategy"
+
+	"go.uber.org/zap"
+)
+
+// пул серверов со своими стратегией, проверкой, повторами и разделением трафика; привязка клиента
+// и предохранитель общие для всех пулов
+func newPool(cfg configloading.PoolConfig) (balancer.BalancerIface, error) {
+	strat, err := strategy.New(cfg.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	lb := balancer.NewBalancer(strat)
+	// серверы из конфига, а не добавленные вручную: перезагрузка может их удалить
+	lb.SyncBacks(newBackends(cfg.Backends))
+
+	if enabled, keyCookie, cookie := configloading.AffinityParams(); enabled {
+		lb.EnableAffinity(keyCookie, affinityCookie(cookie, cfg.Name))
+	}
+	lb.SetBodyBuffering(cfg.Retry.BufferBody, cfg.Retry.MaxBodySize)
+	lb.SetAttempts(cfg.Retry.Attempts)
+	lb.SetHealthConfig(cfg.Health)
+	lb.SetSplit(cfg.Split)
+	lb.SetHedging(cfg.Hedge)
+	if enabled, breakerCfg := configloading.BreakerParams(); enabled {
+		lb.SetBreaker(breakerCfg)
+	}
+
+	return lb, nil
+}
+
+// у каждого пула своя cookie привязки: все они с Path "/", и с общей cookie
+// ответ одного пула затирал бы привязку к серверу другого. Пул по умолчанию
+// сохраняет имя из конфига, чтобы уже выданные cookie не потерялись
+func affinityCookie(cookie, pool string) string {
+	if pool == configloading.DefaultPool {
+		return cookie
+	}
+	return cookie + "_" + pool
+}
+
+// серверы из списка конфига; неверные адреса пропускаются
+func newBackends(configs []configloading.BackendConfig) []backend.BackendIface {
+	servers := make([]backend.BackendIface, 0, len(configs))
+	for _, cfg := range configs {
+		if server := backend.NewBackend(cfg.URL, cfg.Weight); server != nil {
+			server.SetHealthCheck(cfg.HealthPath, cfg.HealthStatus)
+			server.SetGroup(cfg.Group)
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// пул по умолчанию, именованные пулы и таблица маршрутизации между ними
+func newRouter() *balancer.Router {
+	def, err := newPool(configloading.DefaultPoolParams())
+	if err != nil {
+		logger.Log.Fatal(messages.ErrStrategy, zap.Error(err))
+	}
+	router := balancer.NewRouter(def)
+
+	for _, cfg := range configloading.PoolParams() {
+		pool, err := newPool(cfg)
+		if err != nil {
+			logger.Log.Fatal(messages.ErrPool, zap.String(messages.Pool, cfg.Name), zap.Error(err))
+		}
+		if err := router.AddPool(cfg.Name, pool); err != nil {
+			logger.Log.Fatal(messages.ErrPool, zap.String(messages.Pool, cfg.Name), zap.Error(err))
+		}
+		logger.Log.Info(messages.InfoPool,
+			zap.String(messages.Pool, cfg.Name),
+			zap.Int(messages.Count, len(cfg.Backends)),
+		)
+	}
+
+	if err := router.SetRoutes(newRoutes(configloading.RouteParams())); err != nil {
+		logger.Log.Fatal(messages.ErrRoutes, zap.Error(err))
+	}
+	return router
+}
+
+func newRoutes(configs []configloading.RouteConfig) []balancer.Route {
+	routes := make([]balancer.Route, 0, len(configs))
+	for _, cfg := range configs {
+		routes = append(routes, balancer.Route{
+			Pool:    cfg.Pool,
+			Prefix:  cfg.Prefix,
+			Host:    cfg.Host,
+			Methods: cfg.Methods,
+		})
+	}
+	return routes
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	configloading "load_balancer/config_loading"
+	"load_balancer/strategy"
+
+	"github.com/spf13/viper"
+)
+
+func TestPoolAffinityCookie(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set(configloading.AffinityEnabled, true)
+
+	tests := []struct {
+		pool string
+		want string
+	}{
+		{pool: configloading.DefaultPool, want: "lb_affinity"},
+		{pool: "chat", want: "lb_affinity_chat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pool, func(t *testing.T) {
+			pool, err := newPool(configloading.PoolConfig{
+				Name:     tt.pool,
+				Strategy: strategy.RoundRobin,
+				Backends: []configloading.BackendConfig{{URL: srv.URL, Weight: 1}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&http.Cookie{Name: "authToken", Value: "token"})
+			rec := httptest.NewRecorder()
+			pool.ServeHTTP(rec, r)
+
+			cookies := rec.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != tt.want {
+				t.Fatalf("cookies %v, want %s", cookies, tt.want)
+			}
+		})
+	}
+}
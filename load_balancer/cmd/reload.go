@@ -0,0 +1,55 @@
+package main
+
+import (
+	"time"
+
+	"load_balancer/balancer"
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/metrics"
+	ratelimiter "load_balancer/rate_limiter"
+
+	"go.uber.org/zap"
+)
+
+// применение перезагруженного конфига; при ошибке продолжает действовать предыдущий
+func applyConfig(router balancer.RouterIface, rl ratelimiter.BucketIface, ticker *time.Ticker) func(configloading.ReloadParams, error) {
+	return func(params configloading.ReloadParams, err error) {
+		if err != nil {
+			logger.Log.Error(messages.ErrConfigRejected, zap.Error(err))
+			metrics.ConfigReloadsTotal.WithLabelValues("rejected").Inc()
+			metrics.ConfigLastReloadSuccess.Set(0)
+			return
+		}
+
+		// таблица маршрутизации ссылается только на уже созданные пулы
+		if err := router.SetRoutes(newRoutes(params.Routes)); err != nil {
+			logger.Log.Error(messages.ErrConfigRejected, zap.Error(err))
+			metrics.ConfigReloadsTotal.WithLabelValues("rejected").Inc()
+			metrics.ConfigLastReloadSuccess.Set(0)
+			return
+		}
+
+		router.SyncBacks(newBackends(params.Backends))
+		for name, backends := range params.Pools {
+			pool, ok := router.Pool(name)
+			if !ok {
+				logger.Log.Warn(messages.ErrPoolNotReloaded, zap.String(messages.Pool, name))
+				continue
+			}
+			pool.SyncBacks(newBackends(backends))
+		}
+		for name, split := range params.Splits {
+			if pool, ok := router.Pool(name); ok {
+				pool.SetSplit(split)
+			}
+		}
+		ticker.Reset(time.Duration(params.Interval) * time.Second)
+		rl.SetDefaults(params.MaxTokens, params.Rate)
+
+		logger.Log.Info(messages.InfoConfigReloaded)
+		metrics.ConfigReloadsTotal.WithLabelValues("success").Inc()
+		metrics.ConfigLastReloadSuccess.Set(1)
+	}
+}
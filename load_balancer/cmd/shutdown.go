@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"load_balancer/balancer"
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/handler"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/tracing"
+
+	"go.uber.org/zap"
+)
+
+// интервал опроса числа проксируемых запросов при остановке
+const drainPoll = 100 * time.Millisecond
+
+// плавная остановка: /ready начинает отвечать 503, через readinessDelay серверы перестают
+// принимать соединения, проксируемые запросы дорабатывают не дольше timeout,
+// после чего WebSocket-соединения закрываются кадром 1001
+func drain(cfg configloading.ShutdownConfig, lb balancer.BalancerIface, readiness *handler.ReadinessHandler, servers ...*http.Server) {
+	readiness.Drain()
+	time.Sleep(cfg.ReadinessDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		if server == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				logger.Log.Error(messages.ErrShutdown, zap.Error(err))
+				server.Close() //nolint:errcheck
+			}
+		}()
+	}
+
+	waitInFlight(ctx, lb)
+	wg.Wait()
+
+	wsCtx, wsCancel := context.WithTimeout(context.Background(), cfg.WebSocketGrace)
+	defer wsCancel()
+	if n := lb.CloseWebSockets(wsCtx); n > 0 {
+		logger.Log.Info(messages.InfoWebSocketsClosed, zap.Int(messages.Count, n))
+	}
+}
+
+// ожидание завершения проксируемых запросов (без WebSocket) до истечения ctx
+func waitInFlight(ctx context.Context, lb balancer.BalancerIface) {
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+
+	for {
+		n := lb.InFlight()
+		if n <= 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Log.Warn(messages.InfoDrainTimeout, zap.Int64(messages.InFlight, n))
+			return
+		case <-ticker.C:
+			logger.Log.Info(messages.InfoDraining, zap.Int64(messages.InFlight, n))
+		}
+	}
+}
+
+// отправка в коллектор операций, завершённых до остановки
+func flushTraces(exporter *tracing.Exporter, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := exporter.Shutdown(ctx); err != nil {
+		logger.Log.Warn(messages.ErrExportSpans, zap.Error(err))
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	tlsconfig "load_balancer/tls_config"
+
+	"go.uber.org/zap"
+)
+
+// HTTPS-сервер с HSTS и HTTP/2; сертификат перечитывается по SIGHUP
+func newTLSServer(ctx context.Context, cfg configloading.TLSConfig, h http.Handler) (*http.Server, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New(messages.ErrNoTLSCert)
+	}
+
+	certs, err := tlsconfig.NewCertReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	go reloadCertOnHUP(ctx, certs)
+
+	return &http.Server{
+		Addr:      cfg.Address,
+		Handler:   tlsconfig.HSTS(cfg.HSTSMaxAge, cfg.HSTSSubdomains, h),
+		TLSConfig: tlsconfig.ServerConfig(certs),
+	}, nil
+}
+
+// перечитывание сертификата по SIGHUP до завершения работы
+func reloadCertOnHUP(ctx context.Context, certs *tlsconfig.CertReloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := certs.Reload(); err != nil {
+				logger.Log.Error(messages.ErrCertReload, zap.Error(err))
+				continue
+			}
+			logger.Log.Info(messages.InfoCertReloaded)
+		}
+	}
+}
+
+// обработчик HTTP-адреса при включённом TLS: редирект на HTTPS, кроме /metrics и /ready,
+// которые Prometheus и проверки готовности продолжают запрашивать по HTTP
+func plainHandler(cfg configloading.TLSConfig, h http.Handler) http.Handler {
+	if !cfg.RedirectHTTP {
+		return h
+	}
+
+	_, port, _ := net.SplitHostPort(cfg.Address)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", h)
+	mux.Handle("/ready", h)
+	mux.Handle("/", tlsconfig.RedirectHandler(port))
+	return mux
+}
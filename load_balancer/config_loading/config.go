@@ -2,22 +2,235 @@ package configloading
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
+	"load_balancer/breaker"
+	"load_balancer/cache"
 
 	"load_balancer/internal/messages"
+	ratelimiter "load_balancer/rate_limiter"
+	"load_balancer/tracing"
 
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
 )
 
 const (
 	ServerAddr   = "server.address"
 	BackendAddrs = "backends"
+	Strategy     = "strategy"
 	Interval     = "interval"
 	DBAddr       = "db.address"
 	MaxTokens    = "maxTokens"
 	Rate         = "rate"
 	Salt         = "salt"
+
+	TrustedProxies = "proxy.trusted"
+	ProxyHeader    = "proxy.header"
+
+	LimiterAlgorithm = "limiter.algorithm"
+	LimiterStorage   = "limiter.storage"
+	LimiterTTL       = "limiter.ttl"
+	LimiterOnFailure = "limiter.onRedisFailure"
+	LimiterRetry     = "limiter.redisRetry"
+	LimiterPolicies  = "limiter.policies"
+	LimiterSession   = "limiter.sessionCookie"
+	LimiterAllowlist = "limiter.allowlist"
+	LimiterDenylist  = "limiter.denylist"
+
+	SheddingEnabled          = "shedding.enabled"
+	SheddingInitialLimit     = "shedding.initialLimit"
+	SheddingMinLimit         = "shedding.minLimit"
+	SheddingMaxLimit         = "shedding.maxLimit"
+	SheddingLatencyThreshold = "shedding.latencyThreshold"
+	SheddingBackoff          = "shedding.backoff"
+	SheddingDefaultShare     = "shedding.defaultShare"
+	SheddingRetryAfter       = "shedding.retryAfter"
+	SheddingClasses          = "shedding.classes"
+
+	AdminToken    = "admin.token"
+	AdminAddr     = "admin.address"
+	AdminCert     = "admin.tls.cert"
+	AdminKey      = "admin.tls.key"
+	AdminClientCA = "admin.tls.clientCA"
+
+	TLSEnabled        = "tls.enabled"
+	TLSAddr           = "tls.address"
+	TLSCert           = "tls.cert"
+	TLSKey            = "tls.key"
+	TLSRedirect       = "tls.redirectHTTP"
+	TLSHSTSMaxAge     = "tls.hsts.maxAge"
+	TLSHSTSSubdomains = "tls.hsts.includeSubdomains"
+	TLSBackendCA      = "tls.backendCA"
+
+	CacheEnabled       = "cache.enabled"
+	CacheMaxSize       = "cache.maxSize"
+	CacheMaxObjectSize = "cache.maxObjectSize"
+
+	CompressionEnabled = "compression.enabled"
+	CompressionMinSize = "compression.minSize"
+	CompressionTypes   = "compression.types"
+	CompressionSkip    = "compression.skip"
+
+	ShutdownReadinessDelay = "shutdown.readinessDelay"
+	ShutdownTimeout        = "shutdown.timeout"
+	ShutdownWebSocketGrace = "shutdown.websocketGrace"
+
+	TracingEndpoint    = "tracing.endpoint"
+	TracingServiceName = "tracing.serviceName"
+	TracingBatchSize   = "tracing.batchSize"
+	TracingInterval    = "tracing.interval"
+
+	AffinityEnabled   = "affinity.enabled"
+	AffinityKeyCookie = "affinity.keyCookie"
+	AffinityCookie    = "affinity.cookie"
+
+	RetryBufferBody  = "retry.bufferBody"
+	RetryMaxBodySize = "retry.maxBodySize"
+	RetryAttempts    = "retry.attempts"
+
+	HedgeEnabled    = "hedge.enabled"
+	HedgePrefixes   = "hedge.prefixes"
+	HedgePercentile = "hedge.percentile"
+	HedgeMinDelay   = "hedge.minDelay"
+	HedgeBudget     = "hedge.budget"
+	HedgeWindow     = "hedge.window"
+
+	Pools  = "pools"
+	Routes = "routes"
+
+	MirrorEnabled     = "mirror.enabled"
+	MirrorPool        = "mirror.pool"
+	MirrorPercent     = "mirror.percent"
+	MirrorHeader      = "mirror.header"
+	MirrorMethods     = "mirror.methods"
+	MirrorMaxBodySize = "mirror.maxBodySize"
+	MirrorTimeout     = "mirror.timeout"
+	MirrorConcurrency = "mirror.concurrency"
+
+	SplitWeights   = "split.weights"
+	SplitCanary    = "split.canary"
+	SplitStable    = "split.stable"
+	SplitHeader    = "split.header"
+	SplitCookie    = "split.cookie"
+	SplitKeyCookie = "split.keyCookie"
+
+	HealthRise             = "health.rise"
+	HealthFall             = "health.fall"
+	HealthTimeout          = "health.timeout"
+	HealthJitter           = "health.jitter"
+	HealthPath             = "health.path"
+	HealthStatus           = "health.status"
+	HealthEjectThreshold   = "health.outlier.threshold"
+	HealthEjectMinRequests = "health.outlier.minRequests"
+	HealthEjectBase        = "health.outlier.baseEjection"
+	HealthEjectMax         = "health.outlier.maxEjection"
+
+	BreakerEnabled        = "breaker.enabled"
+	BreakerErrorRate      = "breaker.errorRate"
+	BreakerMinRequests    = "breaker.minRequests"
+	BreakerWindow         = "breaker.window"
+	BreakerSlowThreshold  = "breaker.slowThreshold"
+	BreakerOpenTimeout    = "breaker.openTimeout"
+	BreakerHalfOpenProbes = "breaker.halfOpenProbes"
 )
 
+// PolicyConfig - параметры политики ограничения запросов из списка limiter.policies
+type PolicyConfig struct {
+	Name      string
+	Prefix    string
+	Methods   []string
+	Key       string // ip или session
+	MaxTokens int
+	Rate      int
+}
+
+// строгие лимиты на вход и регистрацию против перебора паролей и массовой регистрации
+var defaultPolicies = []interface{}{
+	map[string]interface{}{"name": "login", "prefix": "/api/login", "methods": "POST", "maxTokens": 5, "rate": 60},
+	map[string]interface{}{"name": "register", "prefix": "/api/register", "methods": "POST", "maxTokens": 3, "rate": 600},
+}
+
+// вход и проверки здоровья сбрасываются последними: без входа пользователи не вернутся,
+// без проверок оркестратор сочтёт реплику мёртвой
+var defaultClasses = []interface{}{
+	map[string]interface{}{"name": "critical", "prefixes": []string{"/api/login", "/health"}, "share": 1},
+}
+
+// PriorityClass - класс приоритета: запросам класса доступна доля Share адаптивного
+// предела, поэтому при перегрузке первыми сбрасываются классы с меньшей долей
+type PriorityClass struct {
+	Name     string
+	Prefixes []string // префиксы пути
+	Share    float64  // доля предела от 0 до 1
+}
+
+// SheddingConfig - параметры адаптивного предела одновременных запросов и классов приоритета
+type SheddingConfig struct {
+	Limit        ratelimiter.AdaptiveConfig
+	Classes      []PriorityClass
+	DefaultShare float64       // доля предела для запросов вне классов
+	RetryAfter   time.Duration // значение Retry-After в ответе 503
+}
+
+// MirrorConfig - копирование доли запросов в теневой пул для проверки новой сборки
+// на реальном трафике
+type MirrorConfig struct {
+	Pool        string        // теневой пул
+	Percent     float64       // доля копируемых запросов в процентах
+	Header      string        // заголовок, которым помечаются копии
+	Methods     []string      // копируемые методы, пусто - любые
+	MaxBodySize int64         // запросы с телом больше лимита не копируются
+	Timeout     time.Duration // предельное время ответа теневого пула
+	Concurrency int           // предел одновременных копий, лишние не отправляются
+}
+
+// AdminConfig - параметры админского API
+type AdminConfig struct {
+	Token        string // токен для Authorization: Bearer, пусто - только mTLS
+	Address      string // отдельный адрес, пусто - на основном сервере
+	CertFile     string // сертификат и ключ TLS отдельного адреса
+	KeyFile      string
+	ClientCAFile string // CA клиентских сертификатов для mTLS
+}
+
+// TLSConfig - параметры HTTPS основного сервера и TLS до серверов
+type TLSConfig struct {
+	Enabled        bool
+	Address        string // адрес HTTPS, server.address остаётся для HTTP
+	CertFile       string
+	KeyFile        string
+	RedirectHTTP   bool          // HTTP перенаправляет на HTTPS
+	HSTSMaxAge     time.Duration // 0 - без Strict-Transport-Security
+	HSTSSubdomains bool
+	BackendCAFile  string // CA серверов по HTTPS, пусто - только системные
+}
+
+// HealthConfig - параметры активной и пассивной проверки серверов
+type HealthConfig struct {
+	Rise    int           // успешных проверок подряд, чтобы вернуть сервер в работу
+	Fall    int           // неудачных проверок подряд, чтобы вывести сервер из работы
+	Timeout time.Duration // таймаут одной проверки
+	Jitter  time.Duration // случайная задержка перед проверкой, чтобы не бить по всем серверам разом
+	Path    string        // путь проверки по умолчанию
+	Status  int           // ожидаемый код ответа по умолчанию
+
+	EjectThreshold   float64       // доля 5xx за интервал, при которой сервер исключается
+	EjectMinRequests int           // минимум запросов за интервал для оценки доли 5xx
+	EjectBase        time.Duration // время первого исключения, каждое следующее вдвое дольше
+	EjectMax         time.Duration // максимальное время исключения
+}
+
+// BackendConfig - параметры сервера из списка backends
+type BackendConfig struct {
+	URL          string
+	Weight       int
+	HealthPath   string // путь проверки сервера, пусто - health.path
+	HealthStatus int    // ожидаемый код ответа проверки, 0 - health.status
+	Group        string // группа для разделения трафика, пусто - stable
+}
+
 func LoadConfig() error {
 	viper.SetConfigFile("./config/config.yaml")
 	err := viper.ReadInConfig()
@@ -29,13 +242,404 @@ func LoadConfig() error {
 	return nil
 }
 
-func SetParams() (serverAddr string, backendAddrs []string, interval int, dbAddr string, salt string, maxTokens, rate int) {
+func SetParams() (serverAddr string, interval int, salt string) {
 	serverAddr = viper.GetString(ServerAddr)
-	backendAddrs = viper.GetStringSlice(BackendAddrs)
 	interval = viper.GetInt(Interval)
-	dbAddr = viper.GetString(DBAddr)
 	salt = viper.GetString(Salt)
-	maxTokens = viper.GetInt(MaxTokens)
-	rate = viper.GetInt(Rate)
-	return serverAddr, backendAddrs, interval, dbAddr, salt, maxTokens, rate
+	return serverAddr, interval, salt
+}
+
+// параметры ограничителя запросов
+func LimiterParams() ratelimiter.Config {
+	viper.SetDefault(LimiterAlgorithm, ratelimiter.TokenBucket)
+	viper.SetDefault(LimiterStorage, ratelimiter.StorageRedis)
+	viper.SetDefault(LimiterTTL, time.Hour)
+	viper.SetDefault(LimiterOnFailure, ratelimiter.FailoverMemory)
+	viper.SetDefault(LimiterRetry, 5*time.Second)
+
+	return ratelimiter.Config{
+		Algorithm: viper.GetString(LimiterAlgorithm),
+		Storage:   viper.GetString(LimiterStorage),
+		RedisAddr: viper.GetString(DBAddr),
+		MaxTokens: viper.GetInt(MaxTokens),
+		Rate:      viper.GetInt(Rate),
+
+		TTL:            viper.GetDuration(LimiterTTL),
+		OnRedisFailure: viper.GetString(LimiterOnFailure),
+		RedisRetry:     viper.GetDuration(LimiterRetry),
+	}
+}
+
+// таблица политик ограничения запросов и cookie сессии для политик с key: session
+func PolicyParams() (policies []PolicyConfig, sessionCookie string) {
+	viper.SetDefault(LimiterPolicies, defaultPolicies)
+	viper.SetDefault(LimiterSession, "authToken")
+
+	return parsePolicies(viper.Get(LimiterPolicies)), viper.GetString(LimiterSession)
+}
+
+// параметры сброса нагрузки по адаптивному пределу одновременных запросов
+func SheddingParams() (enabled bool, cfg SheddingConfig) {
+	viper.SetDefault(SheddingInitialLimit, 50)
+	viper.SetDefault(SheddingMinLimit, 5)
+	viper.SetDefault(SheddingMaxLimit, 500)
+	viper.SetDefault(SheddingLatencyThreshold, time.Second)
+	viper.SetDefault(SheddingBackoff, 0.9)
+	viper.SetDefault(SheddingDefaultShare, 0.8)
+	viper.SetDefault(SheddingRetryAfter, time.Second)
+	viper.SetDefault(SheddingClasses, defaultClasses)
+
+	enabled = viper.GetBool(SheddingEnabled)
+	cfg = SheddingConfig{
+		Limit: ratelimiter.AdaptiveConfig{
+			InitialLimit:     viper.GetInt(SheddingInitialLimit),
+			MinLimit:         viper.GetInt(SheddingMinLimit),
+			MaxLimit:         viper.GetInt(SheddingMaxLimit),
+			LatencyThreshold: viper.GetDuration(SheddingLatencyThreshold),
+			Backoff:          viper.GetFloat64(SheddingBackoff),
+		},
+		Classes:      parseClasses(viper.Get(SheddingClasses)),
+		DefaultShare: viper.GetFloat64(SheddingDefaultShare),
+		RetryAfter:   viper.GetDuration(SheddingRetryAfter),
+	}
+	return enabled, cfg
+}
+
+// постоянные записи allowlist и denylist лимитера (IP или подсети)
+func ACLParams() (allow, deny []string) {
+	return viper.GetStringSlice(LimiterAllowlist), viper.GetStringSlice(LimiterDenylist)
+}
+
+// параметры админского API
+func AdminParams() AdminConfig {
+	return AdminConfig{
+		Token:        viper.GetString(AdminToken),
+		Address:      viper.GetString(AdminAddr),
+		CertFile:     viper.GetString(AdminCert),
+		KeyFile:      viper.GetString(AdminKey),
+		ClientCAFile: viper.GetString(AdminClientCA),
+	}
+}
+
+// доверенные прокси перед балансировщиком (IP и подсети CIDR) и заголовок их цепочки адресов
+func TrustedProxiesParams() (trusted []string, header string) {
+	viper.SetDefault(ProxyHeader, "x-forwarded-for")
+	return viper.GetStringSlice(TrustedProxies), viper.GetString(ProxyHeader)
+}
+
+// параметры TLS
+func TLSParams() TLSConfig {
+	viper.SetDefault(TLSAddr, ":443")
+	viper.SetDefault(TLSRedirect, true)
+	viper.SetDefault(TLSHSTSMaxAge, 365*24*time.Hour)
+
+	return TLSConfig{
+		Enabled:        viper.GetBool(TLSEnabled),
+		Address:        viper.GetString(TLSAddr),
+		CertFile:       viper.GetString(TLSCert),
+		KeyFile:        viper.GetString(TLSKey),
+		RedirectHTTP:   viper.GetBool(TLSRedirect),
+		HSTSMaxAge:     viper.GetDuration(TLSHSTSMaxAge),
+		HSTSSubdomains: viper.GetBool(TLSHSTSSubdomains),
+		BackendCAFile:  viper.GetString(TLSBackendCA),
+	}
+}
+
+// параметры кэша ответов
+func CacheParams() (enabled bool, cfg cache.Config) {
+	viper.SetDefault(CacheMaxSize, 64<<20)
+	viper.SetDefault(CacheMaxObjectSize, 1<<20)
+
+	enabled = viper.GetBool(CacheEnabled)
+	cfg = cache.Config{
+		MaxSize:       viper.GetInt64(CacheMaxSize),
+		MaxObjectSize: viper.GetInt64(CacheMaxObjectSize),
+	}
+	return enabled, cfg
+}
+
+// CompressionConfig - параметры сжатия ответов
+type CompressionConfig struct {
+	MinSize      int      // ответы меньше не сжимаются
+	ContentTypes []string // сжимаемые типы; "text/*" - все подтипы
+	SkipPrefixes []string // пути, ответы на которые не трогаем
+}
+
+// параметры сжатия ответов; скачиваемые файлы заданий и решений уже сжаты
+func CompressionParams() (enabled bool, cfg CompressionConfig) {
+	viper.SetDefault(CompressionEnabled, true)
+	viper.SetDefault(CompressionMinSize, 1024)
+	viper.SetDefault(CompressionTypes, []string{
+		"text/*", "application/json", "application/javascript", "image/svg+xml",
+	})
+	viper.SetDefault(CompressionSkip, []string{"/api/download-task", "/api/download-solution"})
+
+	enabled = viper.GetBool(CompressionEnabled)
+	cfg = CompressionConfig{
+		MinSize:      viper.GetInt(CompressionMinSize),
+		ContentTypes: viper.GetStringSlice(CompressionTypes),
+		SkipPrefixes: viper.GetStringSlice(CompressionSkip),
+	}
+	return enabled, cfg
+}
+
+// ShutdownConfig - параметры плавной остановки
+type ShutdownConfig struct {
+	ReadinessDelay time.Duration // от провала /ready до закрытия приёма соединений
+	Timeout        time.Duration // предельное ожидание проксируемых запросов
+	WebSocketGrace time.Duration // ожидание ответа клиента на кадр закрытия WebSocket
+}
+
+// параметры плавной остановки
+func ShutdownParams() ShutdownConfig {
+	viper.SetDefault(ShutdownReadinessDelay, 2*time.Second)
+	viper.SetDefault(ShutdownTimeout, 15*time.Second)
+	viper.SetDefault(ShutdownWebSocketGrace, 2*time.Second)
+
+	return ShutdownConfig{
+		ReadinessDelay: viper.GetDuration(ShutdownReadinessDelay),
+		Timeout:        viper.GetDuration(ShutdownTimeout),
+		WebSocketGrace: viper.GetDuration(ShutdownWebSocketGrace),
+	}
+}
+
+// параметры экспорта трасс; без адреса коллектора трассы только передаются дальше в traceparent
+func TracingParams() (enabled bool, cfg tracing.Config) {
+	viper.SetDefault(TracingServiceName, "load_balancer")
+	viper.SetDefault(TracingBatchSize, 128)
+	viper.SetDefault(TracingInterval, 5*time.Second)
+
+	cfg = tracing.Config{
+		Endpoint:    viper.GetString(TracingEndpoint),
+		ServiceName: viper.GetString(TracingServiceName),
+		BatchSize:   viper.GetInt(TracingBatchSize),
+		Interval:    viper.GetDuration(TracingInterval),
+	}
+	return cfg.Endpoint != "", cfg
+}
+
+// параметры копирования трафика в теневой пул; без пула копирование выключено
+func MirrorParams() (enabled bool, cfg MirrorConfig) {
+	viper.SetDefault(MirrorPercent, 1)
+	viper.SetDefault(MirrorHeader, "X-Mirrored")
+	viper.SetDefault(MirrorMethods, []string{"GET", "HEAD"})
+	viper.SetDefault(MirrorMaxBodySize, 1<<20)
+	viper.SetDefault(MirrorTimeout, 5*time.Second)
+	viper.SetDefault(MirrorConcurrency, 64)
+
+	cfg = MirrorConfig{
+		Pool:        strings.ToLower(viper.GetString(MirrorPool)),
+		Percent:     viper.GetFloat64(MirrorPercent),
+		Header:      viper.GetString(MirrorHeader),
+		Methods:     viper.GetStringSlice(MirrorMethods),
+		MaxBodySize: viper.GetInt64(MirrorMaxBodySize),
+		Timeout:     viper.GetDuration(MirrorTimeout),
+		Concurrency: viper.GetInt(MirrorConcurrency),
+	}
+	return viper.GetBool(MirrorEnabled) && cfg.Pool != "", cfg
+}
+
+// параметры привязки клиента к серверу
+func AffinityParams() (enabled bool, keyCookie, cookie string) {
+	viper.SetDefault(AffinityKeyCookie, "authToken")
+	viper.SetDefault(AffinityCookie, "lb_affinity")
+
+	enabled = viper.GetBool(AffinityEnabled)
+	keyCookie = viper.GetString(AffinityKeyCookie)
+	cookie = viper.GetString(AffinityCookie)
+	return enabled, keyCookie, cookie
+}
+
+// параметры повторных попыток: буферизация тела запроса и число попыток
+func RetryParams() RetryConfig {
+	return retryParams(sameKey)
+}
+
+func retryParams(key func(string) string) RetryConfig {
+	viper.SetDefault(RetryBufferBody, true)
+	viper.SetDefault(RetryMaxBodySize, 10<<20)
+
+	return RetryConfig{
+		BufferBody:  viper.GetBool(key(RetryBufferBody)),
+		MaxBodySize: viper.GetInt64(key(RetryMaxBodySize)),
+		Attempts:    viper.GetInt(key(RetryAttempts)),
+	}
+}
+
+// параметры запасных запросов для GET; выключенные - нулевой HedgeConfig
+func hedgeParams(key func(string) string) HedgeConfig {
+	viper.SetDefault(HedgePercentile, 95)
+	viper.SetDefault(HedgeMinDelay, 20*time.Millisecond)
+	viper.SetDefault(HedgeBudget, 0.1)
+	viper.SetDefault(HedgeWindow, 1000)
+
+	if !viper.GetBool(key(HedgeEnabled)) {
+		return HedgeConfig{}
+	}
+	return HedgeConfig{
+		Prefixes:   viper.GetStringSlice(key(HedgePrefixes)),
+		Percentile: viper.GetFloat64(key(HedgePercentile)),
+		MinDelay:   viper.GetDuration(key(HedgeMinDelay)),
+		Budget:     viper.GetFloat64(key(HedgeBudget)),
+		Window:     viper.GetInt(key(HedgeWindow)),
+	}
+}
+
+// параметры активной и пассивной проверки серверов
+func HealthParams() HealthConfig {
+	return healthParams(sameKey)
+}
+
+func healthParams(key func(string) string) HealthConfig {
+	viper.SetDefault(HealthRise, 1)
+	viper.SetDefault(HealthFall, 1)
+	viper.SetDefault(HealthTimeout, 2*time.Second)
+	viper.SetDefault(HealthPath, "/health")
+	viper.SetDefault(HealthStatus, 200)
+	viper.SetDefault(HealthEjectMinRequests, 10)
+	viper.SetDefault(HealthEjectBase, 30*time.Second)
+	viper.SetDefault(HealthEjectMax, 5*time.Minute)
+
+	return HealthConfig{
+		Rise:             viper.GetInt(key(HealthRise)),
+		Fall:             viper.GetInt(key(HealthFall)),
+		Timeout:          viper.GetDuration(key(HealthTimeout)),
+		Jitter:           viper.GetDuration(key(HealthJitter)),
+		Path:             viper.GetString(key(HealthPath)),
+		Status:           viper.GetInt(key(HealthStatus)),
+		EjectThreshold:   viper.GetFloat64(key(HealthEjectThreshold)),
+		EjectMinRequests: viper.GetInt(key(HealthEjectMinRequests)),
+		EjectBase:        viper.GetDuration(key(HealthEjectBase)),
+		EjectMax:         viper.GetDuration(key(HealthEjectMax)),
+	}
+}
+
+// параметры предохранителя серверов
+func BreakerParams() (enabled bool, cfg breaker.Config) {
+	viper.SetDefault(BreakerErrorRate, 0.5)
+	viper.SetDefault(BreakerMinRequests, 20)
+	viper.SetDefault(BreakerWindow, 10*time.Second)
+	viper.SetDefault(BreakerOpenTimeout, 30*time.Second)
+	viper.SetDefault(BreakerHalfOpenProbes, 3)
+
+	enabled = viper.GetBool(BreakerEnabled)
+	cfg = breaker.Config{
+		ErrorRate:      viper.GetFloat64(BreakerErrorRate),
+		MinRequests:    viper.GetInt(BreakerMinRequests),
+		Window:         viper.GetDuration(BreakerWindow),
+		SlowThreshold:  viper.GetDuration(BreakerSlowThreshold),
+		OpenTimeout:    viper.GetDuration(BreakerOpenTimeout),
+		HalfOpenProbes: viper.GetInt(BreakerHalfOpenProbes),
+	}
+	return enabled, cfg
+}
+
+// элемент списка shedding.classes - объект {name, prefixes, share}, prefixes - строка
+// или список; доля ограничивается отрезком [0, 1], классы без префиксов пропускаются
+func parseClasses(raw interface{}) []PriorityClass {
+	var classes []PriorityClass
+
+	for _, item := range cast.ToSlice(raw) {
+		m, err := cast.ToStringMapE(item)
+		if err != nil {
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			fields[strings.ToLower(k)] = v
+		}
+
+		class := PriorityClass{
+			Name:  cast.ToString(fields["name"]),
+			Share: min(max(cast.ToFloat64(fields["share"]), 0), 1),
+		}
+		if prefixes, ok := fields["prefixes"].(string); ok {
+			class.Prefixes = strings.Fields(strings.ReplaceAll(prefixes, ",", " "))
+		} else {
+			class.Prefixes = cast.ToStringSlice(fields["prefixes"])
+		}
+		if class.Name == "" && len(class.Prefixes) > 0 {
+			class.Name = class.Prefixes[0]
+		}
+
+		if len(class.Prefixes) > 0 {
+			classes = append(classes, class)
+		}
+	}
+
+	return classes
+}
+
+// элемент списка backends - либо строка с адресом, либо объект {url, weight, healthPath, healthStatus, group}
+func parseBackends(raw interface{}) []BackendConfig {
+	var backends []BackendConfig
+
+	for _, item := range cast.ToSlice(raw) {
+		cfg := BackendConfig{Weight: 1}
+
+		if m, err := cast.ToStringMapE(item); err == nil {
+			// viper приводит ключи вложенных объектов к нижнему регистру
+			fields := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				fields[strings.ToLower(k)] = v
+			}
+
+			cfg.URL = cast.ToString(fields["url"])
+			if w := cast.ToInt(fields["weight"]); w > 0 {
+				cfg.Weight = w
+			}
+			cfg.HealthPath = cast.ToString(fields["healthpath"])
+			cfg.HealthStatus = cast.ToInt(fields["healthstatus"])
+			cfg.Group = strings.ToLower(cast.ToString(fields["group"]))
+		} else {
+			cfg.URL = cast.ToString(item)
+		}
+
+		if cfg.URL != "" {
+			backends = append(backends, cfg)
+		}
+	}
+
+	return backends
+}
+
+// элемент списка limiter.policies - объект {name, prefix, methods, key, maxTokens, rate},
+// methods - строка или список; политики без префикса или с неположительными лимитами пропускаются
+func parsePolicies(raw interface{}) []PolicyConfig {
+	var policies []PolicyConfig
+
+	for _, item := range cast.ToSlice(raw) {
+		m, err := cast.ToStringMapE(item)
+		if err != nil {
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			fields[strings.ToLower(k)] = v
+		}
+
+		cfg := PolicyConfig{
+			Name:      cast.ToString(fields["name"]),
+			Prefix:    cast.ToString(fields["prefix"]),
+			Key:       cast.ToString(fields["key"]),
+			MaxTokens: cast.ToInt(fields["maxtokens"]),
+			Rate:      cast.ToInt(fields["rate"]),
+		}
+		if methods, ok := fields["methods"].(string); ok {
+			cfg.Methods = strings.Fields(strings.ReplaceAll(methods, ",", " "))
+		} else {
+			cfg.Methods = cast.ToStringSlice(fields["methods"])
+		}
+		if cfg.Name == "" {
+			cfg.Name = cfg.Prefix
+		}
+
+		if cfg.Prefix != "" && cfg.MaxTokens > 0 && cfg.Rate > 0 {
+			policies = append(policies, cfg)
+		}
+	}
+
+	return policies
 }
@@ -0,0 +1,67 @@
+package configloading
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPolicyParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []PolicyConfig
+	}{
+		{name: "defaults", want: []PolicyConfig{
+			{Name: "login", Prefix: "/api/login", Methods: []string{"POST"}, MaxTokens: 5, Rate: 60},
+			{Name: "register", Prefix: "/api/register", Methods: []string{"POST"}, MaxTokens: 3, Rate: 600},
+		}},
+		{name: "own table", config: `
+limiter:
+  policies:
+    - name: tasks
+      prefix: /api/tasks
+      methods: GET, POST
+      key: session
+      maxTokens: 20
+      rate: 1
+    - prefix: /api/upload
+      methods: [PUT]
+      maxTokens: 2
+      rate: 30
+`, want: []PolicyConfig{
+			{Name: "tasks", Prefix: "/api/tasks", Methods: []string{"GET", "POST"}, Key: "session", MaxTokens: 20, Rate: 1},
+			{Name: "/api/upload", Prefix: "/api/upload", Methods: []string{"PUT"}, MaxTokens: 2, Rate: 30},
+		}},
+		{name: "incomplete policies are skipped", config: `
+limiter:
+  policies:
+    - name: no-prefix
+      maxTokens: 1
+      rate: 1
+    - name: no-limit
+      prefix: /api/x
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			viper.SetConfigType("yaml")
+			if err := viper.ReadConfig(strings.NewReader(tt.config)); err != nil {
+				t.Fatal(err)
+			}
+
+			policies, cookie := PolicyParams()
+			if !reflect.DeepEqual(policies, tt.want) {
+				t.Fatalf("policies %+v, want %+v", policies, tt.want)
+			}
+			if cookie != "authToken" {
+				t.Fatalf("session cookie %q, want authToken", cookie)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package configloading
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"load_balancer/backend"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// имя пула из параметров верхнего уровня (backends, strategy, health, retry)
+const DefaultPool = "default"
+
+// SplitConfig - весовое разделение трафика между группами серверов пула
+// (выкатка новой версии на долю запросов)
+type SplitConfig struct {
+	Weights   map[string]int // группа -> вес; группы без веса получают запросы только принудительно
+	Canary    string         // группа для значения always
+	Stable    string         // группа для значения never
+	Header    string         // заголовок принудительного выбора, например X-Canary
+	Cookie    string         // cookie принудительного выбора
+	KeyCookie string         // cookie, по хешу которой клиент закрепляется за группой; без неё - IP
+}
+
+// HedgeConfig - запасные запросы для GET: если сервер не ответил за время Percentile-го
+// процентиля ответов, тот же запрос уходит на другой сервер, клиент получает первый ответ
+type HedgeConfig struct {
+	Prefixes   []string      // пути, GET на которые дублируются; пусто - любые
+	Percentile float64       // процентиль времени ответа, после которого уходит запасной запрос
+	MinDelay   time.Duration // задержка не меньше, пока ответов мало или они слишком быстрые
+	Budget     float64       // доля запросов, которые можно продублировать
+	Window     int           // сколько последних ответов учитывается в процентиле
+}
+
+// RetryConfig - параметры повторных попыток
+type RetryConfig struct {
+	BufferBody  bool  // буферизовать тело запроса, чтобы повторять POST
+	MaxBodySize int64 // максимальный размер буферизуемого тела в байтах
+	Attempts    int   // попыток на запрос, 0 - по числу серверов пула
+}
+
+// PoolConfig - пул серверов со своими стратегией, проверкой, повторами и разделением трафика
+type PoolConfig struct {
+	Name     string
+	Backends []BackendConfig
+	Strategy string
+	Health   HealthConfig
+	Retry    RetryConfig
+	Split    SplitConfig
+	Hedge    HedgeConfig
+}
+
+// RouteConfig - правило таблицы маршрутизации
+type RouteConfig struct {
+	Pool    string
+	Prefix  string
+	Host    string
+	Methods []string
+}
+
+// ключ параметра без изменений - параметры верхнего уровня
+func sameKey(key string) string {
+	return key
+}
+
+// ключ параметра пула, если он задан в pools.<name>, иначе общий ключ верхнего уровня
+func poolKey(v *viper.Viper, name string) func(string) string {
+	return func(key string) string {
+		if k := Pools + "." + name + "." + key; v.IsSet(k) {
+			return k
+		}
+		return key
+	}
+}
+
+// пул по умолчанию из параметров верхнего уровня (backends, strategy, health, retry, split, hedge)
+func DefaultPoolParams() PoolConfig {
+	return PoolConfig{
+		Name:     DefaultPool,
+		Backends: parseBackends(viper.Get(BackendAddrs)),
+		Strategy: viper.GetString(Strategy),
+		Health:   healthParams(sameKey),
+		Retry:    retryParams(sameKey),
+		Split:    splitParams(viper.GetViper(), sameKey),
+		Hedge:    hedgeParams(sameKey),
+	}
+}
+
+// именованные пулы из pools; не заданные в пуле параметры берутся с верхнего уровня
+func PoolParams() []PoolConfig {
+	names := poolNames(viper.GetViper())
+
+	pools := make([]PoolConfig, 0, len(names))
+	for _, name := range names {
+		key := poolKey(viper.GetViper(), name)
+		pools = append(pools, PoolConfig{
+			Name:     name,
+			Backends: parseBackends(viper.Get(Pools + "." + name + "." + BackendAddrs)),
+			Strategy: viper.GetString(key(Strategy)),
+			Health:   healthParams(key),
+			Retry:    retryParams(key),
+			Split:    splitParams(viper.GetViper(), key),
+			Hedge:    hedgeParams(key),
+		})
+	}
+	return pools
+}
+
+// разделение трафика между группами серверов; v - конфиг, из которого читаются параметры
+// (при перезагрузке - новый файл)
+func splitParams(v *viper.Viper, key func(string) string) SplitConfig {
+	v.SetDefault(SplitCanary, "canary")
+	v.SetDefault(SplitStable, backend.DefaultGroup)
+	v.SetDefault(SplitHeader, "X-Canary")
+	v.SetDefault(SplitCookie, "canary")
+	v.SetDefault(SplitKeyCookie, "authToken")
+
+	weights := make(map[string]int)
+	for group, weight := range v.GetStringMap(key(SplitWeights)) {
+		weights[strings.ToLower(group)] = cast.ToInt(weight)
+	}
+
+	return SplitConfig{
+		Weights:   weights,
+		Canary:    strings.ToLower(v.GetString(key(SplitCanary))),
+		Stable:    strings.ToLower(v.GetString(key(SplitStable))),
+		Header:    v.GetString(key(SplitHeader)),
+		Cookie:    v.GetString(key(SplitCookie)),
+		KeyCookie: v.GetString(key(SplitKeyCookie)),
+	}
+}
+
+// разделение трафика всех пулов для перезагрузки конфига, включая пул по умолчанию
+func poolSplits(v *viper.Viper) map[string]SplitConfig {
+	splits := map[string]SplitConfig{DefaultPool: splitParams(v, sameKey)}
+	for _, name := range poolNames(v) {
+		splits[name] = splitParams(v, poolKey(v, name))
+	}
+	return splits
+}
+
+// таблица маршрутизации
+func RouteParams() []RouteConfig {
+	return parseRoutes(viper.Get(Routes))
+}
+
+// имена пулов по порядку; viper приводит их к нижнему регистру
+func poolNames(v *viper.Viper) []string {
+	names := make([]string, 0)
+	for name := range v.GetStringMap(Pools) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// списки серверов пулов для перезагрузки конфига
+func poolBackends(v *viper.Viper) map[string][]BackendConfig {
+	backends := make(map[string][]BackendConfig)
+	for _, name := range poolNames(v) {
+		backends[name] = parseBackends(v.Get(Pools + "." + name + "." + BackendAddrs))
+	}
+	return backends
+}
+
+// элемент списка routes - объект {pool, prefix, host, methods}, methods - строка или список;
+// правила без пула пропускаются
+func parseRoutes(raw interface{}) []RouteConfig {
+	var routes []RouteConfig
+
+	for _, item := range cast.ToSlice(raw) {
+		m, err := cast.ToStringMapE(item)
+		if err != nil {
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			fields[strings.ToLower(k)] = v
+		}
+
+		cfg := RouteConfig{
+			Pool:   strings.ToLower(cast.ToString(fields["pool"])),
+			Prefix: cast.ToString(fields["prefix"]),
+			Host:   cast.ToString(fields["host"]),
+		}
+		if methods, ok := fields["methods"].(string); ok {
+			cfg.Methods = strings.Fields(strings.ReplaceAll(methods, ",", " "))
+		} else {
+			cfg.Methods = cast.ToStringSlice(fields["methods"])
+		}
+
+		if cfg.Pool != "" {
+			routes = append(routes, cfg)
+		}
+	}
+
+	return routes
+}
@@ -0,0 +1,154 @@
+package configloading
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"load_balancer/backend"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// ReloadParams - параметры, которые применяются без перезапуска балансировщика
+type ReloadParams struct {
+	Backends  []BackendConfig
+	Pools     map[string][]BackendConfig // серверы именованных пулов
+	Routes    []RouteConfig
+	Splits    map[string]SplitConfig // разделение трафика по именам пулов
+	Interval  int
+	MaxTokens int
+	Rate      int
+}
+
+// WatchConfig - следить за файлом конфига и вызывать onReload при каждом его изменении;
+// err != nil - конфиг отклонён. Следим сами, а не через viper.WatchConfig: тот перечитывает
+// общий viper до проверки, и отклонённый конфиг всё равно подменил бы значения
+func WatchConfig(onReload func(params ReloadParams, err error)) error {
+	file := filepath.Clean(viper.ConfigFileUsed())
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// следим за каталогом: редакторы и ConfigMap в Kubernetes заменяют файл, а не пишут в него
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close() //nolint:errcheck
+		return err
+	}
+
+	go func() {
+		defer watcher.Close() //nolint:errcheck
+
+		realFile, _ := filepath.EvalSymlinks(file)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// ConfigMap подменяет символическую ссылку, сам файл при этом не меняется
+				current, _ := filepath.EvalSymlinks(file)
+				written := filepath.Clean(event.Name) == file && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+				if !written && (current == "" || current == realFile) {
+					continue
+				}
+				realFile = current
+				onReload(reloadFile(file))
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Log.Error(messages.ErrWatchConfig, zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+// перечитать конфиг отдельным экземпляром viper и проверить его;
+// в общий viper значения попадают только после успешной проверки
+func reloadFile(file string) (ReloadParams, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ReloadParams{}, fmt.Errorf(messages.ErrReadConfig, err)
+	}
+
+	v := viper.New()
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(file), "."))
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return ReloadParams{}, fmt.Errorf(messages.ErrReadConfig, err)
+	}
+
+	params := ReloadParams{
+		Backends:  parseBackends(v.Get(BackendAddrs)),
+		Pools:     poolBackends(v),
+		Routes:    parseRoutes(v.Get(Routes)),
+		Splits:    poolSplits(v),
+		Interval:  v.GetInt(Interval),
+		MaxTokens: v.GetInt(MaxTokens),
+		Rate:      v.GetInt(Rate),
+	}
+	if err := params.Validate(); err != nil {
+		return ReloadParams{}, err
+	}
+
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return ReloadParams{}, fmt.Errorf(messages.ErrReadConfig, err)
+	}
+	return params, nil
+}
+
+// Validate - проверка параметров перед применением
+func (p ReloadParams) Validate() error {
+	if len(p.Backends) == 0 {
+		return errors.New(messages.ErrNoBackendsInConfig)
+	}
+
+	if err := validateBackends(p.Backends); err != nil {
+		return err
+	}
+	for name, backends := range p.Pools {
+		if len(backends) == 0 {
+			return fmt.Errorf(messages.ErrEmptyPool, name)
+		}
+		if err := validateBackends(backends); err != nil {
+			return err
+		}
+	}
+
+	for _, route := range p.Routes {
+		if _, ok := p.Pools[route.Pool]; !ok && route.Pool != DefaultPool {
+			return fmt.Errorf(messages.ErrUnknownPool, route.Pool)
+		}
+	}
+
+	if p.Interval <= 0 {
+		return fmt.Errorf(messages.ErrNotPositive, Interval)
+	}
+	if p.MaxTokens <= 0 {
+		return fmt.Errorf(messages.ErrNotPositive, MaxTokens)
+	}
+	if p.Rate <= 0 {
+		return fmt.Errorf(messages.ErrNotPositive, Rate)
+	}
+
+	return nil
+}
+
+func validateBackends(backends []BackendConfig) error {
+	for _, b := range backends {
+		if !backend.ValidURL(b.URL) {
+			return fmt.Errorf(messages.ErrBadBackendInConfig, b.URL)
+		}
+	}
+	return nil
+}
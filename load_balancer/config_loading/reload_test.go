@@ -0,0 +1,185 @@
+package configloading
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"load_balancer/internal/logger"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() ReloadParams {
+		return ReloadParams{
+			Backends:  []BackendConfig{{URL: "http://api-1:8080", Weight: 1}},
+			Pools:     map[string][]BackendConfig{"chat": {{URL: "https://chat-1:8443", Weight: 1}}},
+			Routes:    []RouteConfig{{Pool: "chat", Prefix: "/ws"}, {Pool: "default", Prefix: "/"}},
+			Interval:  5,
+			MaxTokens: 10,
+			Rate:      1,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(p *ReloadParams)
+		err    string // "" - параметры верны
+	}{
+		{name: "valid", change: func(p *ReloadParams) {}},
+		{name: "no backends", change: func(p *ReloadParams) { p.Backends = nil }, err: "no backends"},
+		{name: "backend without scheme", change: func(p *ReloadParams) { p.Backends[0].URL = "api-1:8080" }, err: "invalid backend url"},
+		{name: "backend without host", change: func(p *ReloadParams) { p.Backends[0].URL = "http://" }, err: "invalid backend url"},
+		{name: "empty pool", change: func(p *ReloadParams) { p.Pools["chat"] = nil }, err: "pool chat has no backends"},
+		{name: "bad pool backend", change: func(p *ReloadParams) { p.Pools["chat"][0].URL = "ftp://chat-1" }, err: "invalid backend url"},
+		{name: "route to unknown pool", change: func(p *ReloadParams) { p.Routes[0].Pool = "files" }, err: "unknown pool: files"},
+		{name: "zero interval", change: func(p *ReloadParams) { p.Interval = 0 }, err: Interval},
+		{name: "negative tokens", change: func(p *ReloadParams) { p.MaxTokens = -1 }, err: MaxTokens},
+		{name: "zero rate", change: func(p *ReloadParams) { p.Rate = 0 }, err: Rate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.change(&p)
+			err := p.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+const reloadConfig = `
+backends:
+  - url: http://api-1:8080
+  - url: http://api-2:8080
+    weight: 3
+interval: %INTERVAL%
+maxTokens: 10
+rate: 1
+`
+
+// записать конфиг с заданным interval
+func writeConfig(t *testing.T, file, interval string) {
+	t.Helper()
+	data := strings.ReplaceAll(reloadConfig, "%INTERVAL%", interval)
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// файл конфига во временном каталоге, загруженный в общий viper
+func loadTestConfig(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, file, "5")
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigFile(file)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestReloadRejected(t *testing.T) {
+	file := loadTestConfig(t)
+
+	// отклонённый конфиг не трогает действующие значения
+	writeConfig(t, file, "0")
+	if _, err := reloadFile(file); err == nil {
+		t.Fatal("a config with zero interval must be rejected")
+	}
+	if got := viper.GetInt(Interval); got != 5 {
+		t.Fatalf("interval %d after a rejected reload, want 5", got)
+	}
+
+	if err := os.WriteFile(file, []byte("backends: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloadFile(file); err == nil {
+		t.Fatal("a malformed config must be rejected")
+	}
+	if got := viper.GetInt(Interval); got != 5 {
+		t.Fatalf("interval %d after a malformed reload, want 5", got)
+	}
+
+	// принятый конфиг попадает в общий viper
+	writeConfig(t, file, "7")
+	params, err := reloadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Interval != 7 || len(params.Backends) != 2 || params.Backends[1].Weight != 3 {
+		t.Fatalf("params %+v", params)
+	}
+	if got := viper.GetInt(Interval); got != 7 {
+		t.Fatalf("interval %d after a valid reload, want 7", got)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	file := loadTestConfig(t)
+
+	type reload struct {
+		params ReloadParams
+		err    error
+	}
+	reloads := make(chan reload, 16)
+	if err := WatchConfig(func(params ReloadParams, err error) {
+		reloads <- reload{params, err}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// запись может прийти несколькими событиями: ждём последнего результата
+	next := func() reload {
+		t.Helper()
+		var last reload
+		select {
+		case last = <-reloads:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reload after the config changed")
+		}
+		for {
+			select {
+			case last = <-reloads:
+			case <-time.After(200 * time.Millisecond):
+				return last
+			}
+		}
+	}
+
+	writeConfig(t, file, "0")
+	if got := next(); got.err == nil {
+		t.Fatal("a config with zero interval must be rejected")
+	}
+	if got := viper.GetInt(Interval); got != 5 {
+		t.Fatalf("interval %d after a rejected reload, want 5", got)
+	}
+
+	writeConfig(t, file, "9")
+	if got := next(); got.err != nil || got.params.Interval != 9 {
+		t.Fatalf("reload %+v, want interval 9", got)
+	}
+	if got := viper.GetInt(Interval); got != 9 {
+		t.Fatalf("interval %d after a valid reload, want 9", got)
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"load_balancer/backend"
+	"load_balancer/balancer"
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/internal/response"
+
+	"go.uber.org/zap"
+)
+
+type BackendsHandler struct {
+	Balancer balancer.RouterIface
+}
+
+// пул из параметра pool, без него - пул по умолчанию
+func (bh *BackendsHandler) pool(w http.ResponseWriter, r *http.Request) (balancer.BalancerIface, bool) {
+	name := r.URL.Query().Get("pool")
+	pool, ok := bh.Balancer.Pool(name)
+	if !ok {
+		err := fmt.Errorf(messages.ErrUnknownPool, name)
+		logger.Log.Info(err.Error())
+		response.WriteAPIResponse(w, http.StatusNotFound, false, err.Error(), nil)
+	}
+	return pool, ok
+}
+
+// обработчик получения списка серверов с их состоянием; pool - только серверы этого пула
+func (bh *BackendsHandler) ListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		states := bh.Balancer.ListBacks()
+
+		if name := r.URL.Query().Get("pool"); name != "" {
+			if _, ok := bh.pool(w, r); !ok {
+				return
+			}
+			filtered := make([]balancer.BackendState, 0, len(states))
+			for _, state := range states {
+				if state.Pool == name {
+					filtered = append(filtered, state)
+				}
+			}
+			states = filtered
+		}
+
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoBackendList, states)
+	}
+}
+
+// обработчик добавления сервера
+func (bh *BackendsHandler) AddHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		url := r.URL.Query().Get("url")
+		if url == "" {
+			logger.Log.Info(messages.ErrNoURL)
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoURL, nil)
+			return
+		}
+
+		pool, ok := bh.pool(w, r)
+		if !ok {
+			return
+		}
+
+		weight := 1
+		if valStr := r.URL.Query().Get("weight"); valStr != "" {
+			val, err := strconv.Atoi(valStr)
+			if err != nil || val <= 0 {
+				logger.Log.Info(messages.ErrBadValue, zap.Error(err))
+				response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrBadValue, nil)
+				return
+			}
+			weight = val
+		}
+
+		server := backend.NewBackend(url, weight)
+		if server == nil {
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrInvalidBackendURL, nil)
+			return
+		}
+		server.SetGroup(strings.ToLower(r.URL.Query().Get("group")))
+
+		if err := pool.AddBack(server); err != nil {
+			logger.Log.Info(messages.ErrAddBackend, zap.Error(err))
+			response.WriteAPIResponse(w, http.StatusConflict, false, err.Error(), nil)
+			return
+		}
+
+		logger.Log.Info(messages.InfoBackendAdded, zap.String(messages.URL, url))
+		response.WriteAPIResponse(w, http.StatusCreated, true, messages.InfoBackendAdded, nil)
+	}
+}
+
+// обработчик удаления сервера
+func (bh *BackendsHandler) RemoveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		url := r.URL.Query().Get("url")
+		if url == "" {
+			logger.Log.Info(messages.ErrNoURL)
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoURL, nil)
+			return
+		}
+
+		pool, ok := bh.pool(w, r)
+		if !ok {
+			return
+		}
+
+		if err := pool.RemoveBack(url); err != nil {
+			logger.Log.Info(messages.ErrRemoveBackend, zap.Error(err))
+			response.WriteAPIResponse(w, http.StatusNotFound, false, err.Error(), nil)
+			return
+		}
+
+		logger.Log.Info(messages.InfoBackendRemoved, zap.String(messages.URL, url))
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoBackendRemoved, nil)
+	}
+}
+
+// обработчик перевода сервера в режим draining (value=false - вернуть в работу)
+func (bh *BackendsHandler) DrainHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		url := r.URL.Query().Get("url")
+		if url == "" {
+			logger.Log.Info(messages.ErrNoURL)
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoURL, nil)
+			return
+		}
+
+		pool, ok := bh.pool(w, r)
+		if !ok {
+			return
+		}
+
+		draining := true
+		if valStr := r.URL.Query().Get("value"); valStr != "" {
+			val, err := strconv.ParseBool(valStr)
+			if err != nil {
+				logger.Log.Info(messages.ErrBadValue, zap.Error(err))
+				response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrBadValue, nil)
+				return
+			}
+			draining = val
+		}
+
+		if err := pool.DrainBack(url, draining); err != nil {
+			logger.Log.Info(messages.ErrDrainBackend, zap.Error(err))
+			response.WriteAPIResponse(w, http.StatusNotFound, false, err.Error(), nil)
+			return
+		}
+
+		logger.Log.Info(messages.InfoBackendDraining, zap.String(messages.URL, url), zap.Bool(messages.Draining, draining))
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoBackendDraining, nil)
+	}
+}
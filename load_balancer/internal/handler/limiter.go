@@ -1,78 +1,304 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"load_balancer/internal/logger"
 	"load_balancer/internal/messages"
+	"load_balancer/internal/middleware"
 	"load_balancer/internal/response"
 	ratelimiter "load_balancer/rate_limiter"
 
 	"go.uber.org/zap"
 )
 
+// LimiterHandler - админский API лимитера. Корзины адресуются исходным IP
+// (для политик с key: session - значением cookie), хеш с солью считается здесь
 type LimiterHandler struct {
-	Limiter ratelimiter.BucketIface
+	Limits *middleware.MiddlewareHandler
+	ACL    ratelimiter.AccessIface
 }
 
-// обработчик установки rate
-func (lh *LimiterHandler) SetRateHandler() http.HandlerFunc {
+// лимитер из параметра policy и ключ корзины из параметра ip
+func (lh *LimiterHandler) bucket(w http.ResponseWriter, r *http.Request) (ratelimiter.BucketIface, string, bool) {
+	ip := r.URL.Query().Get("ip")
+	if ip == "" {
+		logger.Log.Info(messages.ErrNoIP)
+		response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoIP, nil)
+		return nil, "", false
+	}
+
+	policy := r.URL.Query().Get("policy")
+	limiter, ok := lh.Limits.LimiterByName(policy)
+	if !ok {
+		msg := fmt.Sprintf(messages.ErrUnknownPolicy, policy)
+		logger.Log.Info(msg)
+		response.WriteAPIResponse(w, http.StatusNotFound, false, msg, nil)
+		return nil, "", false
+	}
+
+	return limiter, lh.Limits.Key(ip), true
+}
+
+// ответ на ошибку хранилища лимитов
+func writeLimiterError(w http.ResponseWriter, msg string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ratelimiter.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ratelimiter.ErrUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+
+	logger.Log.Info(msg, zap.Error(err))
+	response.WriteAPIResponse(w, status, false, msg, nil)
+}
+
+// обработчик получения корзин всех пользователей: всех лимитеров или одного (policy)
+func (lh *LimiterHandler) ListHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ip := r.URL.Query().Get("ip")
-		valStr := r.URL.Query().Get("value")
+		names := lh.Limits.Names()
+		if policy := r.URL.Query().Get("policy"); policy != "" {
+			names = []string{policy}
+		}
 
-		if ip == "" || valStr == "" {
-			logger.Log.Info(messages.ErrNoIPORVal)
-			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoIPORVal, nil)
+		result := make(map[string][]ratelimiter.BucketInfo, len(names))
+		for _, name := range names {
+			limiter, ok := lh.Limits.LimiterByName(name)
+			if !ok {
+				msg := fmt.Sprintf(messages.ErrUnknownPolicy, name)
+				response.WriteAPIResponse(w, http.StatusNotFound, false, msg, nil)
+				return
+			}
+
+			buckets, err := limiter.List()
+			if err != nil {
+				writeLimiterError(w, messages.ErrListBuckets, err)
+				return
+			}
+			result[name] = buckets
+		}
+
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoBuckets, result)
+	}
+}
+
+// обработчик получения корзины пользователя
+func (lh *LimiterHandler) GetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limiter, key, ok := lh.bucket(w, r)
+		if !ok {
 			return
 		}
 
-		rate, err := strconv.Atoi(valStr)
+		info, err := limiter.Get(key)
 		if err != nil {
-			logger.Log.Info(messages.ErrBadValue, zap.Error(err))
-			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrBadValue, nil)
+			writeLimiterError(w, messages.ErrGetBucket, err)
+			return
+		}
+
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoBuckets, info)
+	}
+}
+
+// обработчик установки лимитов пользователя (rate и/или max); корзина создаётся, если её нет
+func (lh *LimiterHandler) SetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limiter, key, ok := lh.bucket(w, r)
+		if !ok {
 			return
 		}
 
-		if err := lh.Limiter.SetRate(ip, rate); err != nil {
-			logger.Log.Info(messages.ErrSetRate, zap.Error(err))
-			response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ErrSetRate, nil)
+		rate, hasRate, rateErr := intParam(r, "rate")
+		maxTokens, hasMax, maxErr := intParam(r, "max")
+		if rateErr != nil || maxErr != nil || rate < 0 || maxTokens < 0 || !hasRate && !hasMax {
+			logger.Log.Info(messages.ErrNoLimits)
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoLimits, nil)
 			return
 		}
 
-		logger.Log.Info(messages.InfoRateUPD, zap.String(messages.IP, ip))
-		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoRateUPD, nil)
+		if _, err := limiter.Get(key); errors.Is(err, ratelimiter.ErrNotFound) {
+			if err := limiter.AddUser(key); err != nil {
+				writeLimiterError(w, messages.ErrSetRate, err)
+				return
+			}
+		}
+
+		if hasRate {
+			if err := limiter.SetRate(key, rate); err != nil {
+				writeLimiterError(w, messages.ErrSetRate, err)
+				return
+			}
+		}
+		if hasMax {
+			if err := limiter.SetMaxTokens(key, maxTokens); err != nil {
+				writeLimiterError(w, messages.ErrSetMax, err)
+				return
+			}
+		}
+
+		logger.Log.Info(messages.InfoBucketUpdated, zap.String(messages.Key, key))
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoBucketUpdated, nil)
 	}
 }
 
-// обработчик установки max tokens
-func (lh *LimiterHandler) SetMaxHandler() http.HandlerFunc {
+// обработчик восстановления пользователю всего всплеска запросов
+func (lh *LimiterHandler) ResetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limiter, key, ok := lh.bucket(w, r)
+		if !ok {
+			return
+		}
+
+		if err := limiter.Reset(key); err != nil {
+			writeLimiterError(w, messages.ErrResetBucket, err)
+			return
+		}
+
+		logger.Log.Info(messages.InfoBucketReset, zap.String(messages.Key, key))
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoBucketReset, nil)
+	}
+}
+
+// обработчик удаления корзины пользователя
+func (lh *LimiterHandler) DeleteHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limiter, key, ok := lh.bucket(w, r)
+		if !ok {
+			return
+		}
+
+		if err := limiter.Delete(key); err != nil {
+			writeLimiterError(w, messages.ErrDeleteBucket, err)
+			return
+		}
+
+		logger.Log.Info(messages.InfoBucketDeleted, zap.String(messages.Key, key))
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoBucketDeleted, nil)
+	}
+}
+
+// обработчик получения списков доступа и банов
+func (lh *LimiterHandler) ACLListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoACL, lh.ACL.List())
+	}
+}
+
+// обработчик добавления IP или подсети в allowlist/denylist (ttl - временно)
+func (lh *LimiterHandler) ACLAddHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, entry := r.URL.Query().Get("list"), r.URL.Query().Get("entry")
+		if list == "" || entry == "" {
+			logger.Log.Info(messages.ErrNoACLEntry)
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoACLEntry, nil)
+			return
+		}
+
+		var ttl time.Duration
+		if valStr := r.URL.Query().Get("ttl"); valStr != "" {
+			val, err := time.ParseDuration(valStr)
+			if err != nil || val <= 0 {
+				logger.Log.Info(messages.ErrBadDuration, zap.Error(err))
+				response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrBadDuration, nil)
+				return
+			}
+			ttl = val
+		}
+
+		if err := lh.ACL.Add(list, entry, ttl); err != nil {
+			logger.Log.Info(messages.ErrACL, zap.Error(err))
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, err.Error(), nil)
+			return
+		}
+
+		logger.Log.Info(messages.InfoACLAdded, zap.String(messages.List, list), zap.String(messages.Entry, entry))
+		response.WriteAPIResponse(w, http.StatusCreated, true, messages.InfoACLAdded, nil)
+	}
+}
+
+// обработчик удаления записи из allowlist/denylist
+func (lh *LimiterHandler) ACLRemoveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, entry := r.URL.Query().Get("list"), r.URL.Query().Get("entry")
+		if list == "" || entry == "" {
+			logger.Log.Info(messages.ErrNoACLEntry)
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoACLEntry, nil)
+			return
+		}
+
+		lh.removeEntry(w, list, entry)
+	}
+}
+
+// обработчик временного бана IP или подсети на duration
+func (lh *LimiterHandler) BanHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ip := r.URL.Query().Get("ip")
-		valStr := r.URL.Query().Get("value")
+		if ip == "" {
+			logger.Log.Info(messages.ErrNoIP)
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoIP, nil)
+			return
+		}
 
-		if ip == "" || valStr == "" {
-			logger.Log.Info(messages.ErrNoIPORVal)
-			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoIPORVal, nil)
+		duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+		if err != nil || duration <= 0 {
+			logger.Log.Info(messages.ErrBadDuration, zap.Error(err))
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrBadDuration, nil)
 			return
 		}
 
-		max, err := strconv.Atoi(valStr)
-		if err != nil {
-			logger.Log.Info(messages.ErrBadValue, zap.Error(err))
-			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrBadValue, nil)
+		if err := lh.ACL.Add(ratelimiter.ListDeny, ip, duration); err != nil {
+			logger.Log.Info(messages.ErrACL, zap.Error(err))
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, err.Error(), nil)
 			return
 		}
 
-		if err := lh.Limiter.SetMaxTokens(ip, max); err != nil {
-			logger.Log.Info(messages.ErrSetMax, zap.Error(err))
-			response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ErrSetMax, nil)
+		logger.Log.Info(messages.InfoBanned, zap.String(messages.IP, ip), zap.Duration(messages.Duration, duration))
+		response.WriteAPIResponse(w, http.StatusCreated, true, messages.InfoBanned, nil)
+	}
+}
+
+// обработчик снятия бана
+func (lh *LimiterHandler) UnbanHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := r.URL.Query().Get("ip")
+		if ip == "" {
+			logger.Log.Info(messages.ErrNoIP)
+			response.WriteAPIResponse(w, http.StatusBadRequest, false, messages.ErrNoIP, nil)
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
-		logger.Log.Info(messages.InfoMaxUPD, zap.String(messages.IP, ip))
-		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoMaxUPD, nil)
+		lh.removeEntry(w, ratelimiter.ListDeny, ip)
+	}
+}
+
+func (lh *LimiterHandler) removeEntry(w http.ResponseWriter, list, entry string) {
+	if err := lh.ACL.Remove(list, entry); err != nil {
+		logger.Log.Info(messages.ErrACL, zap.Error(err))
+		status := http.StatusNotFound
+		if errors.Is(err, ratelimiter.ErrBadEntry) {
+			status = http.StatusBadRequest
+		}
+		response.WriteAPIResponse(w, status, false, err.Error(), nil)
+		return
 	}
+
+	logger.Log.Info(messages.InfoACLRemoved, zap.String(messages.List, list), zap.String(messages.Entry, entry))
+	response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoACLRemoved, nil)
+}
+
+// целочисленный параметр запроса, found - параметр передан
+func intParam(r *http.Request, name string) (val int, found bool, err error) {
+	valStr := r.URL.Query().Get(name)
+	if valStr == "" {
+		return 0, false, nil
+	}
+
+	val, err = strconv.Atoi(valStr)
+	return val, true, err
 }
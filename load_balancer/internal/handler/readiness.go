@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"load_balancer/internal/messages"
+	"load_balancer/internal/response"
+)
+
+// ReadinessHandler - готовность балансировщика принимать новые запросы;
+// при остановке проверка готовности проваливается раньше, чем закрываются соединения
+type ReadinessHandler struct {
+	draining atomic.Bool
+}
+
+// Drain - перестать сообщать о готовности
+func (rh *ReadinessHandler) Drain() {
+	rh.draining.Store(true)
+}
+
+// обработчик проверки готовности: 200 в работе, 503 во время остановки
+func (rh *ReadinessHandler) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rh.draining.Load() {
+			response.WriteAPIResponse(w, http.StatusServiceUnavailable, false, messages.ErrDraining, nil)
+			return
+		}
+		response.WriteAPIResponse(w, http.StatusOK, true, messages.InfoReady, nil)
+	}
+}
@@ -13,47 +13,144 @@ const (
 	ErrInvalidBackendURL  = "invalid backend url"
 	ErrReadConfig         = "unable to read config file: %v"
 	ErrProxy              = "proxy error"
-	ErrAddToken           = "failed to add a token"
-	ErrUpdate             = "failed to update due to concurrent modification of IP %s: %v"
-	ErrFind               = "failed to find due to concurrent modification of IP %s: %v"
-	ErrInsert             = "failed to insert due to concurrent modification of IP %s: %v"
-	ErrNoData             = "no data found for IP %s"
+	ErrUpdate             = "failed to update due to concurrent modification of IP %s: %w"
+	ErrFind               = "failed to find due to concurrent modification of IP %s: %w"
+	ErrInsert             = "failed to insert due to concurrent modification of IP %s: %w"
+	ErrDelete             = "failed to delete IP %s: %w"
+	ErrList               = "failed to list rate limiter buckets: %w"
+	ErrTake               = "failed to take a token for IP %s: %w"
+	ErrScriptReply        = "unexpected reply from the token bucket script"
+	ErrNoData             = "no data found"
+	ErrForIP              = "%w for IP %s"
 	ErrLimiter            = "rate limiter failed to process the request"
 	ErrTooManyRequests    = "rate limit exceeded"
 	ErrNoAvailableToken   = "no tokens are available"
-	ErrGetKeys            = "failed to list Redis keys"
-	ErrNoIPORVal          = "missing 'ip' or 'value' parameter"
+	ErrNoIP               = "missing 'ip' parameter"
+	ErrNoLimits           = "missing or invalid 'rate' and 'max' parameters"
+	ErrUnknownPolicy      = "unknown rate limit policy: %s"
+	ErrGetBucket          = "failed to get bucket"
+	ErrListBuckets        = "failed to list buckets"
+	ErrResetBucket        = "failed to reset bucket"
+	ErrDeleteBucket       = "failed to delete bucket"
+	ErrNoACLEntry         = "missing 'list' or 'entry' parameter"
+	ErrBadDuration        = "missing or invalid duration"
+	ErrACL                = "failed to change access list"
 	ErrBadValue           = "invalid 'value' parameter"
 	ErrSetRate            = "failed to set rate"
 	ErrSetMax             = "failed to set max tokens"
+	ErrUnknownStrategy    = "unknown balancing strategy: %s"
+	ErrStrategy           = "failed to create balancing strategy"
+	ErrUnknownAlgorithm   = "unknown rate limiting algorithm: %s"
+	ErrUnknownStorage     = "unknown rate limiter storage: %s"
+	ErrLimiterInit        = "failed to create rate limiter"
+	ErrUnknownFailover    = "unknown rate limiter redis failure policy: %s"
+	ErrLimiterUnavailable = "rate limiter storage is unavailable"
+	ErrBadACLEntry        = "access list entry must be an IP or a CIDR subnet"
+	ErrUnknownACL         = "unknown access list: %s"
+	ErrACLEntryNotFound   = "access list entry %s not found"
+	ErrForbidden          = "access denied"
+	ErrAdminServer        = "failed to create admin server"
+	ErrAdminNoCert        = "admin.tls.clientCA requires admin.tls.cert and admin.tls.key"
+	ErrAdminClientCA      = "failed to load admin client CA: %w"
+	ErrReadBody           = "failed to read request body"
+	ErrNotRetryable       = "request can not be safely retried"
+	ErrBackendExists      = "backend %s already exists"
+	ErrBackendNotFound    = "backend %s not found"
+	ErrNoURL              = "missing 'url' parameter"
+	ErrAddBackend         = "failed to add backend"
+	ErrRemoveBackend      = "failed to remove backend"
+	ErrDrainBackend       = "failed to change backend draining mode"
+	ErrUnauthorized       = "admin credentials required"
+	ErrNoAdminAuth        = "admin API has neither a token nor client certificates configured, all requests will be rejected"
+	ErrConfigRejected     = "config reload rejected, keeping the previous config"
+	ErrNoBackendsInConfig = "config has no backends"
+	ErrBadBackendInConfig = "invalid backend url in config: %s"
+	ErrNotPositive        = "config parameter %s must be positive"
+	ErrWatchConfig        = "failed to watch config file"
+	ErrBadTrustedProxy    = "trusted proxy must be an IP or a CIDR subnet: %s"
+	ErrTrustedProxies     = "failed to set trusted proxies"
+	ErrBadProxyHeader     = "proxy header must be forwarded or x-forwarded-for: %s"
+	ErrLoadCert           = "failed to load TLS certificate: %w"
+	ErrBackendCA          = "failed to load backend CA: %w"
+	ErrTLSServer          = "failed to create TLS server"
+	ErrCertReload         = "failed to reload TLS certificate, keeping the previous one"
+	ErrBackendTLS         = "failed to configure TLS to backends"
+	ErrDraining           = "load balancer is shutting down"
+	ErrNoTLSCert          = "tls.enabled requires tls.cert and tls.key"
+	ErrExportSpans        = "failed to export spans"
+	ErrUnknownPool        = "unknown pool: %s"
+	ErrPoolExists         = "pool %s already exists"
+	ErrEmptyPool          = "pool %s has no backends"
+	ErrRoutes             = "failed to set routing table"
+	ErrPool               = "failed to create pool"
+	ErrPoolNotReloaded    = "pools added or removed in config take effect after restart"
+	ErrMirror             = "failed to set up traffic mirroring"
+	ErrOverloaded         = "service is overloaded, retry later"
 )
 
 // info messages
 const (
 	InfoBalancerON         = "load Balancer is on"
+	InfoAdminON            = "admin API is on"
+	InfoTLSON              = "TLS listener is on"
+	InfoTracingON          = "exporting traces"
+	InfoCertReloaded       = "TLS certificate reloaded"
 	InfoGracefulStopStart  = "shutting down gracefully"
 	InfoGracefulStopFinish = "server gracefully stopped"
+	InfoReady              = "ready"
+	InfoDraining           = "waiting for in-flight requests"
+	InfoDrainTimeout       = "drain deadline exceeded, dropping in-flight requests"
+	InfoWebSocketsClosed   = "websocket connections closed"
 	InfoForwardingURL      = "forwarding to"
 	InfoForwardingActive   = "active"
 	InfoSuccessfulProxy    = "successfully proxied to"
+	InfoClientGone         = "client closed the request, not retrying"
 	InfoShutdownHealth     = "shutting down health checks"
 	InfoUnreachable        = "server is unreachable"
 	InfoReachable          = "server is reachable"
-	InfoAddedToken         = "added a token"
-	InfoTickersStopped     = "all tickers stopped due to server shutdown"
-	InfoUserCreated        = "user created"
 	InfoAccessGranted      = "access granted"
-	InfoRateUPD            = "rate updated"
-	InfoMaxUPD             = "max tokens updated"
+	InfoBuckets            = "buckets"
+	InfoBucketUpdated      = "bucket limits updated"
+	InfoBucketReset        = "bucket reset"
+	InfoBucketDeleted      = "bucket deleted"
+	InfoACL                = "access lists"
+	InfoACLAdded           = "access list entry added"
+	InfoACLRemoved         = "access list entry removed"
+	InfoBanned             = "banned"
+	InfoBackendList        = "backends"
+	InfoBackendAdded       = "backend added"
+	InfoBackendRemoved     = "backend removed"
+	InfoBackendDraining    = "backend draining mode changed"
+	InfoBackendsKept       = "backends added through the admin API are kept on reload"
+	InfoConfigReloaded     = "config reloaded"
+	InfoPool               = "pool is on"
+	InfoMirrorON           = "mirroring traffic to shadow pool"
+	InfoSheddingON         = "adaptive concurrency limit is on"
+	InfoEjected            = "server ejected due to high 5xx rate"
+	InfoBreakerState       = "circuit breaker state changed"
+	InfoLimiterRecovered   = "rate limiter storage is available again"
 )
 
 // misc
 const (
-	URL    = "URL"
-	Port   = "Port"
-	Number = "Number"
-	Code   = "Code"
-	Status = "Status"
-	IP     = "IP"
-	Tokens = "tokens"
+	URL      = "URL"
+	Port     = "Port"
+	Number   = "Number"
+	Code     = "Code"
+	Status   = "Status"
+	IP       = "IP"
+	Method   = "Method"
+	Draining = "Draining"
+	Duration = "Duration"
+	State    = "State"
+	Policy   = "Policy"
+	Key      = "Key"
+	List     = "List"
+	Entry    = "Entry"
+	Tokens   = "tokens"
+	InFlight = "InFlight"
+	Count    = "Count"
+	Pool     = "Pool"
+	Percent  = "Percent"
+	Limit    = "Limit"
 )
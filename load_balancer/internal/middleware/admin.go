@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/internal/response"
+	"load_balancer/internal/util"
+
+	"go.uber.org/zap"
+)
+
+// AdminAuth - доступ к админскому API по токену (Authorization: Bearer) или по клиентскому
+// сертификату, проверенному TLS-сервером (mTLS). Без токена остаётся только mTLS
+type AdminAuth struct {
+	Token string
+}
+
+func (a *AdminAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logger.Log.Info(messages.ErrUnauthorized,
+			zap.String(messages.IP, util.GetClientIP(r)),
+			zap.String(messages.URL, r.URL.Path),
+		)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		response.WriteAPIResponse(w, http.StatusUnauthorized, false, messages.ErrUnauthorized, nil)
+	})
+}
+
+func (a *AdminAuth) authorized(r *http.Request) bool {
+	// цепочка есть, только если сервер запросил сертификат и проверил его по клиентскому CA
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && a.Token != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) == 1
+}
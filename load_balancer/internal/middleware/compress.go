@@ -0,0 +1,286 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// кодировки ответа в порядке предпочтения при равном q
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// уровень brotli ниже стандартного 11: сжатие на лету, а не заранее
+const brotliLevel = 5
+
+var (
+	gzipPool   = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	brotliPool = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, brotliLevel) }}
+)
+
+// Compression - сжатие ответов gzip или brotli по Accept-Encoding клиента
+type Compression struct {
+	MinSize      int      // ответы меньше не сжимаются
+	ContentTypes []string // сжимаемые типы; "text/*" - все подтипы
+	SkipPrefixes []string // пути, ответы на которые не трогаем (уже сжатые файлы)
+}
+
+func (c *Compression) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" || c.skipped(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			cfg:            c,
+			encoding:       negotiate(r.Header.Values("Accept-Encoding")),
+		}
+		defer cw.Close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+func (c *Compression) skipped(path string) bool {
+	for _, prefix := range c.SkipPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Compression) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range c.ContentTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, found := strings.CutSuffix(allowed, "*"); found && strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// выбор кодировки по Accept-Encoding (RFC 9110, 12.5.3): наибольший q, при равенстве
+// brotli; "" - клиент не принимает ни одну из поддерживаемых
+func negotiate(values []string) string {
+	q := map[string]float64{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			weight := 1.0
+			if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					weight = parsed
+				}
+			}
+			q[strings.ToLower(strings.TrimSpace(name))] = weight
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{EncodingBrotli, EncodingGzip} {
+		weight, found := q[encoding]
+		if !found {
+			weight, found = q["*"]
+		}
+		if found && weight > bestQ {
+			best, bestQ = encoding, weight
+		}
+	}
+	return best
+}
+
+// compressWriter - копит начало ответа до MinSize, затем решает, сжимать ли его;
+// ответ, закончившийся раньше, отправляется как есть
+type compressWriter struct {
+	http.ResponseWriter
+	cfg      *Compression
+	encoding string // выбранная кодировка, "" - клиент не принимает сжатие
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser // nil - ответ идёт без сжатия
+}
+
+var (
+	_ http.ResponseWriter = &compressWriter{}
+	_ http.Flusher        = &compressWriter{}
+)
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.status != 0 || cw.decided {
+		return
+	}
+	if code >= 100 && code < 200 {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	cw.status = code
+	// у этих ответов нет тела, решать нечего
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.decide()
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.cfg.MinSize {
+		if err := cw.flushBuffer(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush - потоковый ответ: решение принимается по уже записанному, дальше данные
+// сбрасываются клиенту без ожидания MinSize
+func (cw *compressWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if err := cw.flushBuffer(); err != nil {
+		return
+	}
+
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		f.Flush() //nolint:errcheck
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush() //nolint:errcheck
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close - завершить ответ: короткий ответ без сжатия, у сжатого дописать окончание потока
+func (cw *compressWriter) Close() {
+	if cw.status == 0 {
+		return
+	}
+	if !cw.decided {
+		cw.decideFor(len(cw.buf) >= cw.cfg.MinSize)
+	}
+	if err := cw.flushBuffer(); err != nil {
+		return
+	}
+
+	switch enc := cw.encoder.(type) {
+	case *gzip.Writer:
+		enc.Close() //nolint:errcheck
+		gzipPool.Put(enc)
+	case *brotli.Writer:
+		enc.Close() //nolint:errcheck
+		brotliPool.Put(enc)
+	}
+	cw.encoder = nil
+}
+
+// отправка накопленного начала ответа; при необходимости сначала принимается решение
+func (cw *compressWriter) flushBuffer() error {
+	if !cw.decided {
+		cw.decide()
+	}
+	if len(cw.buf) == 0 {
+		return nil
+	}
+
+	buf := cw.buf
+	cw.buf = nil
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+func (cw *compressWriter) decide() {
+	cw.decideFor(true)
+}
+
+// решение о сжатии и отправка заголовков; bigEnough - тело не меньше MinSize
+func (cw *compressWriter) decideFor(bigEnough bool) {
+	cw.decided = true
+	h := cw.Header()
+
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	// сервер уже сжал ответ или отдаёт часть файла - кодировку не меняем
+	eligible := h.Get("Content-Encoding") == "" &&
+		h.Get("Content-Range") == "" &&
+		cw.status != http.StatusPartialContent &&
+		cw.cfg.compressible(h.Get("Content-Type"))
+
+	// представление зависит от Accept-Encoding, даже если этому клиенту отдаём без сжатия
+	if eligible {
+		addVary(h, "Accept-Encoding")
+	}
+
+	if eligible && bigEnough && cw.encoding != "" && cw.status != http.StatusNoContent && cw.status != http.StatusNotModified {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		// сжатое представление отличается от исходного побайтно, сильный ETag к нему не подходит
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		cw.encoder = cw.newEncoder()
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+func (cw *compressWriter) newEncoder() io.WriteCloser {
+	if cw.encoding == EncodingBrotli {
+		enc := brotliPool.Get().(*brotli.Writer)
+		enc.Reset(cw.ResponseWriter)
+		return enc
+	}
+
+	enc := gzipPool.Get().(*gzip.Writer)
+	enc.Reset(cw.ResponseWriter)
+	return enc
+}
+
+// добавить имя в Vary, если его там ещё нет
+func addVary(h http.Header, name string) {
+	for _, value := range h.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			existing = strings.TrimSpace(existing)
+			if existing == "*" || strings.EqualFold(existing, name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
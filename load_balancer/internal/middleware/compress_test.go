@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+var testCompression = &Compression{
+	MinSize:      100,
+	ContentTypes: []string{"text/*", "application/json"},
+	SkipPrefixes: []string{"/api/download-task"},
+}
+
+func serve(h http.Handler, target, acceptEncoding string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func respond(contentType, body string, header map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		for name, value := range header {
+			w.Header().Set(name, value)
+		}
+		// пишем частями, как прокси
+		for i := 0; i < len(body); i += 30 {
+			w.Write([]byte(body[i:min(i+30, len(body))])) //nolint:errcheck
+		}
+	})
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	case EncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                         "",
+		"identity":                 "",
+		"gzip":                     EncodingGzip,
+		"gzip, deflate, br":        EncodingBrotli,
+		"br;q=0.5, gzip":           EncodingGzip,
+		"br;q=0, gzip;q=0":         "",
+		"*":                        EncodingBrotli,
+		"*;q=0.1, gzip;q=0.5":      EncodingGzip,
+		"GZIP;q=1.0, br;q=0.999":   EncodingGzip,
+		"deflate, br;q=0, *;q=0.2": EncodingGzip,
+	}
+	for header, want := range cases {
+		if got := negotiate([]string{header}); got != want {
+			t.Errorf("%q: expected %q, got %q", header, want, got)
+		}
+	}
+}
+
+func TestCompressesAllowedTypes(t *testing.T) {
+	body := strings.Repeat(`{"success":true,"message":"ok"}`, 20)
+	h := testCompression.Middleware(respond("application/json; charset=utf-8", body,
+		map[string]string{"Content-Length": "620", "ETag": `"v1"`}))
+
+	for _, encoding := range []string{EncodingGzip, EncodingBrotli} {
+		rec := serve(h, "/api/get-tasks", encoding)
+
+		if got := rec.Header().Get("Content-Encoding"); got != encoding {
+			t.Fatalf("expected Content-Encoding %s, got %q", encoding, got)
+		}
+		if rec.Header().Get("Content-Length") != "" {
+			t.Fatal("expected Content-Length of the uncompressed body to be dropped")
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Fatalf("expected Vary: Accept-Encoding, got %q", got)
+		}
+		if got := rec.Header().Get("ETag"); got != `W/"v1"` {
+			t.Fatalf("expected weakened ETag, got %q", got)
+		}
+		if got := decode(t, encoding, rec.Body.Bytes()); got != body {
+			t.Fatalf("%s: decoded body differs from the original", encoding)
+		}
+		if rec.Body.Len() >= len(body) {
+			t.Fatalf("%s: expected compressed body to be smaller", encoding)
+		}
+	}
+}
+
+func TestLeavesResponsesUncompressed(t *testing.T) {
+	long := strings.Repeat("a", 500)
+
+	cases := []struct {
+		name         string
+		target       string
+		accept       string
+		body         string
+		handler      http.Handler
+		wantEncoding string
+		wantVary     bool
+	}{
+		{"client without gzip", "/main", "", long, respond("text/html", long, nil), "", true},
+		{"below min size", "/main", "gzip", "short", respond("text/html", "short", nil), "", true},
+		{"type not allowed", "/assets/app.png", "gzip", long, respond("image/png", long, nil), "", false},
+		{"already encoded", "/main", "gzip", long,
+			respond("text/html", long, map[string]string{"Content-Encoding": "br"}), "br", false},
+		{"download skipped", "/api/download-task?id=1", "gzip", long, respond("text/plain", long, nil), "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(testCompression.Middleware(tc.handler), tc.target, tc.accept)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tc.wantEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tc.wantEncoding, got)
+			}
+			if rec.Body.String() != tc.body {
+				t.Fatalf("expected body as is, got %d bytes", rec.Body.Len())
+			}
+			if got := rec.Header().Get("Vary") == "Accept-Encoding"; got != tc.wantVary {
+				t.Fatalf("expected Vary set=%v, got %q", tc.wantVary, rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestSniffsContentTypeAndKeepsVary(t *testing.T) {
+	body := "<!DOCTYPE html><html>" + strings.Repeat("<p>text</p>", 30) + "</html>"
+	h := testCompression.Middleware(respond("", body, map[string]string{"Vary": "Cookie"}))
+
+	rec := serve(h, "/", "gzip")
+	if rec.Header().Get("Content-Encoding") != EncodingGzip {
+		t.Fatal("expected sniffed text/html to be compressed")
+	}
+	if got := rec.Header().Values("Vary"); len(got) != 2 || got[1] != "Accept-Encoding" {
+		t.Fatalf("expected Accept-Encoding appended to Vary, got %v", got)
+	}
+}
+
+func TestNoBodyStatuses(t *testing.T) {
+	h := testCompression.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotModified)
+	}))
+
+	rec := serve(h, "/main", "gzip")
+	if rec.Code != http.StatusNotModified || rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 0 {
+		t.Fatalf("expected empty uncompressed 304, got %d %q", rec.Code, rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestFlushStreamsCompressedData(t *testing.T) {
+	h := testCompression.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n")) //nolint:errcheck
+		w.(http.Flusher).Flush()
+	}))
+
+	rec := serve(h, "/events", "gzip")
+	if !rec.Flushed || rec.Header().Get("Content-Encoding") != EncodingGzip {
+		t.Fatalf("expected flushed gzip stream, flushed=%v encoding=%q", rec.Flushed, rec.Header().Get("Content-Encoding"))
+	}
+	if got := decode(t, EncodingGzip, rec.Body.Bytes()); got != "data: 1\n\n" {
+		t.Fatalf("unexpected stream body %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -16,8 +17,12 @@ import (
 )
 
 type MiddlewareHandler struct {
-	Limiter ratelimiter.BucketIface
-	Salt    string
+	Limiter       ratelimiter.BucketIface // лимит по IP для запросов вне политик
+	Salt          string
+	SessionCookie string                  // cookie сессии для политик с KeySession
+	ACL           ratelimiter.AccessIface // списки доступа, nil - не проверяются
+
+	policies []Policy // отсортированы по убыванию длины префикса
 }
 
 type statusRecorder struct {
@@ -30,48 +35,98 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap - доступ к исходному writer для http.ResponseController (Flush, Hijack для /ws)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // LimitMiddleware - middleware для ограничения количества запросов
 func (mh *MiddlewareHandler) LimitMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		ip := util.GetClientIP(r)
-		hashedIP := util.HashIP(ip, mh.Salt)
+		policy, buckets := mh.buckets(r, ip)
 
 		metrics.ActiveConnections.Inc()
 		defer metrics.ActiveConnections.Dec()
 
-		tokens, err := mh.Limiter.GetTokens(hashedIP)
-		if err != nil {
-			logger.Log.Info(messages.InfoUserCreated, zap.String(messages.IP, ip), zap.Error(err))
-			if err := mh.Limiter.AddUser(hashedIP); err != nil {
-				metrics.RequestCount.WithLabelValues(r.Method, r.URL.Path, "500").Inc()
-				response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ErrLimiter, nil)
+		if mh.ACL != nil {
+			switch mh.ACL.Check(ip) {
+			case ratelimiter.VerdictDeny:
+				logger.Log.Info(messages.ErrForbidden, zap.String(messages.IP, ip))
+				metrics.RequestCount.WithLabelValues(r.Method, r.URL.Path, "403").Inc()
+				response.WriteAPIResponse(w, http.StatusForbidden, false, messages.ErrForbidden, nil)
+				return
+			case ratelimiter.VerdictAllow:
+				mh.serve(next, w, r, start)
 				return
 			}
-			mh.Limiter.AddToken(hashedIP)
 		}
 
-		if err := mh.Limiter.RemoveToken(hashedIP); err != nil {
+		// заголовки RateLimit-* - по последней корзине, это корзина политики
+		var status ratelimiter.Status
+		var err error
+		for _, b := range buckets {
+			if status, err = b.limiter.RemoveToken(b.key); err != nil {
+				break
+			}
+		}
+		if errors.Is(err, ratelimiter.ErrNoTokens) {
+			setRateLimitHeaders(w.Header(), status)
+			w.Header().Set("Retry-After", strconv.Itoa(max(1, seconds(status.RetryAfter))))
 			metrics.RequestCount.WithLabelValues(r.Method, r.URL.Path, "429").Inc()
 			metrics.RequestDuration.WithLabelValues(r.URL.Path).Observe(time.Since(start).Seconds())
 			response.WriteAPIResponse(w, http.StatusTooManyRequests, false, messages.ErrTooManyRequests, nil)
 			return
 		}
+		if errors.Is(err, ratelimiter.ErrUnavailable) {
+			metrics.RequestCount.WithLabelValues(r.Method, r.URL.Path, "503").Inc()
+			response.WriteAPIResponse(w, http.StatusServiceUnavailable, false, messages.ErrLimiterUnavailable, nil)
+			return
+		}
+		if err != nil {
+			logger.Log.Error(messages.ErrLimiter, zap.String(messages.IP, ip), zap.Error(err))
+			metrics.RequestCount.WithLabelValues(r.Method, r.URL.Path, "500").Inc()
+			response.WriteAPIResponse(w, http.StatusInternalServerError, false, messages.ErrLimiter, nil)
+			return
+		}
 
 		logger.Log.Info(messages.InfoAccessGranted,
 			zap.String(messages.IP, ip),
-			zap.String(messages.Tokens, strconv.Itoa(tokens)),
+			zap.String(messages.Policy, policy),
+			zap.String(messages.Tokens, strconv.Itoa(status.Remaining)),
 		)
 
-		// оборачиваем writer для захвата итогового статуса
-		rec := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
-		next.ServeHTTP(rec, r)
+		// заголовки попадут в ответ сервера: прокси дописывает свои к уже установленным
+		setRateLimitHeaders(w.Header(), status)
 
-		metrics.RequestCount.
-			WithLabelValues(r.Method, r.URL.Path, strconv.Itoa(rec.statusCode)).
-			Inc()
-		metrics.RequestDuration.
-			WithLabelValues(r.URL.Path).
-			Observe(time.Since(start).Seconds())
+		mh.serve(next, w, r, start)
 	})
 }
+
+// проксирование запроса, прошедшего лимиты, с метриками итогового статуса
+func (mh *MiddlewareHandler) serve(next http.Handler, w http.ResponseWriter, r *http.Request, start time.Time) {
+	// оборачиваем writer для захвата итогового статуса
+	rec := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	next.ServeHTTP(rec, r)
+
+	metrics.RequestCount.
+		WithLabelValues(r.Method, r.URL.Path, strconv.Itoa(rec.statusCode)).
+		Inc()
+	metrics.RequestDuration.
+		WithLabelValues(r.URL.Path).
+		Observe(time.Since(start).Seconds())
+}
+
+// заголовки RateLimit-* (draft-ietf-httpapi-ratelimit-headers) для клиента,
+// чтобы он мог снижать частоту запросов заранее, не дожидаясь 429
+func setRateLimitHeaders(h http.Header, status ratelimiter.Status) {
+	h.Set("RateLimit-Limit", strconv.Itoa(status.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(status.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(status.Reset)))
+}
+
+// длительность в целых секундах с округлением вверх
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"load_balancer/internal/util"
+	ratelimiter "load_balancer/rate_limiter"
+)
+
+// по чему считается лимит политики
+const (
+	KeyIP      = "ip"      // IP клиента
+	KeySession = "session" // cookie сессии и лимит политики на IP, без cookie - IP клиента
+)
+
+// Policy - отдельный лимит для запросов, путь которых начинается с Prefix
+type Policy struct {
+	Name    string   // имя политики, им же разделяются ключи в хранилище
+	Prefix  string   // префикс пути
+	Methods []string // методы, пусто - любой
+	Key     string   // KeyIP или KeySession
+	Limiter ratelimiter.BucketIface
+}
+
+// SetPolicies - установить таблицу политик; запрос подпадает под политику
+// с самым длинным подходящим префиксом, остальные считаются общим Limiter по IP
+func (mh *MiddlewareHandler) SetPolicies(policies []Policy) {
+	sorted := slices.Clone(policies)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+	mh.policies = sorted
+}
+
+func (p *Policy) matches(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, p.Prefix) {
+		return false
+	}
+	return len(p.Methods) == 0 || slices.ContainsFunc(p.Methods, func(m string) bool {
+		return strings.EqualFold(m, r.Method)
+	})
+}
+
+// префикс корзины IP в лимитере сессионной политики: хеши IP и cookie не пересекаются
+const ipKeyPrefix = "ip:"
+
+// корзина, из которой запрос берёт токен
+type bucketKey struct {
+	limiter ratelimiter.BucketIface
+	key     string
+}
+
+// лимитер политики и ключи корзин для запроса; состояние политик хранится отдельно
+// от общего лимитера (у каждого свой префикс ключей), поэтому ключ - только хеш.
+// Запрос с cookie сессии вдобавок берёт токен корзины своего IP в том же лимитере
+// политики: cookie задаёт клиент, и со случайной cookie на каждый запрос он иначе
+// обходил бы лимит. Общий лимит по IP запросы политик не расходуют
+func (mh *MiddlewareHandler) buckets(r *http.Request, ip string) (policy string, buckets []bucketKey) {
+	for i := range mh.policies {
+		p := &mh.policies[i]
+		if !p.matches(r) {
+			continue
+		}
+
+		byIP := bucketKey{limiter: p.Limiter, key: mh.Key(ip)}
+		if p.Key == KeySession {
+			if c, err := r.Cookie(mh.SessionCookie); err == nil && c.Value != "" {
+				byIP.key = ipKeyPrefix + byIP.key
+				return p.Name, []bucketKey{byIP, {limiter: p.Limiter, key: mh.Key(c.Value)}}
+			}
+		}
+		return p.Name, []bucketKey{byIP}
+	}
+
+	return ratelimiter.DefaultName, []bucketKey{{limiter: mh.Limiter, key: mh.Key(ip)}}
+}
+
+// LimiterByName - лимитер по имени политики, пусто или DefaultName - общий
+func (mh *MiddlewareHandler) LimiterByName(name string) (ratelimiter.BucketIface, bool) {
+	if name == "" || name == ratelimiter.DefaultName {
+		return mh.Limiter, true
+	}
+
+	for _, p := range mh.policies {
+		if p.Name == name {
+			return p.Limiter, true
+		}
+	}
+	return nil, false
+}
+
+// Names - имена общего лимитера и политик
+func (mh *MiddlewareHandler) Names() []string {
+	names := []string{ratelimiter.DefaultName}
+	for _, p := range mh.policies {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Key - ключ корзины для IP (или значения cookie сессии) так же, как при обработке запроса
+func (mh *MiddlewareHandler) Key(id string) string {
+	return util.HashIP(id, mh.Salt)
+}
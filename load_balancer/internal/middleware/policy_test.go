@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+
+	"load_balancer/internal/logger"
+	ratelimiter "load_balancer/rate_limiter"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// лимитер в памяти: сразу доступны все maxTokens запросов
+// (корзина токенов выдаётся с одним), за время теста они не восстанавливаются
+func memoryLimiter(t *testing.T, name string, maxTokens int) ratelimiter.BucketIface {
+	t.Helper()
+	limiter, err := ratelimiter.New(ratelimiter.Config{
+		Name:      name,
+		Algorithm: ratelimiter.SlidingLog,
+		Storage:   ratelimiter.StorageMemory,
+		MaxTokens: maxTokens,
+		Rate:      3600,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return limiter
+}
+
+func policyHandler(t *testing.T, defaultTokens int, policies ...Policy) *MiddlewareHandler {
+	t.Helper()
+	mh := &MiddlewareHandler{
+		Limiter:       memoryLimiter(t, ratelimiter.DefaultName, defaultTokens),
+		Salt:          "salt",
+		SessionCookie: "authToken",
+	}
+	for i := range policies {
+		policies[i].Limiter = memoryLimiter(t, policies[i].Name, 100)
+	}
+	mh.SetPolicies(policies)
+	return mh
+}
+
+func TestPolicyMatch(t *testing.T) {
+	// порядок намеренно не по длине префикса
+	mh := policyHandler(t, 100,
+		Policy{Name: "api", Prefix: "/api/"},
+		Policy{Name: "login", Prefix: "/api/login", Methods: []string{"POST"}},
+		Policy{Name: "upload", Prefix: "/api/upload", Methods: []string{"post", "PUT"}},
+	)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+	}{
+		{name: "longest prefix", method: http.MethodPost, path: "/api/login", want: "login"},
+		{name: "prefix of a longer path", method: http.MethodPost, path: "/api/login/otp", want: "login"},
+		{name: "other method falls to a shorter prefix", method: http.MethodGet, path: "/api/login", want: "api"},
+		{name: "methods are case-insensitive", method: http.MethodPost, path: "/api/upload", want: "upload"},
+		{name: "second method", method: http.MethodPut, path: "/api/upload", want: "upload"},
+		{name: "no methods - any method", method: http.MethodDelete, path: "/api/tasks", want: "api"},
+		{name: "outside policies", method: http.MethodPost, path: "/health", want: ratelimiter.DefaultName},
+		{name: "prefix, not substring", method: http.MethodPost, path: "/v2/api/login", want: ratelimiter.DefaultName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, _ := mh.buckets(httptest.NewRequest(tt.method, tt.path, nil), "10.0.0.1")
+			if policy != tt.want {
+				t.Fatalf("policy %q, want %q", policy, tt.want)
+			}
+		})
+	}
+
+	want := []string{ratelimiter.DefaultName, "upload", "login", "api"}
+	if !slices.Equal(mh.Names(), want) {
+		t.Fatalf("names %v, want %v", mh.Names(), want)
+	}
+}
+
+func TestPolicySession(t *testing.T) {
+	mh := policyHandler(t, 100,
+		Policy{Name: "tasks", Prefix: "/api/tasks", Key: KeySession},
+		Policy{Name: "login", Prefix: "/api/login", Key: KeyIP},
+	)
+
+	tests := []struct {
+		name    string
+		path    string
+		cookie  string
+		buckets []bucketKey
+	}{
+		{name: "session cookie", path: "/api/tasks", cookie: "token-1", buckets: []bucketKey{
+			{limiter: mh.policies[0].Limiter, key: ipKeyPrefix + mh.Key("10.0.0.1")},
+			{limiter: mh.policies[0].Limiter, key: mh.Key("token-1")},
+		}},
+		{name: "no cookie - IP", path: "/api/tasks", buckets: []bucketKey{
+			{limiter: mh.policies[0].Limiter, key: mh.Key("10.0.0.1")},
+		}},
+		{name: "ip policy ignores the cookie", path: "/api/login", cookie: "token-1", buckets: []bucketKey{
+			{limiter: mh.policies[1].Limiter, key: mh.Key("10.0.0.1")},
+		}},
+		{name: "outside policies", path: "/health", cookie: "token-1", buckets: []bucketKey{
+			{limiter: mh.Limiter, key: mh.Key("10.0.0.1")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: mh.SessionCookie, Value: tt.cookie})
+			}
+			_, buckets := mh.buckets(r, "10.0.0.1")
+			if len(buckets) != len(tt.buckets) {
+				t.Fatalf("%d buckets, want %d", len(buckets), len(tt.buckets))
+			}
+			for i, b := range buckets {
+				if b.limiter != tt.buckets[i].limiter || b.key != tt.buckets[i].key {
+					t.Fatalf("bucket %d: %+v, want %+v", i, b, tt.buckets[i])
+				}
+			}
+		})
+	}
+}
+
+// новая cookie на каждый запрос не обходит лимит политики на IP,
+// а запросы политики не расходуют общий лимит
+func TestPolicyRandomCookies(t *testing.T) {
+	mh := policyHandler(t, 1, Policy{Name: "tasks", Prefix: "/api/tasks", Key: KeySession})
+	mh.policies[0].Limiter = memoryLimiter(t, "tasks", 3)
+	h := mh.LimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(path, cookie string) int {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: mh.SessionCookie, Value: cookie})
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	for i := range 3 {
+		if code := serve("/api/tasks", "random-"+strconv.Itoa(i)); code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i, code)
+		}
+	}
+	if code := serve("/api/tasks", "random-3"); code != http.StatusTooManyRequests {
+		t.Fatalf("status %d with a fresh cookie over the IP limit of the policy, want 429", code)
+	}
+	if code := serve("/health", ""); code != http.StatusOK {
+		t.Fatalf("status %d outside the policy, want 200: the general limit must be untouched", code)
+	}
+}
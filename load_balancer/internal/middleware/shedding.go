@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	configloading "load_balancer/config_loading"
+	"load_balancer/internal/messages"
+	"load_balancer/internal/response"
+	"load_balancer/metrics"
+	ratelimiter "load_balancer/rate_limiter"
+)
+
+// DefaultClass - класс приоритета запросов, не подошедших ни под один префикс
+const DefaultClass = "default"
+
+// Shedding - сброс нагрузки: запросы сверх адаптивного предела одновременных запросов
+// к серверам получают 503 с Retry-After, не доходя до серверов
+type Shedding struct {
+	Limiter      *ratelimiter.Adaptive
+	Classes      []configloading.PriorityClass
+	DefaultShare float64       // доля предела для DefaultClass
+	RetryAfter   time.Duration // через сколько клиенту повторить запрос
+}
+
+func (s *Shedding) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// WebSocket держит соединение часами, в пределе он не учитывается
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		class, share := s.class(r.URL.Path)
+		if !s.Limiter.Acquire(share) {
+			metrics.ShedRequests.WithLabelValues(class).Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(max(1, seconds(s.RetryAfter))))
+			response.WriteAPIResponse(w, http.StatusServiceUnavailable, false, messages.ErrOverloaded, nil)
+			return
+		}
+
+		start := time.Now()
+		lw := &latencyWriter{ResponseWriter: w}
+		defer func() {
+			latency := time.Since(start)
+			if !lw.committedAt.IsZero() {
+				latency = lw.committedAt.Sub(start)
+			}
+			s.Limiter.Release(start, latency, lw.status >= http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(lw, r)
+	})
+}
+
+// класс запроса по самому длинному подходящему префиксу и его доля предела
+func (s *Shedding) class(path string) (string, float64) {
+	name, share, longest := DefaultClass, s.DefaultShare, -1
+	for _, c := range s.Classes {
+		for _, prefix := range c.Prefixes {
+			if len(prefix) > longest && strings.HasPrefix(path, prefix) {
+				name, share, longest = c.Name, c.Share, len(prefix)
+			}
+		}
+	}
+	return name, share
+}
+
+// latencyWriter - запоминает код ответа и момент отправки заголовков: время ответа
+// сервера не должно включать передачу тела (скачивание файлов)
+type latencyWriter struct {
+	http.ResponseWriter
+	status      int
+	committedAt time.Time
+}
+
+func (lw *latencyWriter) WriteHeader(code int) {
+	if lw.status == 0 && code >= 200 {
+		lw.status = code
+		lw.committedAt = time.Now()
+	}
+	lw.ResponseWriter.WriteHeader(code)
+}
+
+func (lw *latencyWriter) Write(b []byte) (int, error) {
+	if lw.status == 0 {
+		lw.WriteHeader(http.StatusOK)
+	}
+	return lw.ResponseWriter.Write(b)
+}
+
+// Unwrap - доступ к исходному writer для http.ResponseController
+func (lw *latencyWriter) Unwrap() http.ResponseWriter {
+	return lw.ResponseWriter
+}
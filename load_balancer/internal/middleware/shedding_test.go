@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	configloading "load_balancer/config_loading"
+	"load_balancer/metrics"
+	ratelimiter "load_balancer/rate_limiter"
+)
+
+func TestShedding(t *testing.T) {
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Done()
+		<-release
+	})
+
+	shedding := &Shedding{
+		Limiter: ratelimiter.NewAdaptive(ratelimiter.AdaptiveConfig{
+			InitialLimit: 4, MinLimit: 1, MaxLimit: 4, LatencyThreshold: time.Second,
+		}),
+		Classes: []configloading.PriorityClass{
+			{Name: "critical", Prefixes: []string{"/api/login", "/health"}, Share: 1},
+			{Name: "download", Prefixes: []string{"/api/download-"}, Share: 0.25},
+		},
+		DefaultShare: 0.5,
+		RetryAfter:   1500 * time.Millisecond,
+	}
+	h := shedding.Middleware(backend)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// два обычных запроса занимают свою долю предела
+	var done sync.WaitGroup
+	started.Add(2)
+	for range 2 {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			serve("/api/get-tasks")
+		}()
+	}
+	started.Wait()
+
+	shed := metrics.ShedRequests.WithLabelValues(DefaultClass)
+	before := metrics.CounterValue(shed)
+
+	rec := serve("/api/get-teachers")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503 over the default share", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After %q, want 2", got)
+	}
+	if got := metrics.CounterValue(shed) - before; got != 1 {
+		t.Fatalf("shed %v requests, want 1", got)
+	}
+	if rec := serve("/api/download-task"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503 for the download class", rec.Code)
+	}
+
+	// вход и проверки здоровья проходят до полного предела
+	started.Add(2)
+	for _, path := range []string{"/api/login", "/health"} {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			if rec := serve(path); rec.Code != http.StatusOK {
+				t.Errorf("%s: status %d", path, rec.Code)
+			}
+		}()
+	}
+	started.Wait()
+
+	if rec := serve("/api/login"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503 over the full limit", rec.Code)
+	}
+
+	close(release)
+	done.Wait()
+	started.Add(1)
+	if rec := serve("/api/get-tasks"); rec.Code != http.StatusOK {
+		t.Fatalf("status %d after the load is gone", rec.Code)
+	}
+}
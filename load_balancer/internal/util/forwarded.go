@@ -0,0 +1,234 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"load_balancer/internal/messages"
+)
+
+// заголовок, которым доверенные прокси передают цепочку адресов
+const (
+	HeaderForwarded     = "forwarded"       // RFC 7239 Forwarded
+	HeaderXForwardedFor = "x-forwarded-for" // X-Forwarded-For, как у nginx по умолчанию
+)
+
+// доверенные прокси: подсети и заголовок цепочки. Читается только этот заголовок -
+// прокси, дописывающий X-Forwarded-For, пропускает Forwarded клиента без изменений
+type proxies struct {
+	networks []*net.IPNet
+	header   string
+}
+
+// только от доверенных прокси принимаются заголовок цепочки и X-Real-IP
+var trustedProxies atomic.Pointer[proxies]
+
+// SetTrustedProxies - задать доверенные прокси списком IP и подсетей CIDR и заголовок,
+// которым они передают цепочку (HeaderForwarded или HeaderXForwardedFor, пусто - X-Forwarded-For);
+// пустой список - заголовкам клиента не доверяем, IP берётся из соединения
+func SetTrustedProxies(entries []string, header string) error {
+	header = strings.ToLower(header)
+	switch header {
+	case "":
+		header = HeaderXForwardedFor
+	case HeaderForwarded, HeaderXForwardedFor:
+	default:
+		return fmt.Errorf(messages.ErrBadProxyHeader, header)
+	}
+
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		network, err := parseNetwork(strings.TrimSpace(entry))
+		if err != nil {
+			return err
+		}
+		networks = append(networks, network)
+	}
+
+	trustedProxies.Store(&proxies{networks: networks, header: header})
+	return nil
+}
+
+func parseNetwork(entry string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		return network, nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf(messages.ErrBadTrustedProxy, entry)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}, nil
+}
+
+func isTrusted(ip net.IP) bool {
+	p := trustedProxies.Load()
+	if p == nil || ip == nil {
+		return false
+	}
+
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IP соединения, из которого пришёл запрос
+func peerIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// цепочка адресов от клиента к последнему прокси из заголовка, заданного
+// в SetTrustedProxies; нераспознанные узлы ("unknown", "_hidden") - nil
+func forwardedChain(h http.Header) []net.IP {
+	var chain []net.IP
+	if p := trustedProxies.Load(); p != nil && p.header == HeaderForwarded {
+		for _, element := range splitList(h.Values("Forwarded")) {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if found && strings.EqualFold(key, "for") {
+					chain = append(chain, parseNode(value))
+				}
+			}
+		}
+		return chain
+	}
+
+	for _, node := range splitList(h.Values("X-Forwarded-For")) {
+		chain = append(chain, parseNode(node))
+	}
+	return chain
+}
+
+// элементы списков через запятую из всех строк заголовка; запятые в кавычках не разделяют
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		quoted, start := false, 0
+		for i := 0; i < len(value); i++ {
+			switch value[i] {
+			case '"':
+				quoted = !quoted
+			case ',':
+				if !quoted {
+					items = append(items, strings.TrimSpace(value[start:i]))
+					start = i + 1
+				}
+			}
+		}
+		items = append(items, strings.TrimSpace(value[start:]))
+	}
+	return items
+}
+
+// узел вида 192.0.2.1, 192.0.2.1:80, "[2001:db8::1]:80" или 2001:db8::1
+func parseNode(node string) net.IP {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+
+	if strings.HasPrefix(node, "[") {
+		end := strings.IndexByte(node, ']')
+		if end < 0 {
+			return nil
+		}
+		return net.ParseIP(node[1:end])
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	return net.ParseIP(node)
+}
+
+// вспомогательная функция для получения ip пользователя.
+// Заголовки учитываются, только если запрос пришёл от доверенного прокси; цепочка
+// разбирается справа налево до первого недоверенного адреса - его подделать клиент
+// уже не может, всё левее дописано им самим
+func GetClientIP(r *http.Request) string {
+	peer := peerIP(r)
+	if !isTrusted(net.ParseIP(peer)) {
+		return peer
+	}
+
+	chain := forwardedChain(r.Header)
+	if len(chain) == 0 {
+		if realIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); realIP != nil {
+			return realIP.String()
+		}
+		return peer
+	}
+
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		// узел скрыт доверенным прокси - дальше цепочке верить нельзя
+		if chain[i] == nil {
+			break
+		}
+		client = chain[i].String()
+		if !isTrusted(chain[i]) {
+			break
+		}
+	}
+	return client
+}
+
+// AppendForwarded - добавить в исходящий к серверу запрос свой узел цепочки Forwarded
+// и X-Real-IP с определённым IP клиента. Заголовки от недоверенного клиента
+// отбрасываются, X-Forwarded-For дописывает httputil.ReverseProxy
+func AppendForwarded(r *http.Request) {
+	client := GetClientIP(r)
+	peer := peerIP(r)
+
+	if !isTrusted(net.ParseIP(peer)) {
+		r.Header.Del("Forwarded")
+		r.Header.Del("X-Forwarded-For")
+		r.Header.Del("X-Real-IP")
+	}
+
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+
+	hop := fmt.Sprintf("for=%s;proto=%s", forwardedNode(peer), proto)
+	if r.Host != "" {
+		hop += fmt.Sprintf(";host=%q", r.Host)
+	}
+
+	// Forwarded от прокси, который передаёт цепочку в X-Forwarded-For, мог прийти от клиента:
+	// цепочка для сервера строится из X-Forwarded-For
+	prior := r.Header.Values("Forwarded")
+	if p := trustedProxies.Load(); p == nil || p.header != HeaderForwarded {
+		prior = prior[:0:0]
+		for _, node := range splitList(r.Header.Values("X-Forwarded-For")) {
+			if ip := parseNode(node); ip != nil {
+				prior = append(prior, "for="+forwardedNode(ip.String()))
+			} else {
+				prior = append(prior, "for=unknown")
+			}
+		}
+	}
+	if len(prior) > 0 {
+		hop = strings.Join(prior, ", ") + ", " + hop
+	}
+	r.Header.Set("Forwarded", hop)
+	r.Header.Set("X-Real-IP", client)
+}
+
+// IPv6 в Forwarded записывается в кавычках и квадратных скобках
+func forwardedNode(ip string) string {
+	if strings.Contains(ip, ":") {
+		return `"[` + ip + `]"`
+	}
+	return ip
+}
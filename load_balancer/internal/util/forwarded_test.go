@@ -0,0 +1,167 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func withTrusted(t *testing.T, header string, entries ...string) {
+	t.Helper()
+	if err := SetTrustedProxies(entries, header); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { trustedProxies.Store(nil) })
+}
+
+func request(remote string, headers map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "http://lb.example/api", nil)
+	r.RemoteAddr = remote
+	for key, value := range headers {
+		r.Header.Set(key, value)
+	}
+	return r
+}
+
+func TestGetClientIP(t *testing.T) {
+	withTrusted(t, HeaderXForwardedFor, "10.0.0.0/8", "192.0.2.1")
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.5:1234",
+			map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Real-IP": "2.2.2.2", "Forwarded": "for=3.3.3.3"},
+			"203.0.113.5"},
+		{"no headers", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"x-real-ip from trusted peer", "10.0.0.1:1234",
+			map[string]string{"X-Real-IP": "198.51.100.7"}, "198.51.100.7"},
+		{"rightmost untrusted xff entry", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.7, 10.1.1.1"}, "198.51.100.7"},
+		{"all xff hops trusted", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "10.2.2.2, 192.0.2.1"}, "10.2.2.2"},
+		{"xff with port", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.7:5555"}, "198.51.100.7"},
+		// nginx дописывает только X-Forwarded-For, Forwarded клиента проходит как есть
+		{"spoofed forwarded is ignored", "10.0.0.1:1234",
+			map[string]string{"Forwarded": "for=1.2.3.4", "X-Forwarded-For": "198.51.100.7"}, "198.51.100.7"},
+		{"forwarded without xff", "10.0.0.1:1234",
+			map[string]string{"Forwarded": "for=1.2.3.4"}, "10.0.0.1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := GetClientIP(request(tc.remote, tc.headers)); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGetClientIPForwarded(t *testing.T) {
+	withTrusted(t, HeaderForwarded, "10.0.0.0/8")
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"rightmost untrusted node", "10.0.0.1:1234",
+			map[string]string{
+				"Forwarded":       `for=6.6.6.6, for="[2001:db8::7]:4711";proto=https, for=10.3.3.3`,
+				"X-Forwarded-For": "5.5.5.5",
+			}, "2001:db8::7"},
+		{"hidden node stops the chain", "10.0.0.1:1234",
+			map[string]string{"Forwarded": "for=6.6.6.6, for=_gateway, for=10.3.3.3"}, "10.3.3.3"},
+		{"xff is ignored", "10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "5.5.5.5"}, "10.0.0.1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := GetClientIP(request(tc.remote, tc.headers)); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGetClientIPTrustsNobodyByDefault(t *testing.T) {
+	r := request("10.0.0.1:1234", map[string]string{"X-Real-IP": "1.1.1.1", "X-Forwarded-For": "1.1.1.1"})
+	if got := GetClientIP(r); got != "10.0.0.1" {
+		t.Fatalf("expected connection IP, got %s", got)
+	}
+}
+
+func TestSetTrustedProxiesRejectsGarbage(t *testing.T) {
+	if err := SetTrustedProxies([]string{"10.0.0.0/8", "proxy.local"}, HeaderXForwardedFor); err == nil {
+		t.Fatal("expected error for hostname entry")
+	}
+	if err := SetTrustedProxies([]string{"10.0.0.0/8"}, "x-real-ip"); err == nil {
+		t.Fatal("expected error for unknown header")
+	}
+}
+
+func TestAppendForwarded(t *testing.T) {
+	withTrusted(t, HeaderForwarded, "10.0.0.0/8")
+
+	t.Run("untrusted client headers are dropped", func(t *testing.T) {
+		r := request("203.0.113.5:1234", map[string]string{"Forwarded": "for=1.1.1.1", "X-Forwarded-For": "1.1.1.1"})
+		AppendForwarded(r)
+
+		if got := r.Header.Get("Forwarded"); got != `for=203.0.113.5;proto=http;host="lb.example"` {
+			t.Fatalf("unexpected Forwarded: %s", got)
+		}
+		if r.Header.Get("X-Forwarded-For") != "" {
+			t.Fatal("expected client X-Forwarded-For to be dropped")
+		}
+		if got := r.Header.Get("X-Real-IP"); got != "203.0.113.5" {
+			t.Fatalf("expected X-Real-IP of the client, got %s", got)
+		}
+	})
+
+	t.Run("trusted proxy chain is extended", func(t *testing.T) {
+		r := request("10.0.0.2:1234", map[string]string{"Forwarded": "for=198.51.100.7;proto=https"})
+		AppendForwarded(r)
+
+		got := r.Header.Get("Forwarded")
+		if !strings.HasPrefix(got, "for=198.51.100.7;proto=https, for=10.0.0.2;") {
+			t.Fatalf("expected own hop appended, got %s", got)
+		}
+		if ip := r.Header.Get("X-Real-IP"); ip != "198.51.100.7" {
+			t.Fatalf("expected X-Real-IP 198.51.100.7, got %s", ip)
+		}
+	})
+
+	t.Run("ipv6 peer is quoted", func(t *testing.T) {
+		r := request("[2001:db8::1]:1234", nil)
+		AppendForwarded(r)
+
+		if got := r.Header.Get("Forwarded"); !strings.HasPrefix(got, `for="[2001:db8::1]";`) {
+			t.Fatalf("expected quoted IPv6 node, got %s", got)
+		}
+	})
+}
+
+func TestAppendForwardedFromXFF(t *testing.T) {
+	withTrusted(t, HeaderXForwardedFor, "10.0.0.0/8")
+
+	// Forwarded клиента за nginx заменяется цепочкой из X-Forwarded-For
+	r := request("10.0.0.2:1234", map[string]string{
+		"Forwarded":       "for=1.2.3.4",
+		"X-Forwarded-For": "198.51.100.7, 2001:db8::7",
+	})
+	AppendForwarded(r)
+
+	got := r.Header.Get("Forwarded")
+	if !strings.HasPrefix(got, `for=198.51.100.7, for="[2001:db8::7]", for=10.0.0.2;`) {
+		t.Fatalf("expected chain from X-Forwarded-For, got %s", got)
+	}
+	if ip := r.Header.Get("X-Real-IP"); ip != "2001:db8::7" {
+		t.Fatalf("expected X-Real-IP 2001:db8::7, got %s", ip)
+	}
+}
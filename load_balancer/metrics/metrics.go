@@ -1,6 +1,9 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
 
 var (
 	// общие HTTP-метрики
@@ -32,17 +35,17 @@ var (
 	ProxiedRequestCount = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "proxied_requests_total",
-			Help: "Total number of requests proxied to each backend.",
+			Help: "Total number of requests proxied to each backend (label group is the traffic split group).",
 		},
-		[]string{"backend"},
+		[]string{"backend", "group"},
 	)
 
 	ProxiedFailuresTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "proxied_failures_total",
-			Help: "Number of failed proxy attempts per backend.",
+			Help: "Number of failed proxy attempts per backend and traffic split group.",
 		},
-		[]string{"backend"},
+		[]string{"backend", "group"},
 	)
 
 	BackendResponseStatus = prometheus.NewCounterVec(
@@ -60,6 +63,151 @@ var (
 		},
 		[]string{"backend"},
 	)
+
+	BackendEjections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backend_ejections_total",
+			Help: "Number of times a backend was ejected by passive outlier detection.",
+		},
+		[]string{"backend"},
+	)
+
+	BreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "backend_circuit_breaker_state",
+			Help: "Circuit breaker state per backend (0 - closed, 1 - open, 2 - half-open).",
+		},
+		[]string{"backend"},
+	)
+
+	LimiterDegraded = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_limiter_degraded",
+			Help: "Whether Redis is unreachable and the rate limiter uses its failure policy (1) or not (0).",
+		},
+	)
+
+	// метрики запасных запросов
+	HedgesFired = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hedged_requests_total",
+			Help: "Number of hedged GET requests sent to a second backend.",
+		},
+	)
+
+	HedgesWon = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hedged_requests_won_total",
+			Help: "Number of hedged GET requests that answered the client before the original attempt.",
+		},
+	)
+
+	HedgesBudgetExhausted = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hedged_requests_budget_exhausted_total",
+			Help: "Number of hedges not sent because the hedge budget was exhausted.",
+		},
+	)
+
+	HedgeDelay = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hedge_delay_seconds",
+			Help: "Current delay before a hedged request is sent (latency percentile of recent responses).",
+		},
+	)
+
+	// метрики адаптивного предела одновременных запросов
+	ConcurrencyLimit = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "adaptive_concurrency_limit",
+			Help: "Current adaptive limit of concurrent requests to the backends.",
+		},
+	)
+
+	ConcurrencyInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "adaptive_concurrency_in_flight",
+			Help: "Current number of requests counted against the adaptive concurrency limit.",
+		},
+	)
+
+	ShedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shed_requests_total",
+			Help: "Number of requests rejected with 503 by the adaptive concurrency limit per priority class.",
+		},
+		[]string{"class"},
+	)
+
+	// метрики кэша ответов
+	CacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Number of GET/HEAD requests served from the response cache (including 304).",
+		},
+	)
+
+	CacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Number of GET/HEAD requests forwarded to a backend because no fresh cached response was found.",
+		},
+	)
+
+	CacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cache_size_bytes",
+			Help: "Approximate size of the responses stored in the cache.",
+		},
+	)
+
+	CacheEntries = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cache_entries",
+			Help: "Number of responses stored in the cache.",
+		},
+	)
+
+	// метрики копирования трафика в теневой пул
+	MirrorRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mirror_requests_total",
+			Help: "Number of mirrored requests by primary and shadow pool status codes.",
+		},
+		[]string{"primary", "shadow"},
+	)
+
+	MirrorLatencyDiff = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "mirror_latency_difference_seconds",
+			Help:    "Shadow pool response time minus primary response time for mirrored requests.",
+			Buckets: []float64{-2.5, -1, -0.5, -0.25, -0.1, -0.05, -0.01, 0, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+	)
+
+	MirrorSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mirror_skipped_total",
+			Help: "Number of sampled requests not mirrored (reason busy - concurrency limit, body - body too large).",
+		},
+		[]string{"reason"},
+	)
+
+	// метрики перезагрузки конфига
+	ConfigReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "Number of config reload attempts by result (success, rejected).",
+		},
+		[]string{"result"},
+	)
+
+	ConfigLastReloadSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_last_reload_successful",
+			Help: "Whether the last config reload attempt was successful (1) or rejected (0).",
+		},
+	)
 )
 
 func Init() {
@@ -71,5 +219,33 @@ func Init() {
 		ProxiedFailuresTotal,
 		BackendResponseStatus,
 		BackendConnections,
+		BackendEjections,
+		BreakerState,
+		LimiterDegraded,
+		HedgesFired,
+		HedgesWon,
+		HedgesBudgetExhausted,
+		HedgeDelay,
+		ConcurrencyLimit,
+		ConcurrencyInFlight,
+		ShedRequests,
+		CacheHits,
+		CacheMisses,
+		CacheSize,
+		CacheEntries,
+		MirrorRequests,
+		MirrorLatencyDiff,
+		MirrorSkipped,
+		ConfigReloadsTotal,
+		ConfigLastReloadSuccess,
 	)
 }
+
+// CounterValue - текущее значение счётчика
+func CounterValue(c prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	if err := c.Write(m); err != nil {
+		return 0
+	}
+	return m.GetCounter().GetValue()
+}
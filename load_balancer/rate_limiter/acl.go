@@ -0,0 +1,228 @@
+package ratelimiter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"load_balancer/internal/messages"
+)
+
+// списки доступа
+const (
+	ListAllow = "allow" // запросы не ограничиваются
+	ListDeny  = "deny"  // запросы отклоняются
+)
+
+// Verdict - решение списка доступа по IP клиента
+type Verdict int
+
+const (
+	VerdictNone  Verdict = iota // IP нет в списках, действуют лимиты
+	VerdictAllow                // IP в allowlist
+	VerdictDeny                 // IP в denylist или забанен
+)
+
+// ErrBadEntry - запись списка не IP и не подсеть
+var ErrBadEntry = errors.New(messages.ErrBadACLEntry)
+
+// ACLEntry - запись списка доступа для админского API
+type ACLEntry struct {
+	Entry string     `json:"entry"` // IP или подсеть в нотации CIDR
+	List  string     `json:"list"`  // ListAllow или ListDeny
+	Until *time.Time `json:"until,omitempty"`
+}
+
+type aclEntry struct {
+	network *net.IPNet
+	until   time.Time // нулевое - бессрочно
+}
+
+// accessList - allowlist и denylist по IP и подсетям; бан - запись denylist со сроком.
+// Хранится в памяти реплики, постоянные записи задаются в конфиге.
+// Записи со сроком лежат отдельно от постоянных: бан подсети из конфига
+// не заменяет её постоянную запись, а его снятие не удаляет её
+type accessList struct {
+	mu    sync.RWMutex
+	lists map[string]map[string]aclEntry // список -> запись -> подсеть, бессрочно
+	temp  map[string]map[string]aclEntry // список -> запись -> подсеть со сроком
+	now   func() time.Time
+}
+
+var _ AccessIface = &accessList{}
+
+func NewAccessList() *accessList {
+	return &accessList{
+		lists: map[string]map[string]aclEntry{
+			ListAllow: {},
+			ListDeny:  {},
+		},
+		temp: map[string]map[string]aclEntry{
+			ListAllow: {},
+			ListDeny:  {},
+		},
+		now: time.Now,
+	}
+}
+
+// IP приводится к подсети из одного адреса
+func parseEntry(entry string) (*net.IPNet, error) {
+	if !strings.Contains(entry, "/") {
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("%w: %s", ErrBadEntry, entry)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadEntry, entry)
+	}
+	return network, nil
+}
+
+// Add - запись с ttl > 0 временная, повторная продлевает срок
+func (a *accessList) Add(list, entry string, ttl time.Duration) error {
+	network, err := parseEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries, ok := a.lists[list]
+	if !ok {
+		return fmt.Errorf(messages.ErrUnknownACL, list)
+	}
+
+	e := aclEntry{network: network}
+	if ttl > 0 {
+		e.until = a.now().Add(ttl)
+		entries = a.temp[list]
+	}
+	entries[network.String()] = e
+	return nil
+}
+
+// Remove - сначала снимается временная запись, постоянная удаляется,
+// только если временной нет
+func (a *accessList) Remove(list, entry string) error {
+	network, err := parseEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries, ok := a.lists[list]
+	if !ok {
+		return fmt.Errorf(messages.ErrUnknownACL, list)
+	}
+
+	key := network.String()
+	if _, ok := a.temp[list][key]; ok {
+		entries = a.temp[list]
+	} else if _, ok := entries[key]; !ok {
+		return fmt.Errorf(messages.ErrACLEntryNotFound, entry)
+	}
+
+	delete(entries, key)
+	return nil
+}
+
+// Check - denylist важнее allowlist; истёкшие баны не учитываются и удаляются
+func (a *accessList) Check(clientIP string) Verdict {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return VerdictNone
+	}
+
+	a.mu.RLock()
+	now := a.now()
+	expired := false
+	match := func(list string) bool {
+		for _, e := range a.lists[list] {
+			if e.network.Contains(ip) {
+				return true
+			}
+		}
+		for _, e := range a.temp[list] {
+			if !now.Before(e.until) {
+				expired = true
+				continue
+			}
+			if e.network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	verdict := VerdictNone
+	switch {
+	case match(ListDeny):
+		verdict = VerdictDeny
+	case match(ListAllow):
+		verdict = VerdictAllow
+	}
+	a.mu.RUnlock()
+
+	if expired {
+		a.mu.Lock()
+		a.prune(now)
+		a.mu.Unlock()
+	}
+	return verdict
+}
+
+// prune удаляет истёкшие временные записи, вызывается под a.mu.Lock
+func (a *accessList) prune(now time.Time) {
+	for _, entries := range a.temp {
+		for key, e := range entries {
+			if !now.Before(e.until) {
+				delete(entries, key)
+			}
+		}
+	}
+}
+
+// List - действующие записи; истёкшие баны удаляются.
+// Подсеть может встречаться дважды: постоянной записью и баном со сроком
+func (a *accessList) List() []ACLEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.prune(a.now())
+
+	var result []ACLEntry
+	for list, entries := range a.lists {
+		for key := range entries {
+			result = append(result, ACLEntry{Entry: key, List: list})
+		}
+	}
+	for list, entries := range a.temp {
+		for key, e := range entries {
+			until := e.until
+			result = append(result, ACLEntry{Entry: key, List: list, Until: &until})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].List != result[j].List {
+			return result[i].List < result[j].List
+		}
+		return result[i].Entry < result[j].Entry
+	})
+	return result
+}
@@ -0,0 +1,132 @@
+package ratelimiter
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAccessListCheck(t *testing.T) {
+	acl := NewAccessList()
+	for list, entries := range map[string][]string{
+		ListAllow: {"10.0.0.0/8", "2001:db8::1"},
+		ListDeny:  {"10.1.2.3"},
+	} {
+		for _, entry := range entries {
+			if err := acl.Add(list, entry, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	cases := map[string]Verdict{
+		"10.5.5.5":    VerdictAllow,
+		"10.1.2.3":    VerdictDeny, // denylist важнее allowlist
+		"2001:db8::1": VerdictAllow,
+		"192.168.0.1": VerdictNone,
+		"not-an-ip":   VerdictNone,
+	}
+	for ip, want := range cases {
+		if got := acl.Check(ip); got != want {
+			t.Errorf("%s: expected verdict %d, got %d", ip, want, got)
+		}
+	}
+}
+
+func TestAccessListBanExpires(t *testing.T) {
+	now := time.Now()
+	acl := NewAccessList()
+	acl.now = func() time.Time { return now }
+
+	if err := acl.Add(ListDeny, "1.2.3.4", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if acl.Check("1.2.3.4") != VerdictDeny {
+		t.Fatal("expected banned IP to be denied")
+	}
+	if entries := acl.List(); len(entries) != 1 || entries[0].Until == nil {
+		t.Fatalf("expected one ban with expiry, got %+v", entries)
+	}
+
+	now = now.Add(time.Minute)
+	if acl.Check("1.2.3.4") != VerdictNone {
+		t.Fatal("expected ban to expire")
+	}
+	if entries := acl.List(); len(entries) != 0 {
+		t.Fatalf("expected expired ban to be dropped, got %+v", entries)
+	}
+}
+
+// бан подсети с постоянной записью в конфиге не заменяет её:
+// после истечения или снятия бана подсеть остаётся в denylist
+func TestAccessListBanOverConfig(t *testing.T) {
+	now := time.Now()
+	acl := NewAccessList()
+	acl.now = func() time.Time { return now }
+
+	if err := acl.Add(ListDeny, "10.0.0.0/8", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := acl.Add(ListDeny, "10.0.0.0/8", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if entries := acl.List(); len(entries) != 2 || entries[0].Until != nil || entries[1].Until == nil {
+		t.Fatalf("expected permanent entry and ban, got %+v", entries)
+	}
+
+	if err := acl.Remove(ListDeny, "10.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	if acl.Check("10.1.2.3") != VerdictDeny {
+		t.Fatal("expected config entry to outlive the removed ban")
+	}
+
+	if err := acl.Add(ListDeny, "10.0.0.0/8", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	if acl.Check("10.1.2.3") != VerdictDeny {
+		t.Fatal("expected config entry to outlive the expired ban")
+	}
+	if entries := acl.List(); len(entries) != 1 || entries[0].Until != nil {
+		t.Fatalf("expected only the permanent entry, got %+v", entries)
+	}
+}
+
+// Check удаляет истёкшие баны и без вызова List
+func TestAccessListCheckPrunes(t *testing.T) {
+	now := time.Now()
+	acl := NewAccessList()
+	acl.now = func() time.Time { return now }
+
+	for _, ip := range []string{"1.2.3.4", "5.6.7.8"} {
+		if err := acl.Add(ListDeny, ip, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	acl.Check("192.168.0.1")
+	if n := len(acl.temp[ListDeny]); n != 0 {
+		t.Fatalf("expected expired bans to be pruned by Check, %d left", n)
+	}
+}
+
+func TestAccessListErrors(t *testing.T) {
+	acl := NewAccessList()
+	if err := acl.Add(ListAllow, "example.com", 0); !errors.Is(err, ErrBadEntry) {
+		t.Fatalf("expected ErrBadEntry, got %v", err)
+	}
+	if err := acl.Add("grey", "1.2.3.4", 0); err == nil {
+		t.Fatal("expected error for unknown list")
+	}
+	if err := acl.Remove(ListDeny, "1.2.3.4"); err == nil {
+		t.Fatal("expected error removing missing entry")
+	}
+
+	// запись удаляется в той же форме, в какой была бы добавлена
+	acl.Add(ListDeny, "10.0.0.1/8", 0) //nolint:errcheck
+	if err := acl.Remove(ListDeny, "10.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,98 @@
+package ratelimiter
+
+import (
+	"sync"
+	"time"
+
+	"load_balancer/metrics"
+)
+
+// AdaptiveConfig - параметры адаптивного предела одновременных запросов к серверам
+type AdaptiveConfig struct {
+	InitialLimit     int           // предел при запуске
+	MinLimit         int           // предел не опускается ниже
+	MaxLimit         int           // предел не поднимается выше
+	LatencyThreshold time.Duration // ответ дольше считается признаком перегрузки серверов
+	Backoff          float64       // множитель предела при перегрузке, например 0.9
+}
+
+// Adaptive - общий для всех клиентов предел одновременных запросов (AIMD): каждый
+// быстрый ответ поднимает предел на 1/limit (на единицу за limit ответов), медленный
+// ответ или 5xx умножает его на Backoff. Предел снижается не чаще раза за «поколение»
+// запросов: ответы запросов, начатых до прошлого снижения, его не снижают повторно
+type Adaptive struct {
+	mu           sync.Mutex
+	cfg          AdaptiveConfig
+	limit        float64
+	inFlight     int
+	lastDecrease time.Time
+}
+
+func NewAdaptive(cfg AdaptiveConfig) *Adaptive {
+	cfg.MinLimit = max(cfg.MinLimit, 1)
+	cfg.MaxLimit = max(cfg.MaxLimit, cfg.MinLimit)
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		cfg.Backoff = 0.9
+	}
+
+	a := &Adaptive{
+		cfg:   cfg,
+		limit: float64(min(max(cfg.InitialLimit, cfg.MinLimit), cfg.MaxLimit)),
+	}
+	metrics.ConcurrencyLimit.Set(a.limit)
+	return a
+}
+
+// Acquire - занять место для запроса, которому доступна доля share предела;
+// false - предел для этой доли исчерпан, запрос надо сбросить
+func (a *Adaptive) Acquire(share float64) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if float64(a.inFlight) >= max(a.limit*share, 1) {
+		return false
+	}
+	a.inFlight++
+	metrics.ConcurrencyInFlight.Set(float64(a.inFlight))
+	return true
+}
+
+// Release - освободить место запроса, начатого в start; latency - время до ответа
+// сервера, failed - сервер ответил 5xx
+func (a *Adaptive) Release(start time.Time, latency time.Duration, failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// рост только при нагрузке: простаивающий балансировщик не должен разгонять предел
+	busy := float64(a.inFlight) >= a.limit/2
+	a.inFlight--
+	metrics.ConcurrencyInFlight.Set(float64(a.inFlight))
+
+	switch {
+	case failed || (a.cfg.LatencyThreshold > 0 && latency > a.cfg.LatencyThreshold):
+		if start.Before(a.lastDecrease) {
+			return
+		}
+		a.limit = max(a.limit*a.cfg.Backoff, float64(a.cfg.MinLimit))
+		a.lastDecrease = time.Now()
+	case busy:
+		a.limit = min(a.limit+1/a.limit, float64(a.cfg.MaxLimit))
+	default:
+		return
+	}
+	metrics.ConcurrencyLimit.Set(a.limit)
+}
+
+// Limit - текущий предел
+func (a *Adaptive) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return int(a.limit)
+}
+
+// InFlight - запросов в обработке
+func (a *Adaptive) InFlight() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.inFlight
+}
@@ -0,0 +1,80 @@
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveShare(t *testing.T) {
+	a := NewAdaptive(AdaptiveConfig{InitialLimit: 10, MinLimit: 1, MaxLimit: 100, LatencyThreshold: time.Second})
+
+	for i := range 8 {
+		if !a.Acquire(0.8) {
+			t.Fatalf("request %d rejected below the share", i)
+		}
+	}
+	if a.Acquire(0.8) {
+		t.Fatal("request accepted over the share")
+	}
+	// класс с полной долей получает остаток предела
+	for range 2 {
+		if !a.Acquire(1) {
+			t.Fatal("critical request rejected below the limit")
+		}
+	}
+	if a.Acquire(1) {
+		t.Fatal("request accepted over the limit")
+	}
+	if got := a.InFlight(); got != 10 {
+		t.Fatalf("in flight %d, want 10", got)
+	}
+}
+
+func TestAdaptiveAIMD(t *testing.T) {
+	a := NewAdaptive(AdaptiveConfig{InitialLimit: 10, MinLimit: 2, MaxLimit: 12, LatencyThreshold: 100 * time.Millisecond, Backoff: 0.5})
+
+	// быстрые ответы под нагрузкой поднимают предел до maxLimit
+	for range 200 {
+		for a.Acquire(1) {
+		}
+		for a.InFlight() > 0 {
+			a.Release(time.Now(), time.Millisecond, false)
+		}
+	}
+	if got := a.Limit(); got != 12 {
+		t.Fatalf("limit %d after fast responses, want 12", got)
+	}
+
+	// без нагрузки предел не растёт
+	a = NewAdaptive(AdaptiveConfig{InitialLimit: 10, MinLimit: 2, MaxLimit: 12, LatencyThreshold: 100 * time.Millisecond, Backoff: 0.5})
+	for range 100 {
+		a.Acquire(1)
+		a.Release(time.Now(), time.Millisecond, false)
+	}
+	if got := a.Limit(); got != 10 {
+		t.Fatalf("limit %d after idle responses, want 10", got)
+	}
+
+	// медленные ответы запросов, начатых до снижения, снижают предел один раз
+	start := time.Now()
+	for range 5 {
+		a.Acquire(1)
+	}
+	for range 5 {
+		a.Release(start, time.Second, false)
+	}
+	if got := a.Limit(); got != 5 {
+		t.Fatalf("limit %d after one slow generation, want 5", got)
+	}
+
+	// каждое следующее поколение снижает его снова, но не ниже minLimit
+	for range 5 {
+		time.Sleep(time.Millisecond)
+		start := time.Now()
+		a.Acquire(1)
+		a.Release(start, time.Millisecond, true)
+	}
+	if got := a.Limit(); got != 2 {
+		t.Fatalf("limit %d after failures, want minLimit 2", got)
+	}
+}
@@ -0,0 +1,182 @@
+package ratelimiter
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"load_balancer/internal/logger"
+	"load_balancer/internal/messages"
+	"load_balancer/metrics"
+
+	"go.uber.org/zap"
+)
+
+// поведение при недоступном Redis для параметра limiter.onRedisFailure в конфиге
+const (
+	FailoverMemory = "memory" // считать лимиты в памяти реплики
+	FailOpen       = "open"   // пропускать все запросы
+	FailClosed     = "closed" // отклонять все запросы
+)
+
+// ErrUnavailable - хранилище лимитов недоступно, запрос отклонён (FailClosed)
+var ErrUnavailable = errors.New(messages.ErrLimiterUnavailable)
+
+// failover - хранилище Redis с запасным поведением на время его недоступности.
+// Ошибка Redis считается недоступностью, если не проходит и PING; после этого
+// Redis не опрашивается retry, чтобы запросы не ждали таймаутов подключения
+type failover[T any] struct {
+	primary  StateDB[T]
+	ping     func() error
+	fallback StateDB[T] // хранилище в памяти для FailoverMemory
+	policy   string
+	algo     Algorithm[T]
+	retry    time.Duration
+
+	mu      sync.Mutex
+	down    bool
+	retryAt time.Time
+}
+
+var _ BucketDB = &failover[Bucket]{}
+
+func newFailover[T any](primary StateDB[T], ping func() error, algo Algorithm[T], policy string, retry, ttl time.Duration) *failover[T] {
+	f := &failover[T]{
+		primary: primary,
+		ping:    ping,
+		policy:  policy,
+		algo:    algo,
+		retry:   retry,
+	}
+	if policy == FailoverMemory {
+		f.fallback = NewMemoryAdapter(algo, ttl)
+	}
+	return f
+}
+
+// можно ли обращаться к Redis
+func (f *failover[T]) available() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.down || !time.Now().Before(f.retryAt)
+}
+
+// учесть результат обращения к Redis, true - Redis недоступен
+func (f *failover[T]) report(err error) bool {
+	if errors.Is(err, ErrNotFound) || err != nil && f.ping() == nil {
+		// ошибка не связана с доступностью (нет данных, ошибка скрипта)
+		err = nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		if f.down {
+			f.down = false
+			metrics.LimiterDegraded.Set(0)
+			logger.Log.Info(messages.InfoLimiterRecovered)
+		}
+		return false
+	}
+
+	f.retryAt = time.Now().Add(f.retry)
+	if !f.down {
+		f.down = true
+		metrics.LimiterDegraded.Set(1)
+		logger.Log.Error(messages.ErrLimiterUnavailable,
+			zap.String(messages.Policy, f.policy),
+			zap.Error(err),
+		)
+	}
+	return true
+}
+
+func (f *failover[T]) FindOne(userIP string) (result T, err error) {
+	if f.available() {
+		result, err = f.primary.FindOne(userIP)
+		if !f.report(err) {
+			return result, err
+		}
+	}
+
+	if f.fallback == nil {
+		return result, ErrUnavailable
+	}
+	return f.fallback.FindOne(userIP)
+}
+
+func (f *failover[T]) InsertOne(userIP string, state T) error {
+	if f.available() {
+		err := f.primary.InsertOne(userIP, state)
+		if !f.report(err) {
+			return err
+		}
+	}
+
+	if f.fallback == nil {
+		return ErrUnavailable
+	}
+	return f.fallback.InsertOne(userIP, state)
+}
+
+func (f *failover[T]) UpdateOne(userIP string, update func(state *T)) error {
+	if f.available() {
+		err := f.primary.UpdateOne(userIP, update)
+		if !f.report(err) {
+			return err
+		}
+	}
+
+	if f.fallback == nil {
+		return ErrUnavailable
+	}
+	return f.fallback.UpdateOne(userIP, update)
+}
+
+func (f *failover[T]) DeleteOne(userIP string) error {
+	if f.available() {
+		err := f.primary.DeleteOne(userIP)
+		if !f.report(err) {
+			return err
+		}
+	}
+
+	if f.fallback == nil {
+		return ErrUnavailable
+	}
+	return f.fallback.DeleteOne(userIP)
+}
+
+func (f *failover[T]) List() (map[string]T, error) {
+	if f.available() {
+		result, err := f.primary.List()
+		if !f.report(err) {
+			return result, err
+		}
+	}
+
+	if f.fallback == nil {
+		return nil, ErrUnavailable
+	}
+	return f.fallback.List()
+}
+
+func (f *failover[T]) Take(userIP string, defaults T, cost int) (status Status, ok bool, err error) {
+	if f.available() {
+		status, ok, err = f.primary.Take(userIP, defaults, cost)
+		if !f.report(err) {
+			return status, ok, err
+		}
+	}
+
+	switch f.policy {
+	case FailoverMemory:
+		return f.fallback.Take(userIP, defaults, cost)
+	case FailOpen:
+		limit := f.algo.Limits(&defaults).MaxTokens
+		return Status{Limit: limit, Remaining: limit}, true, nil
+	default:
+		return Status{}, false, ErrUnavailable
+	}
+}
@@ -0,0 +1,103 @@
+package ratelimiter
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("connection refused")
+
+// flakyDB - хранилище, которое можно «отключить»
+type flakyDB struct {
+	BucketDB
+	down bool
+	hits int
+}
+
+func (f *flakyDB) Take(userIP string, defaults Bucket, cost int) (Status, bool, error) {
+	f.hits++
+	if f.down {
+		return Status{}, false, errDown
+	}
+	return f.BucketDB.Take(userIP, defaults, cost)
+}
+
+func (f *flakyDB) ping() error {
+	if f.down {
+		return errDown
+	}
+	return nil
+}
+
+func newFlaky(policy string, retry time.Duration) (*flakyDB, *failover[Bucket]) {
+	primary := &flakyDB{BucketDB: NewMemoryAdapter[Bucket](tokenBucket{}, 0), down: true}
+	return primary, newFailover[Bucket](primary, primary.ping, tokenBucket{}, policy, retry, 0)
+}
+
+func TestFailoverPolicies(t *testing.T) {
+	defaults := fullBucket(2)
+
+	t.Run(FailoverMemory, func(t *testing.T) {
+		_, db := newFlaky(FailoverMemory, time.Minute)
+		for i, want := range []bool{true, true, false} {
+			if _, ok, err := db.Take("ip", defaults, 1); err != nil || ok != want {
+				t.Fatalf("request %d: expected allowed=%v from memory, got %v %v", i, want, ok, err)
+			}
+		}
+	})
+
+	t.Run(FailOpen, func(t *testing.T) {
+		_, db := newFlaky(FailOpen, time.Minute)
+		for i := 0; i < 5; i++ {
+			if _, ok, err := db.Take("ip", defaults, 1); err != nil || !ok {
+				t.Fatalf("request %d: expected allowed, got %v %v", i, ok, err)
+			}
+		}
+	})
+
+	t.Run(FailClosed, func(t *testing.T) {
+		_, db := newFlaky(FailClosed, time.Minute)
+		if _, _, err := db.Take("ip", defaults, 1); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+	})
+}
+
+func TestFailoverRetriesAndRecovers(t *testing.T) {
+	primary, db := newFlaky(FailClosed, time.Hour)
+
+	db.Take("ip", fullBucket(2), 1) //nolint:errcheck
+	db.Take("ip", fullBucket(2), 1) //nolint:errcheck
+	if primary.hits != 1 {
+		t.Fatalf("expected unavailable Redis not to be queried until retry, got %d hits", primary.hits)
+	}
+
+	// время повтора наступило
+	db.retryAt = time.Now()
+	primary.down = false
+	if _, ok, err := db.Take("ip", fullBucket(2), 1); err != nil || !ok {
+		t.Fatalf("expected request through recovered Redis, got %v %v", ok, err)
+	}
+	if db.down {
+		t.Fatal("expected failover to leave degraded mode")
+	}
+}
+
+func TestNewFailsClosedWithoutRedis(t *testing.T) {
+	rl, err := New(Config{
+		Storage:        StorageRedis,
+		RedisAddr:      "127.0.0.1:1",
+		MaxTokens:      5,
+		Rate:           1,
+		OnRedisFailure: FailClosed,
+		RedisRetry:     time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rl.RemoveToken("ip"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+}
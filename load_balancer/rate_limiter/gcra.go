@@ -0,0 +1,69 @@
+package ratelimiter
+
+import "github.com/go-redis/redis"
+
+// GCRA - generic cell rate algorithm: вместо счётчика хранится теоретическое время
+// прихода следующего запроса (TAT). Каждый запрос сдвигает TAT на Rate секунд,
+// запрос пропускается, если TAT опережает текущее время не больше чем на MaxTokens*Rate
+const GCRA = "gcra"
+
+type Cell struct {
+	Limits
+	TAT int64 `json:"tat"` // теоретическое время прихода, мс
+}
+
+type gcra struct{}
+
+var _ Algorithm[Cell] = gcra{}
+
+func (gcra) New(limits Limits) Cell {
+	return Cell{Limits: limits}
+}
+
+func (gcra) Limits(c *Cell) *Limits {
+	return &c.Limits
+}
+
+// должно совпадать с gcraScript
+func (gcra) Take(c *Cell, now int64, cost int) bool {
+	c.TAT = max(c.TAT, now)
+
+	next := c.TAT + int64(cost)*interval(c.Rate)
+	if next > now+window(c.Limits) {
+		return false
+	}
+
+	c.TAT = next
+	return true
+}
+
+// TAT после Take не раньше now; весь всплеск доступен, когда TAT совпадёт с текущим временем
+func (gcra) Status(c *Cell, now int64, cost int) Status {
+	step := interval(c.Rate)
+	limit := now + window(c.Limits)
+
+	return Status{
+		Limit:      c.MaxTokens,
+		Remaining:  int(max(0, limit-c.TAT) / step),
+		Reset:      millis(c.TAT - now),
+		RetryAfter: millis(c.TAT + int64(cost)*step - limit),
+	}
+}
+
+func (gcra) Reset(c *Cell) {
+	c.TAT = 0
+}
+
+func (gcra) Script() *redis.Script {
+	return gcraScript
+}
+
+var gcraScript = newScript(`
+local tat = math.max(tonumber(b.tat) or 0, now)
+if tat + cost * interval <= now + max * interval then
+	tat = tat + cost * interval
+	ok = 1
+end
+
+b.tat = tat
+`)
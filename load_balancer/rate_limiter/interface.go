@@ -1,21 +1,54 @@
 package ratelimiter
 
-import "context"
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+)
 
 type BucketIface interface {
-	GetTokens(userIP string) (int, error)      //получить текущее кол-во токенов пользователя
-	AddToken(userIP string)                    //добавить токен и запустить тикер для добавления токенов
-	RemoveToken(userIP string) error           //забрать токен
+	GetTokens(userIP string) (int, error)      //получить текущее кол-во токенов (доступных запросов) пользователя
+	RemoveToken(userIP string) (Status, error) //забрать токен (состояние создаётся при первом запросе), вернуть состояние лимита
 	GetMaxTokens(userIP string) (int, error)   //получить макс кол-во токенов
 	GetRate(userIP string) (int, error)        //получить скорость восстановления токенов
 	SetMaxTokens(userIP string, max int) error //установить макс кол-во токенов
 	SetRate(userIP string, rate int) error     //установить скорость восстановления токенов
 	AddUser(userIP string) error               //добавить пользователя
-	StopAllTickers(ctx context.Context)        //остановить все тикеры добавления токенов
+	SetDefaults(maxTokens, rate int)           //установить параметры для новых пользователей
+	Get(userIP string) (BucketInfo, error)     //получить лимиты и состояние пользователя
+	List() ([]BucketInfo, error)               //получить всех пользователей
+	Reset(userIP string) error                 //восстановить пользователю весь всплеск, сохранив лимиты
+	Delete(userIP string) error                //удалить пользователя, следующий запрос создаст его с параметрами по умолчанию
+}
+
+// StateDB - хранилище состояния лимитера по IP пользователя, T - состояние алгоритма
+type StateDB[T any] interface {
+	FindOne(userIP string) (result T, err error)
+	InsertOne(userIP string, state T) error
+	UpdateOne(userIP string, update func(state *T)) error //атомарно изменить состояние
+	DeleteOne(userIP string) error
+	List() (map[string]T, error)
+	Take(userIP string, defaults T, cost int) (status Status, ok bool, err error) //атомарно списать cost запросов
+}
+
+// BucketDB - хранилище корзин токенов
+type BucketDB = StateDB[Bucket]
+
+// Algorithm - алгоритм ограничения запросов. Take выполняется хранилищем атомарно:
+// в памяти - под мьютексом, в Redis - Lua-скриптом Script с той же логикой
+type Algorithm[T any] interface {
+	New(limits Limits) T                         //состояние нового пользователя
+	Limits(state *T) *Limits                     //лимиты пользователя
+	Take(state *T, now int64, cost int) bool     //обновить состояние на момент now (мс) и списать cost запросов
+	Status(state *T, now int64, cost int) Status //состояние лимита после Take на момент now
+	Reset(state *T)                              //сбросить учтённые запросы, сохранив лимиты
+	Script() *redis.Script                       //Take для Redis
 }
 
-type BucketDB interface {
-	FindOne(userIP string) (result Bucket, err error)
-	InsertOne(userIP string, bucket Bucket) error
-	UpdateOne(userIP string, updatedBucket Bucket) error
+// AccessIface - списки доступа по IP клиента, проверяются до лимитов
+type AccessIface interface {
+	Add(list, entry string, ttl time.Duration) error //добавить IP или подсеть в список, ttl > 0 - временно (бан)
+	Remove(list, entry string) error                 //удалить запись из списка, временную раньше постоянной
+	Check(clientIP string) Verdict                   //проверить IP клиента
+	List() []ACLEntry                                //получить действующие записи
 }
@@ -1,203 +1,299 @@
 package ratelimiter
 
 import (
-	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
-	"load_balancer/internal/logger"
 	"load_balancer/internal/messages"
 
 	"github.com/go-redis/redis"
-	"go.uber.org/zap"
 )
 
-type tokenBucket struct {
-	DB               BucketDB
-	tickers          map[string]*time.Ticker
-	mu               sync.RWMutex
-	defaultMaxTokens int
-	defaultRate      int
-}
+// ErrNoTokens - у пользователя закончились токены
+var ErrNoTokens = errors.New(messages.ErrNoAvailableToken)
 
-type Bucket struct {
-	Rate      int `json:"refillRate"`
-	MaxTokens int `json:"maxTokens"`
-	Current   int `json:"currentTokens"`
+// ErrNotFound - у пользователя нет состояния в хранилище
+var ErrNotFound = errors.New(messages.ErrNoData)
+
+func notFound(userIP string) error {
+	return fmt.Errorf(messages.ErrForIP, ErrNotFound, userIP)
 }
 
-var _ BucketIface = &tokenBucket{}
+// хранилища состояния для параметра limiter.storage в конфиге
+const (
+	StorageRedis  = "redis"
+	StorageMemory = "memory"
+)
 
-func NewBucket(redisAddr string, defaultMaxTokens, defaultRate int) *tokenBucket {
-	db := redis.NewClient(&redis.Options{Addr: redisAddr})
-	tb := &tokenBucket{
-		DB:               &RedisAdapter{Client: db},
-		tickers:          make(map[string]*time.Ticker),
-		defaultMaxTokens: defaultMaxTokens,
-		defaultRate:      defaultRate,
+// KeyPrefix - общий префикс ключей лимитеров в Redis, за ним следует имя лимитера
+const KeyPrefix = "ratelimit:"
+
+// DefaultName - имя общего лимитера, к которому относятся запросы вне политик
+const DefaultName = "default"
+
+// Config - параметры ограничителя запросов
+type Config struct {
+	Name      string // имя лимитера (политики), пусто - DefaultName
+	Algorithm string // TokenBucket, SlidingWindow, SlidingLog или GCRA
+	Storage   string // StorageRedis или StorageMemory
+	RedisAddr string
+	MaxTokens int // лимиты новых пользователей
+	Rate      int
+
+	TTL            time.Duration // вытеснение простаивающих IP из памяти и срок ключей Redis, 0 - не вытеснять
+	OnRedisFailure string        // FailoverMemory, FailOpen, FailClosed; пусто - вернуть ошибку Redis
+	RedisRetry     time.Duration // через сколько снова обращаться к недоступному Redis
+}
+
+// создать ограничитель по алгоритму и хранилищу из конфига, по умолчанию - корзина токенов в Redis
+func New(cfg Config) (BucketIface, error) {
+	switch cfg.Algorithm {
+	case "", TokenBucket:
+		return build[Bucket](cfg, tokenBucket{})
+	case SlidingWindow:
+		return build[Window](cfg, slidingWindow{})
+	case SlidingLog:
+		return build[Log](cfg, slidingLog{})
+	case GCRA:
+		return build[Cell](cfg, gcra{})
+	default:
+		return nil, fmt.Errorf(messages.ErrUnknownAlgorithm, cfg.Algorithm)
 	}
+}
 
-	go func() {
-		keys, err := db.Keys("*").Result()
-		if err != nil {
-			logger.Log.Error(messages.ErrGetKeys, zap.Error(err))
-			return
+func build[T any](cfg Config, algo Algorithm[T]) (BucketIface, error) {
+	var db StateDB[T]
+	switch cfg.Storage {
+	case "", StorageRedis:
+		name := cfg.Name
+		if name == "" {
+			name = DefaultName
 		}
 
-		for _, key := range keys {
-			tb.AddToken(key)
+		client := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr})
+		db = &RedisAdapter[T]{
+			Client: client,
+			Algo:   algo,
+			Prefix: KeyPrefix + name + ":",
+			TTL:    cfg.TTL,
 		}
-	}()
 
-	return tb
+		switch cfg.OnRedisFailure {
+		case "":
+		case FailoverMemory, FailOpen, FailClosed:
+			ping := func() error { return client.Ping().Err() }
+			db = newFailover(db, ping, algo, cfg.OnRedisFailure, cfg.RedisRetry, cfg.TTL)
+		default:
+			return nil, fmt.Errorf(messages.ErrUnknownFailover, cfg.OnRedisFailure)
+		}
+	case StorageMemory:
+		db = NewMemoryAdapter(algo, cfg.TTL)
+	default:
+		return nil, fmt.Errorf(messages.ErrUnknownStorage, cfg.Storage)
+	}
+
+	return newLimiter(db, algo, cfg.MaxTokens, cfg.Rate), nil
 }
 
-func (tb *tokenBucket) AddUser(userIP string) error {
-	bckt := Bucket{
-		Rate:      tb.defaultRate,
-		MaxTokens: tb.defaultMaxTokens,
-		Current:   1,
-	}
+// Limits - лимиты пользователя, общие для всех алгоритмов:
+// в среднем один запрос раз в Rate секунд, всплеск - до MaxTokens запросов
+type Limits struct {
+	Rate      int `json:"refillRate"` // раз во сколько секунд восстанавливается один запрос
+	MaxTokens int `json:"maxTokens"`  // максимальный всплеск запросов
+}
 
-	err := tb.DB.InsertOne(userIP, bckt)
-	if err != nil {
-		return err
-	}
+// Status - состояние лимита пользователя после запроса
+type Status struct {
+	Limit      int           // максимальный всплеск запросов
+	Remaining  int           // сколько запросов можно сделать сейчас
+	Reset      time.Duration // через сколько восстановится весь всплеск
+	RetryAfter time.Duration // через сколько пройдёт следующий запрос, 0 - пройдёт сразу
+}
 
-	tb.AddToken(userIP)
-	return nil
+// BucketInfo - лимиты и состояние пользователя для админского API
+type BucketInfo struct {
+	Key       string `json:"key"` // хеш IP
+	Rate      int    `json:"refillRate"`
+	MaxTokens int    `json:"maxTokens"`
+	Remaining int    `json:"remaining"`
+	ResetMs   int64  `json:"resetMs"` // через сколько восстановится весь всплеск
 }
 
-func (tb *tokenBucket) GetTokens(userIP string) (int, error) {
-	bckt, err := tb.DB.FindOne(userIP)
-	if err != nil {
-		return 0, err
+// длительность по разнице времени в мс, отрицательная - ноль
+func millis(d int64) time.Duration {
+	return time.Duration(max(0, d)) * time.Millisecond
+}
+
+// noRefill - интервал при Rate <= 0: запросы фактически не восстанавливаются.
+// Значение выбрано так, чтобы MaxTokens*noRefill точно представлялось в числах Lua
+const noRefill = int64(1) << 36
+
+// интервал восстановления одного запроса, мс
+func interval(rate int) int64 {
+	if rate <= 0 {
+		return noRefill
 	}
+	return int64(rate) * 1000
+}
 
-	return bckt.Current, nil
+// длина окна, за которое восстанавливается весь всплеск, мс
+func window(l Limits) int64 {
+	return max(1, int64(l.MaxTokens)) * interval(l.Rate)
 }
 
-func (tb *tokenBucket) RemoveToken(userIP string) error {
-	bckt, err := tb.DB.FindOne(userIP)
-	if err != nil {
-		return err
-	}
+// limiter - общая часть всех алгоритмов: лимиты по умолчанию и работа с хранилищем
+type limiter[T any] struct {
+	DB               StateDB[T]
+	algo             Algorithm[T]
+	mu               sync.RWMutex
+	defaultMaxTokens int
+	defaultRate      int
+}
+
+var _ BucketIface = &limiter[Bucket]{}
 
-	if bckt.Current <= 0 {
-		return errors.New(messages.ErrNoAvailableToken)
+func newLimiter[T any](db StateDB[T], algo Algorithm[T], defaultMaxTokens, defaultRate int) *limiter[T] {
+	return &limiter[T]{
+		DB:               db,
+		algo:             algo,
+		defaultMaxTokens: defaultMaxTokens,
+		defaultRate:      defaultRate,
 	}
+}
 
-	bckt.Current--
+// состояние нового пользователя
+func (l *limiter[T]) defaults() T {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.algo.New(Limits{
+		Rate:      l.defaultRate,
+		MaxTokens: l.defaultMaxTokens,
+	})
+}
 
-	err = tb.DB.UpdateOne(userIP, bckt)
-	if err != nil {
-		return err
-	}
+func (l *limiter[T]) AddUser(userIP string) error {
+	return l.DB.InsertOne(userIP, l.defaults())
+}
 
-	return nil
+// SetDefaults - параметры применяются к новым пользователям,
+// у существующих остаются их текущие (возможно, заданные вручную) значения
+func (l *limiter[T]) SetDefaults(maxTokens, rate int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaultMaxTokens = maxTokens
+	l.defaultRate = rate
 }
 
-func (tb *tokenBucket) GetMaxTokens(userIP string) (int, error) {
-	bckt, err := tb.DB.FindOne(userIP)
+func (l *limiter[T]) GetTokens(userIP string) (int, error) {
+	state, err := l.DB.FindOne(userIP)
 	if err != nil {
 		return 0, err
 	}
 
-	return bckt.MaxTokens, nil
+	// списание нуля запросов только пересчитывает состояние на текущий момент
+	now := time.Now().UnixMilli()
+	l.algo.Take(&state, now, 0)
+	return l.algo.Status(&state, now, 0).Remaining, nil
 }
 
-func (tb *tokenBucket) GetRate(userIP string) (int, error) {
-	bckt, err := tb.DB.FindOne(userIP)
+func (l *limiter[T]) RemoveToken(userIP string) (Status, error) {
+	status, ok, err := l.DB.Take(userIP, l.defaults(), 1)
 	if err != nil {
-		return 0, err
+		return status, err
 	}
 
-	return bckt.Rate, nil
+	if !ok {
+		return status, ErrNoTokens
+	}
+
+	return status, nil
 }
 
-func (tb *tokenBucket) SetMaxTokens(userIP string, max int) error {
-	bckt, err := tb.DB.FindOne(userIP)
+func (l *limiter[T]) GetMaxTokens(userIP string) (int, error) {
+	state, err := l.DB.FindOne(userIP)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	bckt.MaxTokens = max
+	return l.algo.Limits(&state).MaxTokens, nil
+}
 
-	err = tb.DB.UpdateOne(userIP, bckt)
+func (l *limiter[T]) GetRate(userIP string) (int, error) {
+	state, err := l.DB.FindOne(userIP)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return l.algo.Limits(&state).Rate, nil
 }
 
-func (tb *tokenBucket) SetRate(userIP string, rate int) error {
-	bckt, err := tb.DB.FindOne(userIP)
-	if err != nil {
-		return err
-	}
+func (l *limiter[T]) SetMaxTokens(userIP string, max int) error {
+	return l.DB.UpdateOne(userIP, func(state *T) {
+		now := time.Now().UnixMilli()
+		l.algo.Take(state, now, 0)
+		l.algo.Limits(state).MaxTokens = max
+		l.algo.Take(state, now, 0)
+	})
+}
 
-	bckt.Rate = rate
+func (l *limiter[T]) SetRate(userIP string, rate int) error {
+	return l.DB.UpdateOne(userIP, func(state *T) {
+		// запросы, восстановленные по старой скорости, засчитываются до её смены
+		now := time.Now().UnixMilli()
+		l.algo.Take(state, now, 0)
+		l.algo.Limits(state).Rate = rate
+	})
+}
 
-	err = tb.DB.UpdateOne(userIP, bckt)
+func (l *limiter[T]) Get(userIP string) (BucketInfo, error) {
+	state, err := l.DB.FindOne(userIP)
 	if err != nil {
-		return err
+		return BucketInfo{}, err
 	}
 
-	tb.AddToken(userIP)
-	return nil
+	return l.info(userIP, &state, time.Now().UnixMilli()), nil
 }
 
-func (tb *tokenBucket) AddToken(userIP string) {
-	tb.mu.RLock()
-	if ticker, exists := tb.tickers[userIP]; exists {
-		ticker.Stop()
+func (l *limiter[T]) List() ([]BucketInfo, error) {
+	states, err := l.DB.List()
+	if err != nil {
+		return nil, err
 	}
-	tb.mu.RUnlock()
 
-	bckt, err := tb.DB.FindOne(userIP)
-	if err != nil {
-		logger.Log.Error(messages.ErrAddToken, zap.Error(err))
+	now := time.Now().UnixMilli()
+	result := make([]BucketInfo, 0, len(states))
+	for key, state := range states {
+		result = append(result, l.info(key, &state, now))
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
 
-	interval := time.Second * time.Duration(bckt.Rate)
-	ticker := time.NewTicker(interval)
-
-	tb.mu.Lock()
-	tb.tickers[userIP] = ticker
-	tb.mu.Unlock()
-
-	go func(userIP string) {
-		for range ticker.C {
-			bckt, err := tb.DB.FindOne(userIP)
-			if err != nil {
-				logger.Log.Error(messages.ErrAddToken, zap.Error(err))
-			}
-
-			if bckt.Current < bckt.MaxTokens {
-				bckt.Current++
-				logger.Log.Info(messages.InfoAddedToken, zap.String(messages.IP, userIP))
-			}
-
-			err = tb.DB.UpdateOne(userIP, bckt)
-			if err != nil {
-				logger.Log.Error(messages.ErrAddToken, zap.Error(err))
-			}
-		}
-	}(userIP)
+	return result, nil
 }
 
-func (tb *tokenBucket) StopAllTickers(ctx context.Context) {
-	<-ctx.Done()
+func (l *limiter[T]) Reset(userIP string) error {
+	return l.DB.UpdateOne(userIP, l.algo.Reset)
+}
 
-	tb.mu.Lock()
-	defer tb.mu.Unlock()
+func (l *limiter[T]) Delete(userIP string) error {
+	return l.DB.DeleteOne(userIP)
+}
 
-	for key, ticker := range tb.tickers {
-		ticker.Stop()
-		delete(tb.tickers, key)
+// лимиты и состояние пользователя на момент now; state изменяется
+func (l *limiter[T]) info(key string, state *T, now int64) BucketInfo {
+	l.algo.Take(state, now, 0)
+	status := l.algo.Status(state, now, 0)
+	limits := l.algo.Limits(state)
+
+	return BucketInfo{
+		Key:       key,
+		Rate:      limits.Rate,
+		MaxTokens: limits.MaxTokens,
+		Remaining: status.Remaining,
+		ResetMs:   status.Reset.Milliseconds(),
 	}
-
-	logger.Log.Info(messages.InfoTickersStopped)
 }
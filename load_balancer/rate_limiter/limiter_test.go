@@ -0,0 +1,215 @@
+package ratelimiter
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	testMax      = 5
+	testInterval = int64(1000) // Rate = 1 с
+	testWindow   = testMax * testInterval
+)
+
+var testLimits = Limits{Rate: 1, MaxTokens: testMax}
+
+// burstCase - запускает на хранилище в памяти с ручными часами серию всплесков
+// по 2*testMax запросов и возвращает, сколько запросов пропущено в каждом
+type burstCase struct {
+	name string
+	run  func(offsets []int64) []int
+}
+
+func newBurstCase[T any](name string, algo Algorithm[T]) burstCase {
+	return burstCase{
+		name: name,
+		run: func(offsets []int64) []int {
+			now := int64(1_000_000)
+			db := NewMemoryAdapter(algo, 0)
+			db.now = func() int64 { return now }
+
+			// первое обращение создаёт состояние, дальше - простой до первого всплеска
+			db.Take("ip", algo.New(testLimits), 0) //nolint:errcheck
+
+			allowed := make([]int, len(offsets))
+			for i, offset := range offsets {
+				now += offset
+				for j := 0; j < 2*testMax; j++ {
+					if _, ok, _ := db.Take("ip", algo.New(testLimits), 1); ok {
+						allowed[i]++
+					}
+				}
+			}
+			return allowed
+		},
+	}
+}
+
+func TestBurstBehaviour(t *testing.T) {
+	// после простоя, через интервал, через окно после всплеска, через пол-окна
+	offsets := []int64{testWindow, testInterval, testWindow - testInterval, testWindow / 2}
+
+	cases := []struct {
+		burstCase
+		want []int
+	}{
+		// восстанавливает по запросу раз в интервал
+		{newBurstCase(TokenBucket, tokenBucket{}), []int{5, 1, 4, 2}},
+		// то же поведение, что у корзины токенов, но состояние - одно число
+		{newBurstCase(GCRA, gcra{}), []int{5, 1, 4, 2}},
+		// всплеск предыдущего окна учитывается пропорционально, пока окно не сдвинется
+		{newBurstCase(SlidingWindow, slidingWindow{}), []int{5, 0, 0, 3}},
+		// запросы освобождаются ровно через окно после своего прихода
+		{newBurstCase(SlidingLog, slidingLog{}), []int{5, 0, 5, 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.run(offsets)
+			for i := range c.want {
+				if got[i] != c.want[i] {
+					t.Fatalf("expected %v allowed per burst, got %v", c.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBurstNeverExceedsMax(t *testing.T) {
+	for _, c := range []burstCase{
+		newBurstCase(TokenBucket, tokenBucket{}),
+		newBurstCase(GCRA, gcra{}),
+		newBurstCase(SlidingWindow, slidingWindow{}),
+		newBurstCase(SlidingLog, slidingLog{}),
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			// долгий простой не накапливает больше testMax запросов
+			for _, n := range c.run([]int64{100 * testWindow, 100 * testWindow}) {
+				if n != testMax {
+					t.Fatalf("expected burst of %d after idle, got %d", testMax, n)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterWithMemoryStorage(t *testing.T) {
+	for _, algorithm := range []string{TokenBucket, SlidingWindow, SlidingLog, GCRA} {
+		t.Run(algorithm, func(t *testing.T) {
+			rl, err := New(Config{Algorithm: algorithm, Storage: StorageMemory, MaxTokens: testMax, Rate: 60})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			taken := 0
+			for ; taken <= testMax; taken++ {
+				if _, err := rl.RemoveToken("ip"); err != nil {
+					if !errors.Is(err, ErrNoTokens) {
+						t.Fatal(err)
+					}
+					break
+				}
+			}
+			if taken == 0 || taken > testMax {
+				t.Fatalf("expected 1..%d requests before limiting, got %d", testMax, taken)
+			}
+
+			if err := rl.SetMaxTokens("ip", 10); err != nil {
+				t.Fatal(err)
+			}
+			if err := rl.SetRate("ip", 2); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := rl.GetMaxTokens("ip"); got != 10 {
+				t.Fatalf("expected max 10, got %d", got)
+			}
+			if got, _ := rl.GetRate("ip"); got != 2 {
+				t.Fatalf("expected rate 2, got %d", got)
+			}
+			if _, err := rl.GetTokens("unknown"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound for unknown user, got %v", err)
+			}
+
+			if err := rl.Reset("ip"); err != nil {
+				t.Fatal(err)
+			}
+			info, err := rl.Get("ip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Remaining != 10 || info.Rate != 2 || info.MaxTokens != 10 {
+				t.Fatalf("expected full bucket of 10 with rate 2 after reset, got %+v", info)
+			}
+
+			if list, err := rl.List(); err != nil || len(list) != 1 || list[0].Key != "ip" {
+				t.Fatalf("expected one listed bucket, got %+v %v", list, err)
+			}
+			if err := rl.Delete("ip"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := rl.Get("ip"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound after delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewUnknownAlgorithm(t *testing.T) {
+	if _, err := New(Config{Algorithm: "leaky", Storage: StorageMemory}); err == nil {
+		t.Fatal("expected error for unknown algorithm")
+	}
+	if _, err := New(Config{Storage: "etcd"}); err == nil {
+		t.Fatal("expected error for unknown storage")
+	}
+}
+
+// statusCase - проверяет, что Status на хранилище в памяти с ручными часами
+// предсказывает, когда пройдёт следующий запрос и восстановится весь всплеск
+func statusCase[T any](t *testing.T, algo Algorithm[T]) {
+	now := int64(1_000_000)
+	db := NewMemoryAdapter(algo, 0)
+	db.now = func() int64 { return now }
+	defaults := algo.New(testLimits)
+
+	db.Take("ip", defaults, 0) //nolint:errcheck
+	now += 10 * testWindow
+
+	for round := 0; round < 3; round++ {
+		var status Status
+		for {
+			s, ok, _ := db.Take("ip", defaults, 1)
+			status = s
+			if !ok {
+				break
+			}
+			if s.Limit != testMax {
+				t.Fatalf("expected limit %d, got %d", testMax, s.Limit)
+			}
+		}
+		if status.RetryAfter <= 0 || status.Remaining != 0 {
+			t.Fatalf("round %d: expected positive Retry-After and nothing left, got %+v", round, status)
+		}
+
+		now += status.RetryAfter.Milliseconds() - 1
+		if _, ok, _ := db.Take("ip", defaults, 1); ok {
+			t.Fatalf("round %d: request allowed before Retry-After", round)
+		}
+		now++
+		if _, ok, _ := db.Take("ip", defaults, 1); !ok {
+			t.Fatalf("round %d: request limited after Retry-After %v", round, status.RetryAfter)
+		}
+
+		status, _, _ = db.Take("ip", defaults, 0)
+		now += status.Reset.Milliseconds()
+		if status, _, _ = db.Take("ip", defaults, 0); status.Remaining != testMax || status.Reset != 0 {
+			t.Fatalf("round %d: expected full burst after Reset, got %+v", round, status)
+		}
+	}
+}
+
+func TestStatusPredictsLimits(t *testing.T) {
+	t.Run(TokenBucket, func(t *testing.T) { statusCase[Bucket](t, tokenBucket{}) })
+	t.Run(GCRA, func(t *testing.T) { statusCase[Cell](t, gcra{}) })
+	t.Run(SlidingWindow, func(t *testing.T) { statusCase[Window](t, slidingWindow{}) })
+	t.Run(SlidingLog, func(t *testing.T) { statusCase[Log](t, slidingLog{}) })
+}
@@ -0,0 +1,143 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"load_balancer/internal/messages"
+)
+
+// MemoryAdapter - хранилище состояния в памяти процесса. Лимиты не разделяются
+// между репликами балансировщика, но не требуют внешней БД.
+// Состояние IP, к которому не обращались дольше ttl, вытесняется
+type MemoryAdapter[T any] struct {
+	mu        sync.Mutex
+	states    map[string]memoryEntry[T]
+	algo      Algorithm[T]
+	ttl       int64        // мс, 0 - не вытеснять
+	lastSweep int64        // время последней очистки, мс
+	now       func() int64 // текущее время, мс
+}
+
+type memoryEntry[T any] struct {
+	state T
+	seen  int64 // время последнего обращения, мс
+}
+
+var _ BucketDB = &MemoryAdapter[Bucket]{}
+
+func NewMemoryAdapter[T any](algo Algorithm[T], ttl time.Duration) *MemoryAdapter[T] {
+	return &MemoryAdapter[T]{
+		states: make(map[string]memoryEntry[T]),
+		algo:   algo,
+		ttl:    ttl.Milliseconds(),
+		now:    func() int64 { return time.Now().UnixMilli() },
+	}
+}
+
+func (m *MemoryAdapter[T]) FindOne(userIP string) (result T, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.get(userIP, m.now())
+	if !ok {
+		return result, notFound(userIP)
+	}
+
+	return e.state, nil
+}
+
+func (m *MemoryAdapter[T]) InsertOne(userIP string, state T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.put(userIP, state, m.now())
+	return nil
+}
+
+func (m *MemoryAdapter[T]) UpdateOne(userIP string, update func(state *T)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	e, ok := m.get(userIP, now)
+	if !ok {
+		return fmt.Errorf(messages.ErrUpdate, userIP, notFound(userIP))
+	}
+
+	update(&e.state)
+	m.put(userIP, e.state, now)
+	return nil
+}
+
+func (m *MemoryAdapter[T]) DeleteOne(userIP string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.get(userIP, m.now()); !ok {
+		return notFound(userIP)
+	}
+
+	delete(m.states, userIP)
+	return nil
+}
+
+func (m *MemoryAdapter[T]) List() (map[string]T, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	result := make(map[string]T, len(m.states))
+	for ip, e := range m.states {
+		if !m.expired(e, now) {
+			result[ip] = e.state
+		}
+	}
+
+	return result, nil
+}
+
+func (m *MemoryAdapter[T]) Take(userIP string, defaults T, cost int) (status Status, ok bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	e, found := m.get(userIP, now)
+	if !found {
+		e.state = defaults
+	}
+
+	ok = m.algo.Take(&e.state, now, cost)
+	m.put(userIP, e.state, now)
+	return m.algo.Status(&e.state, now, cost), ok, nil
+}
+
+// состояние IP, если оно ещё не вытеснено. Вызывается под мьютексом
+func (m *MemoryAdapter[T]) get(userIP string, now int64) (memoryEntry[T], bool) {
+	e, ok := m.states[userIP]
+	if ok && m.expired(e, now) {
+		delete(m.states, userIP)
+		return e, false
+	}
+	return e, ok
+}
+
+// сохранить состояние и раз в ttl удалить все простаивающие IP. Вызывается под мьютексом
+func (m *MemoryAdapter[T]) put(userIP string, state T, now int64) {
+	m.states[userIP] = memoryEntry[T]{state: state, seen: now}
+
+	if m.ttl <= 0 || now-m.lastSweep < m.ttl {
+		return
+	}
+	m.lastSweep = now
+	for ip, e := range m.states {
+		if m.expired(e, now) {
+			delete(m.states, ip)
+		}
+	}
+}
+
+func (m *MemoryAdapter[T]) expired(e memoryEntry[T], now int64) bool {
+	return m.ttl > 0 && now-e.seen >= m.ttl
+}
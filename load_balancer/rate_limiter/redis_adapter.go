@@ -2,103 +2,212 @@ package ratelimiter
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"load_balancer/internal/messages"
 
 	"github.com/go-redis/redis"
 )
 
-type RedisAdapter struct {
+const (
+	// сколько раз повторять транзакцию UpdateOne при конкурентном изменении ключа
+	maxTxRetries = 10
+	// сколько ключей просить у SCAN за раз
+	scanBatch = 100
+)
+
+// newScript - Take алгоритма одной атомарной операцией в Redis.
+// Время берётся у Redis, чтобы реплики балансировщика с разными часами
+// считали лимиты одинаково. Тело алгоритма получает состояние b, время now (мс),
+// лимиты rate, max, interval, size (окно) и cost и выставляет ok. Скрипт возвращает
+// новое состояние и время Redis, по ним Status считается так же, как в памяти.
+// Ключ живёт ARGV[3] мс с последнего обращения, 0 - без срока
+func newScript(body string) *redis.Script {
+	return redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local b = cjson.decode(redis.call('GET', KEYS[1]) or ARGV[1])
+local rate = tonumber(b.refillRate) or 0
+local max = tonumber(b.maxTokens) or 0
+local interval = ` + fmt.Sprint(noRefill) + `
+if rate > 0 then
+	interval = rate * 1000
+end
+local size = math.max(1, max) * interval
+local cost = tonumber(ARGV[2])
+local ok = 0
+` + body + `
+local state = cjson.encode(b)
+local ttl = tonumber(ARGV[3]) or 0
+if ttl > 0 then
+	redis.call('SET', KEYS[1], state, 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], state)
+end
+
+return {ok, state, now}
+`)
+}
+
+// RedisAdapter - хранилище состояния в Redis, Take выполняется скриптом алгоритма
+type RedisAdapter[T any] struct {
 	Client *redis.Client
+	Algo   Algorithm[T]
+	Prefix string        // префикс ключей, отделяет состояние разных лимитеров
+	TTL    time.Duration // срок ключа с последнего изменения, как вытеснение в памяти; 0 - без срока
 }
 
-func (r *RedisAdapter) FindOne(userIP string) (result Bucket, err error) {
-	var isEmpty bool
-	err = r.Client.Watch(func(tx *redis.Tx) error {
-		res, err := tx.Get(userIP).Result()
-		if err == redis.Nil {
-			isEmpty = true
-			return nil
-		}
-		if err != nil {
-			return err
-		}
+var _ BucketDB = &RedisAdapter[Bucket]{}
 
-		return json.Unmarshal([]byte(res), &result)
-	}, userIP)
+func (r *RedisAdapter[T]) key(userIP string) string {
+	return r.Prefix + userIP
+}
 
+func (r *RedisAdapter[T]) FindOne(userIP string) (result T, err error) {
+	res, err := r.Client.Get(r.key(userIP)).Result()
+	if err == redis.Nil {
+		return result, notFound(userIP)
+	}
 	if err != nil {
 		return result, fmt.Errorf(messages.ErrFind, userIP, err)
 	}
 
-	if isEmpty {
-		return result, fmt.Errorf(messages.ErrNoData, userIP)
+	if err := json.Unmarshal([]byte(res), &result); err != nil {
+		return result, fmt.Errorf(messages.ErrFind, userIP, err)
 	}
 
 	return result, nil
 }
 
-func (r *RedisAdapter) InsertOne(userIP string, bucket Bucket) error {
-	err := r.Client.Watch(func(tx *redis.Tx) error {
-		tData, err := json.Marshal(bucket)
+func (r *RedisAdapter[T]) InsertOne(userIP string, state T) error {
+	tData, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf(messages.ErrInsert, userIP, err)
+	}
+
+	if err := r.Client.Set(r.key(userIP), tData, r.TTL).Err(); err != nil {
+		return fmt.Errorf(messages.ErrInsert, userIP, err)
+	}
+
+	return nil
+}
+
+// UpdateOne - изменение состояния под оптимистичной блокировкой WATCH/MULTI
+func (r *RedisAdapter[T]) UpdateOne(userIP string, update func(state *T)) error {
+	txf := func(tx *redis.Tx) error {
+		res, err := tx.Get(r.key(userIP)).Result()
+		if err == redis.Nil {
+			return notFound(userIP)
+		}
 		if err != nil {
 			return err
 		}
 
-		err = r.Client.Set(userIP, tData, 0).Err()
+		var state T
+		if err := json.Unmarshal([]byte(res), &state); err != nil {
+			return err
+		}
+
+		update(&state)
+
+		tData, err := json.Marshal(state)
 		if err != nil {
 			return err
 		}
 
+		// выполнится, только если ключ не изменился с момента WATCH
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Set(r.key(userIP), tData, r.TTL)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxTxRetries; i++ {
+		err := r.Client.Watch(txf, r.key(userIP))
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf(messages.ErrUpdate, userIP, err)
+		}
 		return nil
-	}, userIP)
+	}
+
+	return fmt.Errorf(messages.ErrUpdate, userIP, redis.TxFailedErr)
+}
 
+func (r *RedisAdapter[T]) DeleteOne(userIP string) error {
+	n, err := r.Client.Del(r.key(userIP)).Result()
 	if err != nil {
-		return fmt.Errorf(messages.ErrInsert, userIP, err)
+		return fmt.Errorf(messages.ErrDelete, userIP, err)
+	}
+	if n == 0 {
+		return notFound(userIP)
 	}
 
 	return nil
 }
 
-func (r *RedisAdapter) UpdateOne(userIP string, updatedBucket Bucket) error {
-
-	err := r.Client.Watch(func(tx *redis.Tx) error {
-		res, err := r.Client.Get(userIP).Result()
-		if err != nil && err != redis.Nil {
-			return err
-		}
+// List - все состояния с префиксом лимитера; SCAN не блокирует Redis, в отличие от KEYS
+func (r *RedisAdapter[T]) List() (map[string]T, error) {
+	result := make(map[string]T)
 
+	iter := r.Client.Scan(0, r.Prefix+"*", scanBatch).Iterator()
+	for iter.Next() {
+		key := iter.Val()
+		res, err := r.Client.Get(key).Result()
 		if err == redis.Nil {
-			return fmt.Errorf(messages.ErrNoData, userIP)
+			continue // ключ удалён во время обхода
 		}
-
-		var currentBucket Bucket
-		err = json.Unmarshal([]byte(res), &currentBucket)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf(messages.ErrList, err)
 		}
 
-		currentBucket.Rate = updatedBucket.Rate
-		currentBucket.MaxTokens = updatedBucket.MaxTokens
-		currentBucket.Current = updatedBucket.Current
-
-		tData, err := json.Marshal(currentBucket)
-		if err != nil {
-			return err
+		var state T
+		if err := json.Unmarshal([]byte(res), &state); err != nil {
+			return nil, fmt.Errorf(messages.ErrList, err)
 		}
+		result[strings.TrimPrefix(key, r.Prefix)] = state
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf(messages.ErrList, err)
+	}
 
-		err = r.Client.Set(userIP, tData, 0).Err()
-		if err != nil {
-			return err
-		}
+	return result, nil
+}
 
-		return nil
-	}, userIP)
+func (r *RedisAdapter[T]) Take(userIP string, defaults T, cost int) (status Status, ok bool, err error) {
+	tData, err := json.Marshal(defaults)
+	if err != nil {
+		return status, false, fmt.Errorf(messages.ErrTake, userIP, err)
+	}
 
+	res, err := r.Algo.Script().Run(r.Client, []string{r.key(userIP)}, tData, cost, r.TTL.Milliseconds()).Result()
 	if err != nil {
-		return fmt.Errorf(messages.ErrUpdate, userIP, err)
+		return status, false, fmt.Errorf(messages.ErrTake, userIP, err)
 	}
 
-	return nil
+	vals, isSlice := res.([]interface{})
+	if !isSlice || len(vals) != 3 {
+		return status, false, fmt.Errorf(messages.ErrTake, userIP, errors.New(messages.ErrScriptReply))
+	}
+
+	okNum, isOkInt := vals[0].(int64)
+	raw, isString := vals[1].(string)
+	now, isNowInt := vals[2].(int64)
+	if !isOkInt || !isString || !isNowInt {
+		return status, false, fmt.Errorf(messages.ErrTake, userIP, errors.New(messages.ErrScriptReply))
+	}
+
+	var state T
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return status, false, fmt.Errorf(messages.ErrTake, userIP, err)
+	}
+
+	return r.Algo.Status(&state, now, cost), okNum == 1, nil
 }
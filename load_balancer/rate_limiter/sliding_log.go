@@ -0,0 +1,100 @@
+package ratelimiter
+
+import "github.com/go-redis/redis"
+
+// SlidingLog - журнал запросов: хранит время каждого запроса за последние
+// MaxTokens*Rate секунд и пропускает запрос, если их меньше MaxTokens.
+// Точнее SlidingWindow, но занимает память пропорционально MaxTokens
+const SlidingLog = "sliding_log"
+
+type Log struct {
+	Limits
+	Times []int64 `json:"log,omitempty"` // время запросов в окне по возрастанию, мс
+}
+
+type slidingLog struct{}
+
+var _ Algorithm[Log] = slidingLog{}
+
+func (slidingLog) New(limits Limits) Log {
+	return Log{Limits: limits}
+}
+
+func (slidingLog) Limits(l *Log) *Limits {
+	return &l.Limits
+}
+
+// должно совпадать с slidingLogScript
+func (slidingLog) Take(l *Log, now int64, cost int) bool {
+	from := now - window(l.Limits)
+
+	// новый срез: состояние могло быть получено копией из хранилища
+	var kept []int64
+	for _, t := range l.Times {
+		if t > from {
+			kept = append(kept, t)
+		}
+	}
+	l.Times = kept
+
+	if len(l.Times)+cost > l.MaxTokens {
+		return false
+	}
+
+	for i := 0; i < cost; i++ {
+		l.Times = append(l.Times, now)
+	}
+	return true
+}
+
+// запрос выходит из окна ровно через окно после своего прихода
+func (slidingLog) Status(l *Log, now int64, cost int) Status {
+	size := window(l.Limits)
+	status := Status{Limit: l.MaxTokens, Remaining: max(0, l.MaxTokens-len(l.Times))}
+
+	if len(l.Times) > 0 {
+		status.Reset = millis(l.Times[len(l.Times)-1] + size - now)
+	}
+
+	switch expire := len(l.Times) + cost - l.MaxTokens; {
+	case expire <= 0:
+	case expire <= len(l.Times):
+		status.RetryAfter = millis(l.Times[expire-1] + size - now)
+	default:
+		status.RetryAfter = status.Reset
+	}
+
+	return status
+}
+
+func (slidingLog) Reset(l *Log) {
+	l.Times = nil
+}
+
+func (slidingLog) Script() *redis.Script {
+	return slidingLogScript
+}
+
+// пустой журнал не сохраняется: cjson кодирует пустую таблицу как объект, а не массив
+var slidingLogScript = newScript(`
+local from = now - size
+local times = {}
+for _, t in ipairs(b.log or {}) do
+	if t > from then
+		table.insert(times, t)
+	end
+end
+
+if #times + cost <= max then
+	for i = 1, cost do
+		table.insert(times, now)
+	end
+	ok = 1
+end
+
+if #times == 0 then
+	b.log = nil
+else
+	b.log = times
+end
+`)
@@ -0,0 +1,131 @@
+package ratelimiter
+
+import "github.com/go-redis/redis"
+
+// SlidingWindow - скользящее окно со счётчиками: в окне длиной MaxTokens*Rate секунд
+// не больше MaxTokens запросов. Запросы предыдущего окна учитываются пропорционально
+// тому, какая его часть ещё попадает в скользящее окно
+const SlidingWindow = "sliding_window"
+
+type Window struct {
+	Limits
+	Start int64 `json:"windowStart"` // начало текущего окна, мс
+	Count int   `json:"count"`       // запросов в текущем окне
+	Prev  int   `json:"prevCount"`   // запросов в предыдущем окне
+}
+
+type slidingWindow struct{}
+
+var _ Algorithm[Window] = slidingWindow{}
+
+func (slidingWindow) New(limits Limits) Window {
+	return Window{Limits: limits}
+}
+
+func (slidingWindow) Limits(w *Window) *Limits {
+	return &w.Limits
+}
+
+// должно совпадать с slidingWindowScript
+func (slidingWindow) Take(w *Window, now int64, cost int) bool {
+	size := window(w.Limits)
+	if w.Start == 0 || w.Start > now {
+		w.Start = now
+	}
+
+	if passed := (now - w.Start) / size; passed > 0 {
+		w.Prev = w.Count
+		if passed > 1 {
+			w.Prev = 0
+		}
+		w.Count = 0
+		w.Start += passed * size
+	}
+
+	if w.estimate(now, size)+int64(cost) > int64(w.MaxTokens) {
+		return false
+	}
+
+	w.Count += cost
+	return true
+}
+
+// оценка числа запросов в скользящем окне, заканчивающемся в now
+func (w *Window) estimate(now, size int64) int64 {
+	return int64(w.Prev)*(size-(now-w.Start))/size + int64(w.Count)
+}
+
+// вклад предыдущего окна убывает линейно: следующий запрос пройдёт, когда вклад
+// уменьшится до свободного места, либо уже в следующем окне, когда убывать начнёт текущее
+func (slidingWindow) Status(w *Window, now int64, cost int) Status {
+	size := window(w.Limits)
+	limit := int64(w.MaxTokens)
+	estimate := w.estimate(now, size)
+	status := Status{Limit: w.MaxTokens, Remaining: int(max(0, limit-estimate))}
+
+	switch {
+	case w.Count > 0:
+		status.Reset = millis(w.Start + 2*size - now)
+	case w.Prev > 0:
+		status.Reset = millis(w.Start + size - now)
+	}
+
+	if estimate+int64(cost) <= limit {
+		return status
+	}
+
+	free := limit - int64(w.Count) - int64(cost)
+	switch {
+	case free >= 0 && w.Prev > 0:
+		status.RetryAfter = millis(w.Start + decayed(int64(w.Prev), free, size) - now)
+	case limit >= int64(cost) && w.Count > 0:
+		status.RetryAfter = millis(w.Start + size + decayed(int64(w.Count), limit-int64(cost), size) - now)
+	default:
+		status.RetryAfter = status.Reset
+	}
+
+	return status
+}
+
+// смещение от начала окна, с которого вклад n запросов предыдущего окна
+// в estimate (с округлением вниз) не больше free
+func decayed(n, free, size int64) int64 {
+	return max(0, size-((free+1)*size-1)/n)
+}
+
+func (slidingWindow) Reset(w *Window) {
+	w.Start, w.Count, w.Prev = 0, 0, 0
+}
+
+func (slidingWindow) Script() *redis.Script {
+	return slidingWindowScript
+}
+
+var slidingWindowScript = newScript(`
+local start = tonumber(b.windowStart) or 0
+local count = tonumber(b.count) or 0
+local prev = tonumber(b.prevCount) or 0
+if start == 0 or start > now then
+	start = now
+end
+
+local passed = math.floor((now - start) / size)
+if passed > 0 then
+	prev = count
+	if passed > 1 then
+		prev = 0
+	end
+	count = 0
+	start = start + passed * size
+end
+
+local estimate = math.floor(prev * (size - (now - start)) / size) + count
+if estimate + cost <= max then
+	count = count + cost
+	ok = 1
+end
+
+b.windowStart = start
+b.count = count
+b.prevCount = prev
+`)
@@ -0,0 +1,307 @@
+package ratelimiter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+// адрес настоящего Redis для прогона тех же тестов на RedisAdapter, без него - miniredis
+const redisAddrEnv = "LIMITER_REDIS_ADDR"
+
+// клиент Redis для тестов: настоящий из redisAddrEnv или miniredis с теми же Lua-скриптами
+func testRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	var mr *miniredis.Miniredis
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		mr = miniredis.RunT(t)
+		addr = mr.Addr()
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+	return client, mr
+}
+
+// storages - хранилища корзин, на которых запускается общий набор тестов
+func storages(t *testing.T) map[string]func() BucketDB {
+	client, _ := testRedis(t)
+	return map[string]func() BucketDB{
+		StorageMemory: func() BucketDB { return NewMemoryAdapter[Bucket](tokenBucket{}, time.Hour) },
+		StorageRedis: func() BucketDB {
+			return &RedisAdapter[Bucket]{Client: client, Algo: tokenBucket{}, Prefix: KeyPrefix + "test:", TTL: time.Hour}
+		},
+	}
+}
+
+// уникальный ключ, чтобы прогоны на общем Redis не мешали друг другу
+func testKey(t *testing.T) string {
+	return fmt.Sprintf("test:%s:%d", t.Name(), time.Now().UnixNano())
+}
+
+// корзина без пополнения на время теста
+func fullBucket(max int) Bucket {
+	return Bucket{Limits: Limits{Rate: 3600, MaxTokens: max}, Current: max}
+}
+
+func TestStorage(t *testing.T) {
+	for name, newDB := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Run("FindMissing", func(t *testing.T) {
+				if _, err := newDB().FindOne(testKey(t)); !errors.Is(err, ErrNotFound) {
+					t.Fatalf("expected ErrNotFound, got %v", err)
+				}
+			})
+
+			t.Run("InsertFind", func(t *testing.T) {
+				db, ip := newDB(), testKey(t)
+				want := fullBucket(7)
+				if err := db.InsertOne(ip, want); err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := db.FindOne(ip)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Fatalf("expected %+v, got %+v", want, got)
+				}
+			})
+
+			t.Run("Update", func(t *testing.T) {
+				db, ip := newDB(), testKey(t)
+				if err := db.UpdateOne(ip, func(b *Bucket) {}); err == nil {
+					t.Fatal("expected error updating missing IP")
+				}
+
+				if err := db.InsertOne(ip, fullBucket(7)); err != nil {
+					t.Fatal(err)
+				}
+				if err := db.UpdateOne(ip, func(b *Bucket) { b.Rate = 42 }); err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := db.FindOne(ip)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Rate != 42 || got.MaxTokens != 7 {
+					t.Fatalf("expected rate 42 and max 7, got %+v", got)
+				}
+			})
+
+			t.Run("DeleteList", func(t *testing.T) {
+				db, ip := newDB(), testKey(t)
+				if err := db.DeleteOne(ip); !errors.Is(err, ErrNotFound) {
+					t.Fatalf("expected ErrNotFound deleting missing IP, got %v", err)
+				}
+
+				if err := db.InsertOne(ip, fullBucket(7)); err != nil {
+					t.Fatal(err)
+				}
+				states, err := db.List()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if states[ip] != fullBucket(7) {
+					t.Fatalf("expected listed bucket %+v, got %+v", fullBucket(7), states[ip])
+				}
+
+				if err := db.DeleteOne(ip); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := db.FindOne(ip); !errors.Is(err, ErrNotFound) {
+					t.Fatalf("expected ErrNotFound after delete, got %v", err)
+				}
+			})
+
+			t.Run("TakeCreatesFromDefaults", func(t *testing.T) {
+				db, ip := newDB(), testKey(t)
+				defaults := tokenBucket{}.New(Limits{Rate: 3600, MaxTokens: 5})
+
+				status, ok, err := db.Take(ip, defaults, 1)
+				if err != nil || !ok || status.Remaining != 0 || status.Limit != 5 {
+					t.Fatalf("expected first request allowed with 0 of 5 left, got %+v %v %v", status, ok, err)
+				}
+				if _, ok, _ := db.Take(ip, defaults, 1); ok {
+					t.Fatal("expected second request to be limited")
+				}
+
+				got, err := db.FindOne(ip)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.MaxTokens != 5 || got.Current != 0 {
+					t.Fatalf("expected stored bucket with max 5 and 0 tokens, got %+v", got)
+				}
+			})
+
+			t.Run("TakeIsAtomic", func(t *testing.T) {
+				db, ip := newDB(), testKey(t)
+				if err := db.InsertOne(ip, fullBucket(20)); err != nil {
+					t.Fatal(err)
+				}
+
+				var allowed atomic.Int32
+				var wg sync.WaitGroup
+				for i := 0; i < 50; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if _, ok, err := db.Take(ip, Bucket{}, 1); err == nil && ok {
+							allowed.Add(1)
+						}
+					}()
+				}
+				wg.Wait()
+
+				if allowed.Load() != 20 {
+					t.Fatalf("expected exactly 20 allowed, got %d", allowed.Load())
+				}
+			})
+		})
+	}
+}
+
+func TestMemoryEvictsIdle(t *testing.T) {
+	now := int64(1_000_000)
+	db := NewMemoryAdapter[Bucket](tokenBucket{}, time.Minute)
+	db.now = func() int64 { return now }
+
+	db.InsertOne("idle", fullBucket(5))   //nolint:errcheck
+	db.InsertOne("active", fullBucket(5)) //nolint:errcheck
+
+	now += 30_000
+	if _, err := db.FindOne("active"); err != nil {
+		t.Fatal(err)
+	}
+	db.Take("active", Bucket{}, 1) //nolint:errcheck
+
+	// idle простаивает минуту, active - полминуты
+	now += 30_000
+	if _, err := db.FindOne("idle"); err == nil {
+		t.Fatal("expected idle IP to be evicted")
+	}
+	if _, err := db.FindOne("active"); err != nil {
+		t.Fatal("expected active IP to be kept")
+	}
+
+	// очистка удаляет простаивающие IP, к которым больше не обращаются
+	db.InsertOne("forgotten", fullBucket(5)) //nolint:errcheck
+	now += 60_000
+	db.Take("other", fullBucket(5), 1) //nolint:errcheck
+	if _, found := db.states["forgotten"]; found {
+		t.Fatal("expected sweep to remove forgotten IP")
+	}
+	if len(db.states) != 1 {
+		t.Fatalf("expected only the last IP to remain, got %d", len(db.states))
+	}
+}
+
+func TestRedisTokenBucket(t *testing.T) {
+	client, mr := testRedis(t)
+	if mr == nil {
+		t.Skip("the test moves miniredis time")
+	}
+	db := &RedisAdapter[Bucket]{Client: client, Algo: tokenBucket{}, Prefix: KeyPrefix + "test:", TTL: time.Hour}
+	defaults := tokenBucket{}.New(Limits{Rate: 2, MaxTokens: 3})
+
+	// время скрипт берёт у Redis: miniredis отдаёт заданное
+	now := time.Now()
+	mr.SetTime(now)
+	take := func() (Status, bool) {
+		t.Helper()
+		status, ok, err := db.Take("ip", defaults, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status, ok
+	}
+
+	// новая корзина - один токен
+	if _, ok := take(); !ok {
+		t.Fatal("the first request was limited")
+	}
+	status, ok := take()
+	if ok {
+		t.Fatal("the second request was allowed")
+	}
+	if status.RetryAfter != 2*time.Second {
+		t.Fatalf("retry after %v, want 2s", status.RetryAfter)
+	}
+
+	// токен в rate секунд, не больше maxTokens
+	mr.SetTime(now.Add(1999 * time.Millisecond))
+	if _, ok := take(); ok {
+		t.Fatal("allowed before the refill")
+	}
+	mr.SetTime(now.Add(time.Minute))
+	for i := range 3 {
+		if _, ok := take(); !ok {
+			t.Fatalf("request %d of a full bucket was limited", i)
+		}
+	}
+	if _, ok := take(); ok {
+		t.Fatal("allowed over maxTokens")
+	}
+
+	// состояние скрипта совпадает с алгоритмом в памяти
+	got, err := db.FindOne("ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := defaults
+	for _, at := range []time.Time{now, now, now.Add(1999 * time.Millisecond), now.Add(time.Minute), now.Add(time.Minute), now.Add(time.Minute), now.Add(time.Minute)} {
+		tokenBucket{}.Take(&want, at.UnixMilli(), 1)
+	}
+	if got != want {
+		t.Fatalf("redis bucket %+v, memory bucket %+v", got, want)
+	}
+}
+
+func TestRedisTTL(t *testing.T) {
+	client, mr := testRedis(t)
+	if mr == nil {
+		t.Skip("the test moves miniredis time")
+	}
+	db := &RedisAdapter[Bucket]{Client: client, Algo: tokenBucket{}, Prefix: KeyPrefix + "test:", TTL: time.Minute}
+	key := db.key("ip")
+
+	// срок продлевается при каждом изменении
+	if _, _, err := db.Take("ip", fullBucket(5), 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.TTL(key); got != time.Minute {
+		t.Fatalf("ttl after take %v, want 1m", got)
+	}
+	mr.FastForward(30 * time.Second)
+	if err := db.UpdateOne("ip", func(b *Bucket) { b.Rate = 5 }); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.TTL(key); got != time.Minute {
+		t.Fatalf("ttl after update %v, want 1m", got)
+	}
+
+	// простаивающий IP удаляется
+	mr.FastForward(time.Minute)
+	if _, err := db.FindOne("ip"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after ttl, got %v", err)
+	}
+
+	// без ttl ключ бессрочный
+	db.TTL = 0
+	if err := db.InsertOne("ip", fullBucket(5)); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.TTL(key); got != 0 {
+		t.Fatalf("ttl %v without limiter ttl, want none", got)
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimiter
+
+import "github.com/go-redis/redis"
+
+// TokenBucket - корзина токенов с ленивым пополнением: токены не добавляются по таймеру,
+// а досчитываются при обращении по времени последнего пополнения (UpdatedAt).
+// Допускает всплеск до MaxTokens запросов, затем один запрос раз в Rate секунд
+const TokenBucket = "token_bucket"
+
+type Bucket struct {
+	Limits
+	Current   int   `json:"currentTokens"` // токенов на момент UpdatedAt
+	UpdatedAt int64 `json:"updatedAt"`     // время последнего пополнения, мс
+}
+
+type tokenBucket struct{}
+
+var _ Algorithm[Bucket] = tokenBucket{}
+
+// новая корзина выдаётся с одним токеном, остальные накапливаются со временем
+func (tokenBucket) New(limits Limits) Bucket {
+	return Bucket{Limits: limits, Current: 1}
+}
+
+func (tokenBucket) Limits(b *Bucket) *Limits {
+	return &b.Limits
+}
+
+func (tokenBucket) Take(b *Bucket, now int64, cost int) bool {
+	refill(b, now)
+	if b.Current < cost {
+		return false
+	}
+
+	b.Current -= cost
+	return true
+}
+
+// после refill следующий токен добавится через интервал от UpdatedAt
+func (tokenBucket) Status(b *Bucket, now int64, cost int) Status {
+	step := interval(b.Rate)
+	status := Status{Limit: b.MaxTokens, Remaining: b.Current}
+
+	if b.Current < b.MaxTokens {
+		status.Reset = millis(b.UpdatedAt + int64(b.MaxTokens-b.Current)*step - now)
+	}
+	if b.Current < cost {
+		status.RetryAfter = millis(b.UpdatedAt + int64(cost-b.Current)*step - now)
+	}
+
+	return status
+}
+
+func (tokenBucket) Reset(b *Bucket) {
+	b.Current = b.MaxTokens
+	b.UpdatedAt = 0
+}
+
+func (tokenBucket) Script() *redis.Script {
+	return tokenBucketScript
+}
+
+// ленивое пополнение корзины на момент now (мс). Должно совпадать с tokenBucketScript
+func refill(bckt *Bucket, now int64) {
+	if bckt.UpdatedAt == 0 || bckt.UpdatedAt > now {
+		bckt.UpdatedAt = now
+	}
+
+	if bckt.Rate > 0 && bckt.Current < bckt.MaxTokens {
+		interval := int64(bckt.Rate) * 1000
+		added := (now - bckt.UpdatedAt) / interval
+		if added > 0 {
+			bckt.Current = int(min(int64(bckt.MaxTokens), int64(bckt.Current)+added))
+			bckt.UpdatedAt += added * interval
+		}
+	}
+
+	// полная корзина не копит время: отсчёт до следующего токена начнётся после списания
+	if bckt.Current >= bckt.MaxTokens {
+		bckt.Current = bckt.MaxTokens
+		bckt.UpdatedAt = now
+	}
+}
+
+var tokenBucketScript = newScript(`
+local cur = tonumber(b.currentTokens) or 0
+local updated = tonumber(b.updatedAt) or 0
+if updated == 0 or updated > now then
+	updated = now
+end
+
+if rate > 0 and cur < max then
+	local interval = rate * 1000
+	local added = math.floor((now - updated) / interval)
+	if added > 0 then
+		cur = math.min(max, cur + added)
+		updated = updated + added * interval
+	end
+end
+if cur >= max then
+	cur = max
+	updated = now
+end
+
+if cur >= cost then
+	cur = cur - cost
+	ok = 1
+end
+
+b.currentTokens = cur
+b.updatedAt = updated
+`)
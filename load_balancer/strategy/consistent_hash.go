@@ -0,0 +1,112 @@
+package strategy
+
+import (
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"load_balancer/backend"
+	"load_balancer/internal/util"
+)
+
+// количество виртуальных узлов на один сервер
+const defaultReplicas = 100
+
+// consistentHash - кольцо согласованного хеширования по ip клиента:
+// один и тот же клиент попадает на один и тот же сервер,
+// а при добавлении или удалении сервера перераспределяется лишь часть клиентов
+type consistentHash struct {
+	mu       sync.RWMutex
+	replicas int
+	ring     []uint32          // отсортированные хеши виртуальных узлов
+	owners   map[uint32]string // хеш виртуального узла -> URL сервера
+	members  string            // список серверов, по которому построено кольцо
+}
+
+var _ Strategy = &consistentHash{}
+
+func NewConsistentHash(replicas int) *consistentHash {
+	if replicas <= 0 {
+		replicas = defaultReplicas
+	}
+
+	return &consistentHash{
+		replicas: replicas,
+		owners:   make(map[uint32]string),
+	}
+}
+
+func (s *consistentHash) Next(lb ServerSlice, r *http.Request) backend.BackendIface {
+	servers := lb.GetServers()
+	if len(servers) == 0 {
+		return nil
+	}
+
+	byURL := make(map[string]backend.BackendIface, len(servers))
+	urls := make([]string, 0, len(servers))
+	for _, back := range servers {
+		byURL[back.GetURL()] = back
+		urls = append(urls, back.GetURL())
+	}
+
+	s.rebuild(urls)
+
+	key := ""
+	if r != nil {
+		key = util.GetClientIP(r)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	h := hashKey(key)
+	idx := sort.Search(len(s.ring), func(i int) bool { return s.ring[i] >= h })
+
+	// идём по кольцу по часовой стрелке до первого доступного сервера
+	for i := 0; i < len(s.ring); i++ {
+		back := byURL[s.owners[s.ring[(idx+i)%len(s.ring)]]]
+		if back != nil && back.IsAlive() {
+			return back
+		}
+	}
+
+	return nil
+}
+
+// перестроение кольца при изменении списка серверов
+func (s *consistentHash) rebuild(urls []string) {
+	sorted := append([]string(nil), urls...)
+	sort.Strings(sorted)
+	members := strings.Join(sorted, ",")
+
+	s.mu.RLock()
+	actual := s.members == members
+	s.mu.RUnlock()
+	if actual {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ring = s.ring[:0]
+	s.owners = make(map[uint32]string, len(sorted)*s.replicas)
+	for _, url := range sorted {
+		for i := 0; i < s.replicas; i++ {
+			h := hashKey(url + "#" + strconv.Itoa(i))
+			s.ring = append(s.ring, h)
+			s.owners[h] = url
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i] < s.ring[j] })
+	s.members = members
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key)) //nolint:errcheck
+	return h.Sum32()
+}
@@ -0,0 +1,78 @@
+package strategy
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConsistentHashIsSticky(t *testing.T) {
+	p := pool(
+		newFake("http://a", 0, true),
+		newFake("http://b", 0, true),
+		newFake("http://c", 0, true),
+	)
+	s := NewConsistentHash(0)
+
+	for i := 0; i < 20; i++ {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = fmt.Sprintf("10.0.0.%d:1234", i)
+
+		first := s.Next(p, r)
+		for j := 0; j < 5; j++ {
+			if got := s.Next(p, r); got != first {
+				t.Fatalf("client %s moved from %s to %s", r.RemoteAddr, first.GetURL(), got.GetURL())
+			}
+		}
+	}
+}
+
+func TestConsistentHashFailover(t *testing.T) {
+	a := newFake("http://a", 0, true)
+	b := newFake("http://b", 0, true)
+	c := newFake("http://c", 0, true)
+	p := pool(a, b, c)
+	s := NewConsistentHash(0)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.168.1.10:5555"
+
+	owner := s.Next(p, r).(*fakeBackend)
+	owner.alive = false
+
+	got := s.Next(p, r)
+	if got == nil || got == owner {
+		t.Fatalf("expected failover from %s, got %v", owner.url, got)
+	}
+
+	owner.alive = true
+	if got := s.Next(p, r); got != owner {
+		t.Fatalf("expected return to %s, got %v", owner.url, got)
+	}
+}
+
+func TestConsistentHashMinimalRemap(t *testing.T) {
+	a := newFake("http://a", 0, true)
+	b := newFake("http://b", 0, true)
+	c := newFake("http://c", 0, true)
+	s := NewConsistentHash(0)
+
+	before := map[string]string{}
+	for i := 0; i < 300; i++ {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = fmt.Sprintf("10.1.%d.%d:80", i/250, i%250)
+		before[r.RemoteAddr] = s.Next(pool(a, b, c), r).GetURL()
+	}
+
+	// после удаления c клиенты a и b должны остаться на своих серверах
+	for addr, url := range before {
+		if url == c.url {
+			continue
+		}
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = addr
+		if got := s.Next(pool(a, b), r).GetURL(); got != url {
+			t.Fatalf("client %s moved from %s to %s", addr, url, got)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package strategy
+
+import (
+	"net/http/httputil"
+
+	"load_balancer/backend"
+)
+
+// fakeBackend - backend.BackendIface без сети для тестов стратегий
+type fakeBackend struct {
+	url    string
+	conns  int64
+	alive  bool
+	weight int
+}
+
+var _ backend.BackendIface = &fakeBackend{}
+
+func newFake(url string, conns int64, alive bool) *fakeBackend {
+	return &fakeBackend{url: url, conns: conns, alive: alive, weight: 1}
+}
+
+func (f *fakeBackend) AddConn()                         { f.conns++ }
+func (f *fakeBackend) RemoveConn()                      { f.conns-- }
+func (f *fakeBackend) GetConns() int64                  { return f.conns }
+func (f *fakeBackend) SetStatus(alive bool)             { f.alive = alive }
+func (f *fakeBackend) IsAlive() bool                    { return f.alive }
+func (f *fakeBackend) SetDraining(bool)                 {}
+func (f *fakeBackend) IsDraining() bool                 { return false }
+func (f *fakeBackend) GetURL() string                   { return f.url }
+func (f *fakeBackend) GetWeight() int                   { return f.weight }
+func (f *fakeBackend) SetWeight(weight int)             { f.weight = weight }
+func (f *fakeBackend) SetHealthCheck(string, int)       {}
+func (f *fakeBackend) GetHealthCheck() (string, int)    { return "", 0 }
+func (f *fakeBackend) GetProxy() *httputil.ReverseProxy { return nil }
+func (f *fakeBackend) GetGroup() string                 { return backend.DefaultGroup }
+func (f *fakeBackend) SetGroup(string)                  {}
+
+type fakePool []backend.BackendIface
+
+func (p fakePool) GetServers() []backend.BackendIface { return p }
+
+func pool(backs ...*fakeBackend) fakePool {
+	p := make(fakePool, 0, len(backs))
+	for _, b := range backs {
+		p = append(p, b)
+	}
+	return p
+}
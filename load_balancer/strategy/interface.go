@@ -0,0 +1,15 @@
+package strategy
+
+import (
+	"net/http"
+
+	"load_balancer/backend"
+)
+
+type ServerSlice interface {
+	GetServers() []backend.BackendIface // получить список серверов
+}
+
+type Strategy interface {
+	Next(lb ServerSlice, r *http.Request) backend.BackendIface // выбрать сервер для обработки запроса
+}
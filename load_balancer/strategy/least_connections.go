@@ -2,12 +2,21 @@ package strategy
 
 import (
 	"math"
+	"net/http"
 
 	"load_balancer/backend"
 )
 
-type ServerSlice interface {
-	GetServers() []backend.BackendIface // получить список серверов
+type leastConns struct{}
+
+var _ Strategy = &leastConns{}
+
+func NewLeastConns() *leastConns {
+	return &leastConns{}
+}
+
+func (s *leastConns) Next(lb ServerSlice, _ *http.Request) backend.BackendIface {
+	return GetLeastConns(lb)
 }
 
 // выбор сервера с наименьшим количеством подключений для обработки запроса
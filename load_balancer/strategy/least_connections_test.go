@@ -0,0 +1,31 @@
+package strategy
+
+import "testing"
+
+func TestLeastConnsPicksMinimum(t *testing.T) {
+	a := newFake("http://a", 5, true)
+	b := newFake("http://b", 1, true)
+	c := newFake("http://c", 3, true)
+
+	got := NewLeastConns().Next(pool(a, b, c), nil)
+	if got != b {
+		t.Fatalf("expected %s, got %v", b.url, got)
+	}
+}
+
+func TestLeastConnsSkipsDead(t *testing.T) {
+	a := newFake("http://a", 5, true)
+	b := newFake("http://b", 0, false)
+
+	got := NewLeastConns().Next(pool(a, b), nil)
+	if got != a {
+		t.Fatalf("expected %s, got %v", a.url, got)
+	}
+}
+
+func TestLeastConnsNoAlive(t *testing.T) {
+	got := NewLeastConns().Next(pool(newFake("http://a", 0, false)), nil)
+	if got != nil {
+		t.Fatalf("expected nil, got %v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package strategy
+
+import (
+	"math/rand/v2"
+	"net/http"
+
+	"load_balancer/backend"
+)
+
+// powerOfTwo - выбор двух случайных доступных серверов
+// и отправка запроса на менее загруженный из них
+type powerOfTwo struct{}
+
+var _ Strategy = &powerOfTwo{}
+
+func NewPowerOfTwo() *powerOfTwo {
+	return &powerOfTwo{}
+}
+
+func (s *powerOfTwo) Next(lb ServerSlice, _ *http.Request) backend.BackendIface {
+	alive := make([]backend.BackendIface, 0, len(lb.GetServers()))
+	for _, back := range lb.GetServers() {
+		if back.IsAlive() {
+			alive = append(alive, back)
+		}
+	}
+
+	switch len(alive) {
+	case 0:
+		return nil
+	case 1:
+		return alive[0]
+	}
+
+	i := rand.IntN(len(alive))
+	j := rand.IntN(len(alive) - 1)
+	if j >= i {
+		j++
+	}
+
+	if alive[j].GetConns() < alive[i].GetConns() {
+		return alive[j]
+	}
+	return alive[i]
+}
@@ -0,0 +1,44 @@
+package strategy
+
+import "testing"
+
+func TestPowerOfTwoPrefersLessLoaded(t *testing.T) {
+	a := newFake("http://a", 100, true)
+	b := newFake("http://b", 0, true)
+	p := pool(a, b)
+	s := NewPowerOfTwo()
+
+	// при двух серверах оба всегда попадают в выборку
+	for i := 0; i < 50; i++ {
+		if got := s.Next(p, nil); got != b {
+			t.Fatalf("step %d: expected %s, got %v", i, b.url, got)
+		}
+	}
+}
+
+func TestPowerOfTwoNeverPicksMostLoaded(t *testing.T) {
+	a := newFake("http://a", 1, true)
+	b := newFake("http://b", 2, true)
+	c := newFake("http://c", 100, true)
+	p := pool(a, b, c)
+	s := NewPowerOfTwo()
+
+	for i := 0; i < 200; i++ {
+		if got := s.Next(p, nil); got == c {
+			t.Fatalf("step %d: most loaded backend selected", i)
+		}
+	}
+}
+
+func TestPowerOfTwoSingleAndDead(t *testing.T) {
+	a := newFake("http://a", 0, false)
+	b := newFake("http://b", 7, true)
+	s := NewPowerOfTwo()
+
+	if got := s.Next(pool(a, b), nil); got != b {
+		t.Fatalf("expected %s, got %v", b.url, got)
+	}
+	if got := s.Next(pool(a), nil); got != nil {
+		t.Fatalf("expected nil, got %v", got)
+	}
+}
@@ -0,0 +1,37 @@
+package strategy
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"load_balancer/backend"
+)
+
+type roundRobin struct {
+	counter uint64
+}
+
+var _ Strategy = &roundRobin{}
+
+func NewRoundRobin() *roundRobin {
+	return &roundRobin{}
+}
+
+// выбор следующего доступного сервера по кругу
+func (s *roundRobin) Next(lb ServerSlice, _ *http.Request) backend.BackendIface {
+	servers := lb.GetServers()
+	n := uint64(len(servers))
+	if n == 0 {
+		return nil
+	}
+
+	start := atomic.AddUint64(&s.counter, 1) - 1
+	for i := uint64(0); i < n; i++ {
+		back := servers[(start+i)%n]
+		if back.IsAlive() {
+			return back
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package strategy
+
+import "testing"
+
+func TestRoundRobinCycles(t *testing.T) {
+	a := newFake("http://a", 0, true)
+	b := newFake("http://b", 0, true)
+	c := newFake("http://c", 0, true)
+	p := pool(a, b, c)
+	s := NewRoundRobin()
+
+	want := []*fakeBackend{a, b, c, a, b, c}
+	for i, w := range want {
+		if got := s.Next(p, nil); got != w {
+			t.Fatalf("step %d: expected %s, got %v", i, w.url, got)
+		}
+	}
+}
+
+func TestRoundRobinSkipsDead(t *testing.T) {
+	a := newFake("http://a", 0, true)
+	b := newFake("http://b", 0, false)
+	c := newFake("http://c", 0, true)
+	p := pool(a, b, c)
+	s := NewRoundRobin()
+
+	for i := 0; i < 6; i++ {
+		if got := s.Next(p, nil); got == b {
+			t.Fatalf("step %d: dead backend selected", i)
+		}
+	}
+}
+
+func TestRoundRobinEmpty(t *testing.T) {
+	if got := NewRoundRobin().Next(pool(), nil); got != nil {
+		t.Fatalf("expected nil, got %v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package strategy
+
+import (
+	"fmt"
+
+	"load_balancer/internal/messages"
+)
+
+// названия стратегий для параметра strategy в конфиге
+const (
+	LeastConnections   = "least_connections"
+	RoundRobin         = "round_robin"
+	WeightedRoundRobin = "weighted_round_robin"
+	PowerOfTwo         = "power_of_two"
+	ConsistentHash     = "consistent_hash"
+)
+
+// создать стратегию по названию из конфига, по умолчанию - least connections
+func New(name string) (Strategy, error) {
+	switch name {
+	case "", LeastConnections:
+		return NewLeastConns(), nil
+	case RoundRobin:
+		return NewRoundRobin(), nil
+	case WeightedRoundRobin:
+		return NewWeightedRoundRobin(), nil
+	case PowerOfTwo:
+		return NewPowerOfTwo(), nil
+	case ConsistentHash:
+		return NewConsistentHash(defaultReplicas), nil
+	default:
+		return nil, fmt.Errorf(messages.ErrUnknownStrategy, name)
+	}
+}
@@ -0,0 +1,15 @@
+package strategy
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"", LeastConnections, RoundRobin, WeightedRoundRobin, PowerOfTwo, ConsistentHash} {
+		if s, err := New(name); err != nil || s == nil {
+			t.Fatalf("strategy %q: unexpected error %v", name, err)
+		}
+	}
+
+	if _, err := New("random"); err == nil {
+		t.Fatal("expected error for unknown strategy")
+	}
+}
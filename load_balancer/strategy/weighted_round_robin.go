@@ -0,0 +1,57 @@
+package strategy
+
+import (
+	"net/http"
+	"sync"
+
+	"load_balancer/backend"
+)
+
+// weightedRoundRobin - плавный взвешенный round robin (как в nginx):
+// сервер с весом 3 получает три запроса из каждых четырёх при соседе с весом 1,
+// но запросы к нему не идут подряд пачкой
+type weightedRoundRobin struct {
+	mu      sync.Mutex
+	current map[string]int // текущий вес сервера по его URL
+}
+
+var _ Strategy = &weightedRoundRobin{}
+
+func NewWeightedRoundRobin() *weightedRoundRobin {
+	return &weightedRoundRobin{
+		current: make(map[string]int),
+	}
+}
+
+func (s *weightedRoundRobin) Next(lb ServerSlice, _ *http.Request) backend.BackendIface {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var selected backend.BackendIface
+	total := 0
+
+	for _, back := range lb.GetServers() {
+		if !back.IsAlive() {
+			continue
+		}
+
+		weight := back.GetWeight()
+		if weight <= 0 {
+			weight = 1
+		}
+
+		url := back.GetURL()
+		s.current[url] += weight
+		total += weight
+
+		if selected == nil || s.current[url] > s.current[selected.GetURL()] {
+			selected = back
+		}
+	}
+
+	if selected != nil {
+		s.current[selected.GetURL()] -= total
+	}
+
+	return selected
+}
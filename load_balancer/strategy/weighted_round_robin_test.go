@@ -0,0 +1,52 @@
+package strategy
+
+import "testing"
+
+func TestWeightedRoundRobinDistribution(t *testing.T) {
+	a := newFake("http://a", 0, true)
+	a.weight = 3
+	b := newFake("http://b", 0, true)
+	b.weight = 1
+	p := pool(a, b)
+	s := NewWeightedRoundRobin()
+
+	counts := map[string]int{}
+	for i := 0; i < 400; i++ {
+		counts[s.Next(p, nil).GetURL()]++
+	}
+
+	if counts[a.url] != 300 || counts[b.url] != 100 {
+		t.Fatalf("expected 300/100, got %d/%d", counts[a.url], counts[b.url])
+	}
+}
+
+func TestWeightedRoundRobinIsSmooth(t *testing.T) {
+	a := newFake("http://a", 0, true)
+	a.weight = 2
+	b := newFake("http://b", 0, true)
+	b.weight = 1
+	p := pool(a, b)
+	s := NewWeightedRoundRobin()
+
+	// плавный WRR с весами 2:1 выдаёт a, b, a
+	want := []*fakeBackend{a, b, a, a, b, a}
+	for i, w := range want {
+		if got := s.Next(p, nil); got != w {
+			t.Fatalf("step %d: expected %s, got %v", i, w.url, got)
+		}
+	}
+}
+
+func TestWeightedRoundRobinSkipsDead(t *testing.T) {
+	a := newFake("http://a", 0, false)
+	a.weight = 10
+	b := newFake("http://b", 0, true)
+	p := pool(a, b)
+	s := NewWeightedRoundRobin()
+
+	for i := 0; i < 5; i++ {
+		if got := s.Next(p, nil); got != b {
+			t.Fatalf("step %d: expected %s, got %v", i, b.url, got)
+		}
+	}
+}
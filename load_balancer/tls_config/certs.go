@@ -0,0 +1,49 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+
+	"load_balancer/internal/messages"
+)
+
+// CertReloader - сертификат сервера, который можно перечитать с диска без перезапуска
+// (по SIGHUP после обновления сертификата); новые соединения получают новый сертификат
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	cr := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := cr.Reload(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Reload - перечитать сертификат и ключ; при ошибке остаётся прежний сертификат
+func (cr *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf(messages.ErrLoadCert, err)
+	}
+	cr.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate - для tls.Config.GetCertificate
+func (cr *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return cr.cert.Load(), nil
+}
+
+// ServerConfig - TLS основного сервера: HTTP/2 с откатом на HTTP/1.1, не ниже TLS 1.2
+func ServerConfig(cr *CertReloader) *tls.Config {
+	return &tls.Config{
+		GetCertificate: cr.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+		MinVersion:     tls.VersionTLS12,
+	}
+}
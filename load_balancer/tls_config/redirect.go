@@ -0,0 +1,49 @@
+package tlsconfig
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RedirectHandler - перенаправление запросов по HTTP на тот же путь по HTTPS;
+// httpsPort - порт HTTPS-сервера, пусто или 443 - порт в адресе не указывается
+func RedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+
+		switch {
+		case httpsPort != "" && httpsPort != "443":
+			host = net.JoinHostPort(host, httpsPort)
+		case strings.Contains(host, ":"):
+			host = "[" + host + "]"
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		// 308 сохраняет метод и тело, в отличие от 301
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
+
+// HSTS - заголовок Strict-Transport-Security для ответов по HTTPS;
+// браузер после него сам обращается только по HTTPS в течение maxAge
+func HSTS(maxAge time.Duration, includeSubdomains bool, next http.Handler) http.Handler {
+	value := fmt.Sprintf("max-age=%d", int64(maxAge/time.Second))
+	if includeSubdomains {
+		value += "; includeSubDomains"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// по RFC 6797 заголовок в ответах по HTTP игнорируется, не отправляем его
+		if r.TLS != nil && maxAge > 0 {
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,255 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// самоподписанный сертификат для localhost, записанный в dir; возвращает пути и сам сертификат
+func selfSigned(t *testing.T, dir, name string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile, cert
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// HTTPS-сервер на ServerConfig, запущенный так же, как в cmd (ServeTLS без файлов);
+// возвращает адрес вида https://127.0.0.1:port
+func startTLS(t *testing.T, cr *CertReloader, h http.Handler) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &http.Server{Handler: h, TLSConfig: ServerConfig(cr), ErrorLog: log.New(io.Discard, "", 0)}
+	go srv.ServeTLS(ln, "", "") //nolint:errcheck
+	t.Cleanup(func() { srv.Close() })
+
+	return "https://" + ln.Addr().String()
+}
+
+func clientFor(certs ...*x509.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool},
+		ForceAttemptHTTP2: true,
+	}}
+}
+
+func TestServerNegotiatesHTTP2WithHSTS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, cert := selfSigned(t, dir, "server")
+
+	cr, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startTLS(t, cr, HSTS(24*time.Hour, true, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	resp, err := clientFor(cert).Get(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %s", resp.Proto)
+	}
+	if got := resp.Header.Get("Strict-Transport-Security"); got != "max-age=86400; includeSubDomains" {
+		t.Fatalf("unexpected HSTS header: %q", got)
+	}
+}
+
+func TestCertReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, first := selfSigned(t, dir, "server")
+
+	cr, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startTLS(t, cr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	servedCert := func() *x509.Certificate {
+		t.Helper()
+		// новое соединение на каждый запрос, чтобы пройти рукопожатие заново
+		client := clientFor(first)
+		client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+		client.Transport.(*http.Transport).DisableKeepAlives = true
+		resp, err := client.Get(srv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0]
+	}
+
+	if !servedCert().Equal(first) {
+		t.Fatal("expected the initial certificate")
+	}
+
+	// новый сертификат на месте старого: до Reload отдаётся прежний
+	newCert, newKey, second := selfSigned(t, t.TempDir(), "server")
+	for src, dst := range map[string]string{newCert: certFile, newKey: keyFile} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dst, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !servedCert().Equal(first) {
+		t.Fatal("expected the old certificate before reload")
+	}
+
+	if err := cr.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if !servedCert().Equal(second) {
+		t.Fatal("expected the new certificate after reload")
+	}
+
+	// битый файл не подменяет рабочий сертификат
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cr.Reload(); err == nil {
+		t.Fatal("expected error reloading a broken certificate")
+	}
+	if !servedCert().Equal(second) {
+		t.Fatal("expected the previous certificate to stay after a failed reload")
+	}
+}
+
+func TestHSTSOnlyOverTLS(t *testing.T) {
+	h := HSTS(time.Hour, false, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	if rec.Header().Get("Strict-Transport-Security") != "" {
+		t.Fatal("expected no HSTS over plain HTTP")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://example.com/", nil))
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=3600" {
+		t.Fatalf("unexpected HSTS header: %q", got)
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	cases := []struct {
+		port, host, want string
+	}{
+		{"443", "example.com", "https://example.com/api/login?x=1"},
+		{"", "example.com:80", "https://example.com/api/login?x=1"},
+		{"8443", "example.com:8080", "https://example.com:8443/api/login?x=1"},
+		{"8443", "[::1]:8080", "https://[::1]:8443/api/login?x=1"},
+		{"443", "[::1]", "https://[::1]/api/login?x=1"},
+	}
+
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodPost, "http://"+tc.host+"/api/login?x=1", nil)
+		rec := httptest.NewRecorder()
+		RedirectHandler(tc.port).ServeHTTP(rec, r)
+
+		if rec.Code != http.StatusPermanentRedirect {
+			t.Fatalf("%s: expected 308, got %d", tc.host, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.host, tc.want, got)
+		}
+	}
+}
+
+func TestBackendTransportTrustsCustomCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := selfSigned(t, dir, "backend")
+
+	cr, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := startTLS(t, cr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// без CA самоподписанный сертификат сервера отвергается
+	if _, err := http.DefaultTransport.RoundTrip(httptest.NewRequest(http.MethodGet, srv, nil)); err == nil {
+		t.Fatal("expected verification error without the custom CA")
+	}
+
+	transport, err := BackendTransport(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if _, err := BackendTransport(filepath.Join(dir, "missing.crt")); err == nil {
+		t.Fatal("expected error for a missing CA file")
+	}
+	if _, err := BackendTransport(keyFile); err == nil {
+		t.Fatal("expected error for a file without certificates")
+	}
+}
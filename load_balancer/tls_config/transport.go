@@ -0,0 +1,36 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"load_balancer/internal/messages"
+)
+
+// BackendTransport - транспорт до серверов по HTTPS, доверяющий системным корневым
+// сертификатам и дополнительно CA из caFile (например, внутреннему CA кластера)
+func BackendTransport(caFile string) (*http.Transport, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf(messages.ErrBackendCA, err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf(messages.ErrBackendCA, errors.New(caFile))
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	return transport, nil
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	var forwarded string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(Header)
+	}))
+
+	t.Run("continues client trace", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/get-tasks", nil)
+		req.Header.Set(Header, incoming)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		sc, ok := Parse(forwarded)
+		if !ok {
+			t.Fatalf("forwarded traceparent %q is invalid", forwarded)
+		}
+		if sc.TraceIDString() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("trace id = %s, want the client's", sc.TraceIDString())
+		}
+		if sc.SpanIDString() == "00f067aa0ba902b7" {
+			t.Error("server must receive the balancer span, not the client's")
+		}
+	})
+
+	t.Run("starts new trace", func(t *testing.T) {
+		for _, value := range []string{"", "garbage"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(Header, value)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			sc, ok := Parse(forwarded)
+			if !ok || !sc.Sampled {
+				t.Fatalf("traceparent %q for %q, want a new sampled trace", forwarded, value)
+			}
+		}
+	})
+}
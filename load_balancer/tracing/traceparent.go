@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// имя заголовка HTTP и ключа метаданных gRPC (W3C Trace Context)
+const Header = "traceparent"
+
+// флаг sampled в trace-flags
+const flagSampled = 0x01
+
+// SpanContext - идентификаторы операции, передаваемые между сервисами в traceparent
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid - идентификаторы не нулевые (нулевые W3C Trace Context запрещает)
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceIDString - идентификатор трассы в hex
+func (sc SpanContext) TraceIDString() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// SpanIDString - идентификатор операции в hex
+func (sc SpanContext) SpanIDString() string {
+	return hex.EncodeToString(sc.SpanID[:])
+}
+
+// Traceparent - значение заголовка traceparent версии 00
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceIDString() + "-" + sc.SpanIDString() + "-" + flags
+}
+
+// Parse - разбор traceparent; ok=false для неверного значения, тогда трасса начинается заново.
+// Значения будущих версий длиннее: берутся известные поля, остальное игнорируется
+func Parse(value string) (sc SpanContext, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || !isHex(version) {
+		return SpanContext{}, false
+	}
+	if version == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return SpanContext{}, false
+	}
+	if !isHex(traceID) || !isHex(spanID) || !isHex(flags) {
+		return SpanContext{}, false
+	}
+
+	hex.Decode(sc.TraceID[:], []byte(traceID)) //nolint:errcheck
+	hex.Decode(sc.SpanID[:], []byte(spanID))   //nolint:errcheck
+	var f [1]byte
+	hex.Decode(f[:], []byte(flags)) //nolint:errcheck
+	sc.Sampled = f[0]&flagSampled != 0
+
+	return sc, sc.IsValid()
+}
+
+// только строчные hex-цифры, как требует спецификация
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func newTraceID() (id [16]byte) {
+	for id == [16]byte{} {
+		rand.Read(id[:]) //nolint:errcheck
+	}
+	return id
+}
+
+func newSpanID() (id [8]byte) {
+	for id == [8]byte{} {
+		rand.Read(id[:]) //nolint:errcheck
+	}
+	return id
+}